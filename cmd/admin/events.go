@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+)
+
+func (a *admin) events(args []string) error {
+	if len(args) == 0 {
+		return errors.New("events: missing subcommand")
+	}
+
+	switch args[0] {
+	case "transfer":
+		return a.transferEvents(args[1:])
+	default:
+		return fmt.Errorf("events: unknown subcommand %q", args[0])
+	}
+}
+
+func (a *admin) transferEvents(args []string) error {
+	flags := flag.NewFlagSet("events transfer", flag.ContinueOnError)
+	to := flags.String("to", "", "user receiving the events")
+	from := flags.String("from", "", "user whose events are all transferred")
+	eventId := flags.String("event", "", "id of the single event to transfer")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *to == "" || (*from == "") == (*eventId == "") {
+		return errors.New("events transfer: -to and exactly one of -event or -from are required")
+	}
+
+	owner, err := a.findUser(*to)
+	if err != nil {
+		return err
+	}
+
+	if *eventId != "" {
+		id, err := parseId("event id", *eventId)
+		if err != nil {
+			return err
+		}
+
+		event, err := a.models.Events.Get(id)
+		if err != nil {
+			return err
+		}
+		if event == nil {
+			return fmt.Errorf("event %d not found", id)
+		}
+
+		if err := a.models.Events.Transfer(event.Id, owner.Id); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(a.out, "Transferred event %d (%s) to user %d (%s)\n", event.Id, event.Name, owner.Id, owner.Email)
+
+		return nil
+	}
+
+	previousOwner, err := a.findUser(*from)
+	if err != nil {
+		return err
+	}
+	if previousOwner.Id == owner.Id {
+		return errors.New("events transfer: -from and -to are the same user")
+	}
+
+	transferred, err := a.models.Events.TransferAll(previousOwner.Id, owner.Id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "Transferred %d event(s) from user %d (%s) to user %d (%s)\n", transferred,
+		previousOwner.Id, previousOwner.Email, owner.Id, owner.Email)
+
+	return nil
+}
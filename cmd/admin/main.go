@@ -0,0 +1,135 @@
+// Command admin manages users and their data directly in the database, for
+// the operations the API doesn't expose.
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const usage = `Usage: admin [flags] <command> [arguments]
+
+USER is either the id or the email of a user.
+
+Commands:
+  users list
+  users create -email EMAIL -name NAME [-password PASSWORD] [-role ROLE] [-verified]
+  users disable USER                     block logins and revoke sessions
+  users enable USER
+  users reset-password USER [-password PASSWORD]
+  users disable-2fa USER                 remove two-factor authentication
+  users grant-role USER ROLE             ROLE is "user" or "admin"
+  users purge USER [-yes]                delete the user and all their data
+  events transfer -to USER (-event ID | -from USER)
+
+When no password is given, a random one is generated and printed.
+
+Flags:
+`
+
+type admin struct {
+	models database.Models
+	out    io.Writer
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "admin:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("admin", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+
+	dbPath := flags.String("db", "./data.db", "path of the SQLite database")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("missing command")
+	}
+
+	if _, err := os.Stat(*dbPath); err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+
+	db, err := database.Open(*dbPath, database.Options{})
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer db.Close()
+
+	a := &admin{models: database.NewModels(db), out: out}
+	command, rest := flags.Arg(0), flags.Args()[1:]
+
+	switch command {
+	case "users":
+		return a.users(rest)
+	case "events":
+		return a.events(rest)
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+// findUser looks a user up by id or, when ref contains an @, by email.
+func (a *admin) findUser(ref string) (*database.User, error) {
+	var user *database.User
+	var err error
+
+	if strings.Contains(ref, "@") {
+		user, err = a.models.Users.GetByEmail(ref)
+	} else {
+		id, convErr := strconv.Atoi(ref)
+		if convErr != nil {
+			return nil, fmt.Errorf("invalid user %q: expected an id or an email", ref)
+		}
+		user, err = a.models.Users.Get(id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user %s not found", ref)
+	}
+
+	return user, nil
+}
+
+func parseId(name, value string) (int, error) {
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+
+	return id, nil
+}
+
+func generatePassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// minPasswordLength matches the validation of the registration endpoint.
+const minPasswordLength = 8
+
+func (a *admin) users(args []string) error {
+	if len(args) == 0 {
+		return errors.New("users: missing subcommand")
+	}
+
+	command, args := args[0], args[1:]
+
+	switch command {
+	case "list":
+		return a.listUsers()
+	case "create":
+		return a.createUser(args)
+	case "disable":
+		return a.disableUser(args)
+	case "enable":
+		return a.enableUser(args)
+	case "reset-password":
+		return a.resetPassword(args)
+	case "disable-2fa":
+		return a.disableTwoFactor(args)
+	case "grant-role":
+		return a.grantRole(args)
+	case "purge":
+		return a.purgeUser(args)
+	default:
+		return fmt.Errorf("users: unknown subcommand %q", command)
+	}
+}
+
+func (a *admin) listUsers() error {
+	users, err := a.models.Users.GetAll()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEMAIL\tNAME\tROLE\tVERIFIED\tSTATUS")
+
+	for _, user := range users {
+		status := "active"
+		switch {
+		case user.IsDisabled():
+			status = "disabled since " + user.DisabledAt.Format(time.RFC3339)
+		case user.IsLocked(time.Now()):
+			status = "locked until " + user.LockedUntil.Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%t\t%s\n", user.Id, user.Email, user.Name, user.Role,
+			user.EmailVerified, status)
+	}
+
+	return w.Flush()
+}
+
+// passwordOrGenerated hashes the given password, or a generated one which is
+// then printed since nobody else will ever see it.
+func (a *admin) passwordOrGenerated(password string) (string, error) {
+	if password == "" {
+		generated, err := generatePassword()
+		if err != nil {
+			return "", err
+		}
+
+		password = generated
+		fmt.Fprintf(a.out, "Generated password: %s\n", password)
+	} else if len(password) < minPasswordLength {
+		return "", fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hashedPassword), nil
+}
+
+func validRole(role string) error {
+	if role != database.RoleUser && role != database.RoleAdmin {
+		return fmt.Errorf("invalid role %q: expected %q or %q", role, database.RoleUser, database.RoleAdmin)
+	}
+
+	return nil
+}
+
+func (a *admin) createUser(args []string) error {
+	flags := flag.NewFlagSet("users create", flag.ContinueOnError)
+	email := flags.String("email", "", "email of the user")
+	name := flags.String("name", "", "name of the user")
+	password := flags.String("password", "", "password of the user (generated when empty)")
+	role := flags.String("role", database.RoleUser, `role of the user, "user" or "admin"`)
+	verified := flags.Bool("verified", false, "mark the email as verified")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" || *name == "" {
+		return errors.New("users create: -email and -name are required")
+	}
+	if err := validRole(*role); err != nil {
+		return err
+	}
+
+	existing, err := a.models.Users.GetByEmail(*email)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("a user with email %s already exists (id %d)", *email, existing.Id)
+	}
+
+	hashedPassword, err := a.passwordOrGenerated(*password)
+	if err != nil {
+		return err
+	}
+
+	user := &database.User{
+		Email:    *email,
+		Name:     *name,
+		Password: hashedPassword,
+		Role:     *role,
+	}
+	if err := a.models.Users.Insert(user); err != nil {
+		return err
+	}
+
+	if *verified {
+		if err := a.models.Users.MarkEmailVerified(user.Id); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(a.out, "Created user %d (%s)\n", user.Id, user.Email)
+
+	return nil
+}
+
+// singleUser parses the USER argument of the subcommands taking nothing else.
+func (a *admin) singleUser(command string, args []string) (*database.User, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("users %s: expected a single USER argument", command)
+	}
+
+	return a.findUser(args[0])
+}
+
+func (a *admin) disableUser(args []string) error {
+	user, err := a.singleUser("disable", args)
+	if err != nil {
+		return err
+	}
+
+	if user.IsDisabled() {
+		fmt.Fprintf(a.out, "User %d (%s) is already disabled\n", user.Id, user.Email)
+		return nil
+	}
+
+	if err := a.models.Users.Disable(user.Id, time.Now()); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "Disabled user %d (%s)\n", user.Id, user.Email)
+
+	return nil
+}
+
+func (a *admin) enableUser(args []string) error {
+	user, err := a.singleUser("enable", args)
+	if err != nil {
+		return err
+	}
+
+	if err := a.models.Users.Enable(user.Id); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "Enabled user %d (%s)\n", user.Id, user.Email)
+
+	return nil
+}
+
+// disableTwoFactor lets a user who lost their authenticator and recovery
+// codes log in with their password alone.
+func (a *admin) disableTwoFactor(args []string) error {
+	user, err := a.singleUser("disable-2fa", args)
+	if err != nil {
+		return err
+	}
+
+	if err := a.models.TwoFactor.Disable(user.Id); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "Disabled two-factor authentication of user %d (%s)\n", user.Id, user.Email)
+
+	return nil
+}
+
+func (a *admin) resetPassword(args []string) error {
+	if len(args) == 0 {
+		return errors.New("users reset-password: missing USER argument")
+	}
+
+	flags := flag.NewFlagSet("users reset-password", flag.ContinueOnError)
+	password := flags.String("password", "", "new password (generated when empty)")
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	user, err := a.findUser(args[0])
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := a.passwordOrGenerated(*password)
+	if err != nil {
+		return err
+	}
+
+	if err := a.models.Users.ResetPassword(user.Id, hashedPassword); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "Reset the password of user %d (%s) and revoked their sessions and API keys\n", user.Id, user.Email)
+
+	return nil
+}
+
+func (a *admin) grantRole(args []string) error {
+	if len(args) != 2 {
+		return errors.New("users grant-role: expected USER and ROLE arguments")
+	}
+
+	if err := validRole(args[1]); err != nil {
+		return err
+	}
+
+	user, err := a.findUser(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := a.models.Users.SetRole(user.Id, args[1]); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "User %d (%s) now has role %s\n", user.Id, user.Email, args[1])
+
+	return nil
+}
+
+func (a *admin) purgeUser(args []string) error {
+	if len(args) == 0 {
+		return errors.New("users purge: missing USER argument")
+	}
+
+	flags := flag.NewFlagSet("users purge", flag.ContinueOnError)
+	confirmed := flags.Bool("yes", false, "confirm the deletion")
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	user, err := a.findUser(args[0])
+	if err != nil {
+		return err
+	}
+
+	if !*confirmed {
+		events, err := a.models.Events.GetByOwnerIds([]int{user.Id})
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(a.out, "User %d (%s) owns %d event(s), which would be deleted with the user.\n",
+			user.Id, user.Email, len(events))
+		fmt.Fprintf(a.out, "Transfer them first with: admin events transfer -from %d -to USER\n", user.Id)
+
+		return errors.New("users purge: pass -yes to confirm")
+	}
+
+	deletedEvents, err := a.models.Users.Purge(user.Id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "Purged user %d (%s) and %d event(s)\n", user.Id, user.Email, deletedEvents)
+
+	return nil
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+)
+
+// personalData is everything the API holds about a user, as exported for
+// them. Secrets (password, key and webhook secrets, TOTP seed) are left out.
+type personalData struct {
+	ExportedAt time.Time      `json:"exportedAt"`
+	Profile    *database.User `json:"profile"`
+	// Events are the events the user organizes.
+	Events []*database.Event `json:"events"`
+	// Attendances are the events the user attends.
+	Attendances   []*database.Event        `json:"attendances"`
+	Messages      []*database.Message      `json:"messages"`
+	Webhooks      []*database.Webhook      `json:"webhooks"`
+	APIKeys       []*database.APIKey       `json:"apiKeys"`
+	Identities    []*database.Identity     `json:"identities"`
+	TwoFactor     twoFactorStatusResponse  `json:"twoFactor"`
+	LoginAttempts []*database.LoginAttempt `json:"loginAttempts"`
+}
+
+// exportFile is a file of the ZIP archive of an export.
+type exportFile struct {
+	name  string
+	value any
+}
+
+// files returns the parts of the export as the files of the ZIP archive.
+func (d *personalData) files() []exportFile {
+	return []exportFile{
+		{"profile.json", d.Profile},
+		{"events.json", d.Events},
+		{"attendances.json", d.Attendances},
+		{"messages.json", d.Messages},
+		{"webhooks.json", d.Webhooks},
+		{"api_keys.json", d.APIKeys},
+		{"identities.json", d.Identities},
+		{"two_factor.json", d.TwoFactor},
+		{"login_attempts.json", d.LoginAttempts},
+	}
+}
+
+type deleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+	// Events tells what happens to the events the user organizes: "transfer"
+	// gives them to the user TransferTo, "delete" cancels them.
+	Events     string `json:"events" binding:"required,oneof=transfer delete"`
+	TransferTo int    `json:"transferTo" binding:"required_if=Events transfer"`
+	// Code or RecoveryCode is required when two-factor authentication is
+	// enabled.
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+func (app *application) collectPersonalData(user *database.User) (*personalData, error) {
+	data := personalData{ExportedAt: time.Now().UTC(), Profile: user}
+
+	var err error
+
+	if data.Events, err = app.models.Events.GetByOwnerIds([]int{user.Id}); err != nil {
+		return nil, err
+	}
+	if data.Attendances, err = app.models.Attendees.GetEventsByAttendee(user.Id); err != nil {
+		return nil, err
+	}
+	if data.Messages, err = app.models.Messages.GetByUser(user.Id); err != nil {
+		return nil, err
+	}
+	if data.Webhooks, err = app.models.Webhooks.GetByUser(user.Id); err != nil {
+		return nil, err
+	}
+	if data.APIKeys, err = app.models.APIKeys.GetByUser(user.Id); err != nil {
+		return nil, err
+	}
+	if data.Identities, err = app.models.Identities.GetByUser(user.Id); err != nil {
+		return nil, err
+	}
+	if data.LoginAttempts, err = app.models.LoginAttempts.GetByEmail(user.Email); err != nil {
+		return nil, err
+	}
+
+	twoFactor, err := app.models.TwoFactor.Get(user.Id)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.IsEnabled() {
+		data.TwoFactor = twoFactorStatusResponse{Enabled: true, EnabledAt: twoFactor.EnabledAt}
+		if data.TwoFactor.RecoveryCodesRemaining, err = app.models.TwoFactor.CountRecoveryCodes(user.Id); err != nil {
+			return nil, err
+		}
+	}
+
+	return &data, nil
+}
+
+// ExportPersonalData downloads everything held about the authenticated user
+//
+//	@Summary		Exports the personal data of the authenticated user
+//	@Description	Downloads the profile, organized events, attendances, chat messages, webhooks, API keys, linked identities, two-factor status and login attempts of the user, as a single JSON document or as a ZIP archive with one JSON file per kind of data. Secrets are never included.
+//	@Tags			users
+//	@Produce		json
+//	@Produce		application/zip
+//	@Param			format	query		string	false	"json (default) or zip"
+//	@Success		200		{object}	personalData
+//	@Router			/api/v1/users/me/export [get]
+//	@Security		BearerAuth
+func (app *application) exportPersonalData(ctx *gin.Context) {
+	format := ctx.DefaultQuery("format", "json")
+	if format != "json" && format != "zip" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Format must be json or zip"})
+		return
+	}
+
+	user := app.GetUserFromContext(ctx)
+
+	data, err := app.collectPersonalData(user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retreive personal data"})
+		return
+	}
+
+	filename := fmt.Sprintf("personal-data-%d-%s.%s", user.Id, data.ExportedAt.Format("20060102"), format)
+	ctx.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	ctx.Header("Cache-Control", "no-store")
+
+	if format == "json" {
+		ctx.IndentedJSON(http.StatusOK, data)
+		return
+	}
+
+	ctx.Header("Content-Type", "application/zip")
+	ctx.Status(http.StatusOK)
+
+	archive := zip.NewWriter(ctx.Writer)
+
+	for _, file := range data.files() {
+		w, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: data.ExportedAt,
+		})
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.value); err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		ctx.Error(err)
+	}
+}
+
+// DeleteAccount deletes the account of the authenticated user
+//
+//	@Summary		Deletes the authenticated user
+//	@Description	Deletes the account of the user and all their data, after checking their password and, when enabled, a second factor. The events they organize are either transferred to another user or deleted, in which case the attendees are told the event was cancelled.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		deleteAccountRequest	true	"Confirmation"
+//	@Success		200		{object}	map[string]any
+//	@Failure		403		{object}	map[string]string
+//	@Failure		422		{object}	map[string]string
+//	@Router			/api/v1/users/me [delete]
+//	@Security		BearerAuth
+func (app *application) deleteAccount(ctx *gin.Context) {
+	var request deleteAccountRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := app.GetUserFromContext(ctx)
+
+	var newOwner *database.User
+	if request.Events == "transfer" {
+		if request.TransferTo == user.Id {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Events can't be transferred to yourself"})
+			return
+		}
+
+		var err error
+		newOwner, err = app.models.Users.Get(request.TransferTo)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+			return
+		}
+
+		if newOwner == nil || newOwner.IsDisabled() {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "User to transfer the events to not found"})
+			return
+		}
+	}
+
+	if !app.checkCurrentPassword(ctx, user, request.Password) {
+		return
+	}
+
+	twoFactor, err := app.models.TwoFactor.Get(user.Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	if twoFactor.IsEnabled() {
+		if request.Code == "" && request.RecoveryCode == "" {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Authentication code required"})
+			return
+		}
+
+		valid, err := app.verifySecondFactor(twoFactor, twoFactorRequest{Code: request.Code, RecoveryCode: request.RecoveryCode})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+			return
+		}
+		if !valid {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid authentication code"})
+			return
+		}
+	}
+
+	response := gin.H{"message": "Account deleted"}
+
+	if newOwner != nil {
+		transferred, err := app.models.Users.PurgeTransferringEvents(user.Id, newOwner.Id)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete account"})
+			return
+		}
+		response["eventsTransferred"] = transferred
+	} else {
+		deleted, err := app.models.Users.Purge(user.Id)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete account"})
+			return
+		}
+		response["eventsDeleted"] = deleted
+
+		app.outbox.Wake()
+	}
+
+	app.notify(user, "account_deleted", nil)
+
+	ctx.JSON(http.StatusOK, response)
+}
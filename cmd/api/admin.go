@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UnlockUser unlocks a user account locked after too many failed logins
+//
+//	@Summary		Unlocks a user account
+//	@Description	Clears the failed login counter and lockout of a user
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int	true	"User ID"
+//	@Success		200	{object}	database.User
+//	@Router			/api/v1/admin/users/{id}/unlock [post]
+//	@Security		BearerAuth
+func (app *application) unlockUser(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	user, err := app.models.Users.Get(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retreive user"})
+		return
+	}
+	if user == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := app.models.Users.ResetLoginFailures(user.Id); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock user"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
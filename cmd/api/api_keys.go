@@ -0,0 +1,164 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+)
+
+// apiKeyPrefix marks the keys issued by the API, which makes them easy to spot
+// by secret scanners and in logs.
+const apiKeyPrefix = "evk_"
+
+type apiKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// apiKeyResponse is only returned when a key is created, the single time the
+// key itself is shown.
+type apiKeyResponse struct {
+	*database.APIKey
+	Key string `json:"key"`
+}
+
+// generateAPIKey returns a new key to hand out to the user together with the
+// hash that should be stored in the database.
+func generateAPIKey() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	return key, hashToken(key), nil
+}
+
+// CreateAPIKey creates a personal API key
+//
+//	@Summary		Creates an API key
+//	@Description	Creates a named API key limited to the given scopes, to be sent in the X-API-Key header or as "Authorization: ApiKey <key>". The key is only returned here. API keys can't be used to manage API keys, and can only get scopes the token creating them has.
+//	@Tags			api-keys
+//	@Accept			json
+//	@Produce		json
+//	@Param			apiKey	body		apiKeyRequest	true	"API key"
+//	@Success		201		{object}	apiKeyResponse
+//	@Failure		403		{object}	map[string]string
+//	@Router			/api/v1/api-keys [post]
+//	@Security		BearerAuth
+func (app *application) createAPIKey(ctx *gin.Context) {
+	var request apiKeyRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Expiry must be in the future"})
+		return
+	}
+
+	scopes, err := normalizeScopes(request.Scopes)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A key can't do more than the token creating it.
+	granted := app.GetScopesFromContext(ctx)
+	for _, scope := range scopes {
+		if !hasScope(granted, scope) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": errMissingScope(scope).Error()})
+			return
+		}
+	}
+
+	key, keyHash, err := generateAPIKey()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	user := app.GetUserFromContext(ctx)
+	apiKey := database.APIKey{
+		UserId:    user.Id,
+		Name:      request.Name,
+		Prefix:    key[:len(apiKeyPrefix)+6],
+		KeyHash:   keyHash,
+		Scopes:    scopes,
+		ExpiresAt: request.ExpiresAt,
+	}
+
+	if err := app.models.APIKeys.Insert(&apiKey); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, apiKeyResponse{APIKey: &apiKey, Key: key})
+}
+
+// GetAPIKeys returns the API keys of the user
+//
+//	@Summary		Returns the user's API keys
+//	@Description	Returns the API keys of the authenticated user with their scopes, expiry and last use
+//	@Tags			api-keys
+//	@Produce		json
+//	@Success		200	{object}	[]database.APIKey
+//	@Router			/api/v1/api-keys [get]
+//	@Security		BearerAuth
+func (app *application) getAPIKeys(ctx *gin.Context) {
+	user := app.GetUserFromContext(ctx)
+
+	keys, err := app.models.APIKeys.GetByUser(user.Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retreive API keys"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKey revokes an API key
+//
+//	@Summary		Revokes an API key
+//	@Description	Deletes an API key, which stops working immediately
+//	@Tags			api-keys
+//	@Produce		json
+//	@Param			id	path	int	true	"API key ID"
+//	@Success		204
+//	@Router			/api/v1/api-keys/{id} [delete]
+//	@Security		BearerAuth
+func (app *application) revokeAPIKey(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	apiKey, err := app.models.APIKeys.Get(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retreive API key"})
+		return
+	}
+
+	user := app.GetUserFromContext(ctx)
+	if apiKey == nil || apiKey.UserId != user.Id {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+
+	if err := app.models.APIKeys.Delete(apiKey.Id); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gumeeee/rest-api-in-gin/pkg/client"
+)
+
+func TestAPIKeyScopes(t *testing.T) {
+	ctx := context.Background()
+	_, server := newTestServer(t, nil)
+
+	c, _ := newTestUser(t, server, "alice@example.com")
+
+	newKeyClient := func(scopes ...string) *client.Client {
+		apiKey, err := c.CreateAPIKey(ctx, client.APIKeyInput{Name: "test", Scopes: scopes})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return client.New(server.URL, client.WithAPIKey(apiKey.Key), client.WithRetries(0, 0, 0))
+	}
+
+	reader := newKeyClient(scopeEventsRead)
+	if _, err := reader.CreateEvent(ctx, testEventInput("Go meetup")); !errors.Is(err, client.ErrForbidden) {
+		t.Errorf("CreateEvent with an events:read key: got %v, want ErrForbidden", err)
+	}
+	if _, err := reader.ListWebhooks(ctx); !errors.Is(err, client.ErrForbidden) {
+		t.Errorf("ListWebhooks with an events:read key: got %v, want ErrForbidden", err)
+	}
+
+	writer := newKeyClient(scopeEventsWrite, scopeWebhooksRead)
+	if _, err := writer.CreateEvent(ctx, testEventInput("Go meetup")); err != nil {
+		t.Errorf("CreateEvent with an events:write key: %v", err)
+	}
+	if _, err := writer.ListWebhooks(ctx); err != nil {
+		t.Errorf("ListWebhooks with a webhooks:read key: %v", err)
+	}
+	if _, err := writer.ListAPIKeys(ctx); !errors.Is(err, client.ErrForbidden) {
+		t.Errorf("ListAPIKeys with an API key: got %v, want ErrForbidden", err)
+	}
+
+	// Access tokens aren't limited by scopes.
+	if _, err := c.CreateEvent(ctx, testEventInput("Go meetup")); err != nil {
+		t.Errorf("CreateEvent with an access token: %v", err)
+	}
+}
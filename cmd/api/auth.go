@@ -1,11 +1,13 @@
 package main
 
 import (
+	"errors"
+	"log"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt"
 	"github.com/gumeeee/rest-api-in-gin/internal/database"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -19,21 +21,58 @@ type registerRequest struct {
 type loginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=8"`
+	// Scopes limits the token to some scopes. It gets all of them when empty.
+	Scopes []string `json:"scopes"`
 }
 
+// loginResponse holds the access token, or when the user has two-factor
+// authentication enabled, the challenge token to send to /auth/login/2fa with
+// a code.
 type loginResponse struct {
-	Token string `json:"token"`
+	Token             string   `json:"token,omitempty"`
+	Scopes            []string `json:"scopes,omitempty"`
+	TwoFactorRequired bool     `json:"twoFactorRequired,omitempty"`
+	ChallengeToken    string   `json:"challengeToken,omitempty"`
 }
 
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+const passwordResetTTL = time.Hour
+
+// passwordResetMaxEmails is the number of password reset emails sent to an
+// address within passwordResetTTL.
+const passwordResetMaxEmails = 3
+
+// dummyPasswordHash is compared with the passwords sent for emails without an
+// account, which then take as long to refuse as wrong passwords.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, err := bcrypt.GenerateFromPassword([]byte("not the password of any account"), bcrypt.DefaultCost)
+	if err != nil {
+		panic(err)
+	}
+
+	return hash
+})
+
 // Login logs in a user
 //
 //	@Summary		Logs in a user
-//	@Description	Logs in a user
+//	@Description	Logs in a user. The token is limited to the requested scopes, or has all of them when none are given. When two-factor authentication is enabled, a challenge token is returned instead, to be completed with /auth/login/2fa.
 //	@Tags			auth
 //	@Accept			json
 //	@Produce		json
 //	@Param			user	body	loginRequest	true	"User"
 //	@Success		200	{object}	loginResponse
+//	@Failure		403	{object}	map[string]string
+//	@Failure		423	{object}	map[string]string
+//	@Failure		429	{object}	map[string]string
 //	@Router			/api/v1/auth/login [post]
 func (app *application) login(ctx *gin.Context) {
 	var auth loginRequest
@@ -43,35 +82,109 @@ func (app *application) login(ctx *gin.Context) {
 		return
 	}
 
+	scopes := allScopes
+	if len(auth.Scopes) > 0 {
+		var err error
+		if scopes, err = normalizeScopes(auth.Scopes); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	ip := ctx.ClientIP()
+
+	if app.ipThrottled(ctx, ip, now) {
+		return
+	}
+
 	existingUser, err := app.models.Users.GetByEmail(auth.Email)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	// Unknown emails are throttled like accounts and cost a password check
+	// too, so that neither the response nor its timing tells whether an
+	// email has an account.
 	if existingUser == nil {
+		unknown, err := app.unknownAccount(auth.Email)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+			return
+		}
+
+		if app.accountThrottled(ctx, unknown, now) {
+			return
+		}
+
+		bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(auth.Password))
+
+		app.recordLoginAttempt(auth.Email, ip, false)
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
 
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Somethin went wrong"})
+	if app.accountThrottled(ctx, existingUser, now) {
 		return
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(existingUser.Password), []byte(auth.Password))
 	if err != nil {
+		app.recordLoginAttempt(auth.Email, ip, false)
+		if err := app.registerFailedLogin(existingUser, now); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+			return
+		}
+
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userId": existingUser.Id,
-		"expr":   time.Now().Add(time.Hour * 24).Unix(),
-	})
+	app.recordLoginAttempt(auth.Email, ip, true)
+	if existingUser.IsDisabled() {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+		return
+	}
 
-	tokenString, err := token.SignedString([]byte(app.jwtSecret))
+	twoFactor, err := app.models.TwoFactor.Get(existingUser.Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	// Failed logins are only reset once the second factor is checked, so
+	// that knowing the password doesn't allow guessing codes forever.
+	if twoFactor.IsEnabled() {
+		challenge, err := app.createLoginChallengeToken(existingUser, scopes)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, loginResponse{TwoFactorRequired: true, ChallengeToken: challenge})
+		return
+	}
+
+	app.issueLoginToken(ctx, existingUser, scopes)
+}
+
+// issueLoginToken ends a successful login by returning an access token.
+func (app *application) issueLoginToken(ctx *gin.Context, user *database.User, scopes []string) {
+	if user.FailedLogins > 0 {
+		if err := app.models.Users.ResetLoginFailures(user.Id); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+			return
+		}
+	}
+
+	tokenString, err := app.createAccessToken(user, scopes)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, loginResponse{Token: tokenString})
+	ctx.JSON(http.StatusOK, loginResponse{Token: tokenString, Scopes: scopes})
 }
 
 // RegisterUser registers a new user
@@ -82,6 +195,7 @@ func (app *application) login(ctx *gin.Context) {
 // @Produce		json
 // @Param			user	body		registerRequest	true	"User"
 // @Success		201	{object}	database.User
+// @Failure		409	{object}	map[string]string
 // @Router			/api/v1/auth/register [post]
 func (app *application) registerUser(ctx *gin.Context) {
 	var register registerRequest
@@ -106,9 +220,206 @@ func (app *application) registerUser(ctx *gin.Context) {
 
 	err = app.models.Users.Insert(&user)
 	if err != nil {
+		if errors.Is(err, database.ErrDuplicateEmail) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Email is already registered"})
+			return
+		}
+
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create user"})
 		return
 	}
 
+	app.sendVerificationEmail(&user)
+
 	ctx.JSON(http.StatusCreated, user)
 }
+
+// ForgotPassword sends a password reset link to a user
+//
+//	@Summary		Requests a password reset
+//	@Description	Sends a single-use password reset token to the given email if an account exists, at most 3 times an hour. Requests are limited per IP like failed logins.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		forgotPasswordRequest	true	"Email"
+//	@Success		202		{object}	map[string]string
+//	@Failure		429		{object}	map[string]string
+//	@Router			/api/v1/auth/forgot-password [post]
+func (app *application) forgotPassword(ctx *gin.Context) {
+	var request forgotPasswordRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now().UTC()
+	ip := ctx.ClientIP()
+
+	if app.ipThrottled(ctx, ip, now) || app.passwordResetsThrottled(ctx, ip, now) {
+		return
+	}
+
+	// The response is the same whether or not the account exists so the
+	// endpoint can't be used to find out which emails are registered.
+	response := gin.H{"message": "If the account exists, a password reset link has been sent"}
+
+	// Requests for an email beyond the limit are answered as usual, but no
+	// more emails are sent to the address.
+	requests, err := app.models.PasswordResets.CountRequestsByEmail(request.Email, now.Add(-passwordResetTTL))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	if err := app.models.PasswordResets.InsertRequest(request.Email, ip, now); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	if requests >= passwordResetMaxEmails {
+		ctx.JSON(http.StatusAccepted, response)
+		return
+	}
+
+	user, err := app.models.Users.GetByEmail(request.Email)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+	if user == nil {
+		ctx.JSON(http.StatusAccepted, response)
+		return
+	}
+
+	token, tokenHash, err := generateOneTimeToken()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	reset := database.PasswordReset{
+		UserId:    user.Id,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	}
+
+	if err := app.models.PasswordResets.Insert(&reset); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	app.notify(user, "password_reset", map[string]any{
+		"ResetURL": app.baseURL + "/reset-password?token=" + token,
+	})
+
+	ctx.JSON(http.StatusAccepted, response)
+}
+
+// ResetPassword sets a new password using a reset token
+//
+//	@Summary		Resets a password
+//	@Description	Sets a new password using a token sent by forgot-password and signs out every existing session
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		resetPasswordRequest	true	"Token and new password"
+//	@Success		200		{object}	map[string]string
+//	@Router			/api/v1/auth/reset-password [post]
+func (app *application) resetPassword(ctx *gin.Context) {
+	var request resetPasswordRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	_, err = app.models.PasswordResets.ResetPassword(hashToken(request.Token), string(hashedPassword), time.Now())
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidToken) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not reset password"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Password has been reset"})
+}
+
+func (app *application) sendVerificationEmail(user *database.User) {
+	token, err := app.createEmailVerificationToken(user)
+	if err != nil {
+		log.Printf("Failed to create verification token for %s: %v", user.Email, err)
+		return
+	}
+
+	app.notify(user, "verify_email", map[string]any{
+		"VerifyURL": app.baseURL + "/api/v1/auth/verify-email?token=" + token,
+	})
+}
+
+// VerifyEmail confirms the email address of a user
+//
+//	@Summary		Confirms an email address
+//	@Description	Marks the email of a user as verified using the signed link sent on registration
+//	@Tags			auth
+//	@Produce		json
+//	@Param			token	query		string	true	"Verification token"
+//	@Success		200		{object}	map[string]string
+//	@Router			/api/v1/auth/verify-email [get]
+func (app *application) verifyEmail(ctx *gin.Context) {
+	userId, email, err := app.parseEmailVerificationToken(ctx.Query("token"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+		return
+	}
+
+	user, err := app.models.Users.Get(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	if user == nil || user.Email != database.NormalizeEmail(email) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+		return
+	}
+
+	if !user.EmailVerified {
+		if err := app.models.Users.MarkEmailVerified(user.Id); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not verify email"})
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+// ResendVerificationEmail sends a new verification link
+//
+//	@Summary		Resends the verification email
+//	@Description	Sends a new email verification link to the authenticated user
+//	@Tags			auth
+//	@Produce		json
+//	@Success		202	{object}	map[string]string
+//	@Router			/api/v1/auth/verify-email/resend [post]
+//	@Security		BearerAuth
+func (app *application) resendVerificationEmail(ctx *gin.Context) {
+	user := app.GetUserFromContext(ctx)
+	if user.EmailVerified {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Email is already verified"})
+		return
+	}
+
+	app.sendVerificationEmail(user)
+
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent"})
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// postJSON posts body as JSON to path and returns the recorded response.
+func postJSON(handler http.Handler, path string, body any) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestLoginDoesNotRevealAccounts(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy func(*loginPolicy)
+		want   []int
+	}{
+		{
+			name:   "delay after a failure",
+			policy: func(p *loginPolicy) { p.baseDelay = time.Minute },
+			want:   []int{http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusTooManyRequests},
+		},
+		{
+			name:   "lockout",
+			policy: func(p *loginPolicy) { p.baseDelay, p.maxFailures = 0, 3 },
+			want: []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusUnauthorized,
+				http.StatusLocked, http.StatusLocked},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(t)
+			tt.policy(&app.loginPolicy)
+			handler := app.routes()
+
+			hash, err := bcrypt.GenerateFromPassword([]byte("password1"), bcrypt.MinCost)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := app.models.Users.Insert(&database.User{Name: "Alice", Email: "alice@example.com", Password: string(hash)}); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, email := range []string{"alice@example.com", "nobody@example.com"} {
+				for i, want := range tt.want {
+					rec := postJSON(handler, "/api/v1/auth/login", loginRequest{Email: email, Password: "wrong password"})
+
+					if rec.Code != want {
+						t.Errorf("%s, attempt %d: got %d %s, want %d", email, i+1, rec.Code, rec.Body, want)
+					}
+					if want != http.StatusUnauthorized && rec.Header().Get("Retry-After") == "" {
+						t.Errorf("%s, attempt %d: no Retry-After header", email, i+1)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestForgotPasswordThrottled(t *testing.T) {
+	app := newTestApp(t)
+	app.loginPolicy.ipMaxFailures = 5
+	handler := app.routes()
+
+	if err := app.models.Users.Insert(&database.User{Name: "Alice", Email: "alice@example.com", Password: "hash"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 5 {
+		rec := postJSON(handler, "/api/v1/auth/forgot-password", forgotPasswordRequest{Email: "alice@example.com"})
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("request %d: got %d %s, want 202", i+1, rec.Code, rec.Body)
+		}
+	}
+
+	// Every reset link sent replaced the previous one, and the ids keep
+	// counting them.
+	var sent int
+	if err := app.db.QueryRow("SELECT MAX(id) FROM password_resets").Scan(&sent); err != nil {
+		t.Fatal(err)
+	}
+	if sent != passwordResetMaxEmails {
+		t.Errorf("sent %d reset links, want %d", sent, passwordResetMaxEmails)
+	}
+
+	rec := postJSON(handler, "/api/v1/auth/forgot-password", forgotPasswordRequest{Email: "nobody@example.com"})
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("request over the IP limit: got %d %s, want 429 with Retry-After", rec.Code, rec.Body)
+	}
+}
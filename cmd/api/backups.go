@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gumeeee/rest-api-in-gin/internal/backup"
+)
+
+// backupSchedule configures the periodic backups of the database.
+type backupSchedule struct {
+	dir      string        // where backups are written, empty to disable them
+	interval time.Duration // time between two backups
+	keep     int           // number of backups kept, older ones are deleted
+}
+
+// runBackups backs the database up until ctx is cancelled.
+func (app *application) runBackups(ctx context.Context) {
+	if app.backups.dir == "" {
+		return
+	}
+
+	ticker := time.NewTicker(app.backups.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		app.backUp(ctx, time.Now())
+	}
+}
+
+func (app *application) backUp(ctx context.Context, now time.Time) {
+	info, err := backup.CreateInDir(ctx, app.db, app.backups.dir, now)
+	if err != nil {
+		log.Printf("Failed to back up the database: %v", err)
+		return
+	}
+
+	log.Printf("Backed up the database to %s (%d bytes, schema version %d)", info.Path, info.Size, info.Version)
+
+	removed, err := backup.Prune(app.backups.dir, app.backups.keep)
+	if err != nil {
+		log.Printf("Failed to delete old backups: %v", err)
+	}
+
+	for _, path := range removed {
+		log.Printf("Deleted old backup %s", path)
+	}
+}
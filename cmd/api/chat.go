@@ -0,0 +1,377 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+	"github.com/gumeeee/rest-api-in-gin/internal/pubsub"
+)
+
+const (
+	chatWriteWait      = 10 * time.Second
+	chatPongWait       = 60 * time.Second
+	chatPingInterval   = chatPongWait * 9 / 10
+	chatMaxFrameSize   = 16 << 10
+	chatMaxMessageSize = 2000
+	chatHistoryLimit   = 50
+	chatReplayLimit    = 500
+)
+
+var chatUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// chatFrame is the JSON document exchanged over the chat WebSocket. Clients
+// send frames of type "message" (with a body) or "ping"; the server sends
+// "message", "pong", "error" and "truncated" frames.
+type chatFrame struct {
+	Type    string            `json:"type"`
+	Body    string            `json:"body,omitempty"`
+	Message *database.Message `json:"message,omitempty"`
+	Error   string            `json:"error,omitempty"`
+	// Before is set on the truncated frame preceding a replay that left out
+	// older missed messages, which can be paged through with the before
+	// parameter of /messages.
+	Before int `json:"before,omitempty"`
+}
+
+func eventChatTopic(eventId int) string {
+	return "chat:" + strconv.Itoa(eventId)
+}
+
+// canJoinEventChat reports whether the user owns or attends the event.
+func (app *application) canJoinEventChat(user *database.User, event *database.Event) (bool, error) {
+	if event.OwnerId == user.Id {
+		return true, nil
+	}
+
+	attendee, err := app.models.Attendees.GetByEventAndAttendeeId(event.Id, user.Id)
+	if err != nil {
+		return false, err
+	}
+
+	return attendee != nil, nil
+}
+
+// tokenFromQuery lets clients that can't set headers on a WebSocket handshake,
+// such as browsers, pass their access token as the token query parameter.
+// requestLogger keeps it out of the logs.
+func tokenFromQuery() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if token := ctx.Query("token"); token != "" && ctx.GetHeader("Authorization") == "" {
+			ctx.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		ctx.Next()
+	}
+}
+
+// chatEventFromPath loads the event named by the :id path parameter and makes
+// sure the user may take part in its chat. It writes the error response itself
+// and returns false when the handler should stop.
+func (app *application) chatEventFromPath(ctx *gin.Context) (*database.Event, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return nil, false
+	}
+
+	event, err := app.models.Events.Get(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retreive event"})
+		return nil, false
+	}
+	if event == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return nil, false
+	}
+
+	allowed, err := app.canJoinEventChat(app.GetUserFromContext(ctx), event)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retreive attendee"})
+		return nil, false
+	}
+	if !allowed {
+		ctx.JSON(http.StatusForbidden,
+			gin.H{"error": "Only the owner and attendees can access this event's chat"})
+		return nil, false
+	}
+
+	return event, true
+}
+
+// GetEventMessages returns the chat history of an event
+//
+//	@Summary		Returns the chat history of an event
+//	@Description	Returns chat messages newest first. Pass the id of the oldest message received as before to get the previous page.
+//	@Tags			chat
+//	@Produce		json
+//	@Param			id		path		int	true	"Event ID"
+//	@Param			before	query		int	false	"Only return messages older than this message ID"
+//	@Param			limit	query		int	false	"Maximum number of messages (default 50, max 100)"
+//	@Success		200		{object}	[]database.Message
+//	@Router			/api/v1/events/{id}/messages [get]
+//	@Security		BearerAuth
+func (app *application) getEventMessages(ctx *gin.Context) {
+	event, ok := app.chatEventFromPath(ctx)
+	if !ok {
+		return
+	}
+
+	before, err := strconv.Atoi(ctx.DefaultQuery("before", "0"))
+	if err != nil || before < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid before parameter"})
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(chatHistoryLimit)))
+	if err != nil || limit < 1 || limit > 100 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Limit must be between 1 and 100"})
+		return
+	}
+
+	messages, err := app.models.Messages.GetBefore(event.Id, before, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retreive messages"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, messages)
+}
+
+// EventChat joins the chat of an event
+//
+//	@Summary		Joins the chat of an event
+//	@Description	Upgrades to a WebSocket carrying JSON frames. Send {"type":"message","body":"..."} to post and {"type":"ping"} to check the connection. Browsers may pass the access token as the token query parameter. After reconnecting, pass the id of the last message received as after to get what was missed: at most the latest 500 messages are replayed, preceded by a {"type":"truncated","before":ID} frame when there were more, whose before can be passed to /messages to get the rest. The connection is closed when the user is removed from the event or the event is deleted.
+//	@Tags			chat
+//	@Param			id		path	int		true	"Event ID"
+//	@Param			after	query	int		false	"Replay messages newer than this message ID"
+//	@Param			token	query	string	false	"Access token, when the Authorization header can't be set"
+//	@Success		101
+//	@Router			/api/v1/events/{id}/chat [get]
+//	@Security		BearerAuth
+func (app *application) eventChat(ctx *gin.Context) {
+	event, ok := app.chatEventFromPath(ctx)
+	if !ok {
+		return
+	}
+
+	after, err := strconv.Atoi(ctx.DefaultQuery("after", "0"))
+	if err != nil || after < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid after parameter"})
+		return
+	}
+
+	user := app.GetUserFromContext(ctx)
+
+	// Subscribe before upgrading so no message posted in between is missed.
+	sub := app.hub.Subscribe(eventChatTopic(event.Id))
+	defer sub.Close()
+
+	// The event's changes tell when the user is removed from it.
+	changes := app.hub.Subscribe(eventStreamTopic(event.Id))
+	defer changes.Close()
+
+	conn, err := chatUpgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		// The upgrader already wrote an error response.
+		return
+	}
+	defer conn.Close()
+
+	// Replay what a reconnecting client missed before handing the connection
+	// to the writer, which skips anything already replayed.
+	lastId := after
+	if after > 0 {
+		missed, err := app.models.Messages.GetAfter(event.Id, after, chatReplayLimit+1)
+		if err != nil {
+			log.Printf("Failed to retreive missed chat messages of event %d: %v", event.Id, err)
+		}
+
+		if len(missed) > chatReplayLimit {
+			missed = missed[1:]
+
+			conn.SetWriteDeadline(time.Now().Add(chatWriteWait))
+			if err := conn.WriteJSON(chatFrame{Type: "truncated", Before: missed[0].Id}); err != nil {
+				return
+			}
+		}
+
+		for _, message := range missed {
+			conn.SetWriteDeadline(time.Now().Add(chatWriteWait))
+			if err := conn.WriteJSON(chatFrame{Type: "message", Message: message}); err != nil {
+				return
+			}
+			lastId = message.Id
+		}
+	}
+
+	outgoing := make(chan chatFrame, 16)
+	done := make(chan struct{})
+	go app.writeChat(conn, user, sub, changes, lastId, outgoing, done)
+
+	app.readChat(conn, user, event, outgoing, done)
+}
+
+// readChat handles the frames sent by the client until the connection closes.
+func (app *application) readChat(conn *websocket.Conn, user *database.User, event *database.Event, outgoing chan<- chatFrame, done <-chan struct{}) {
+	conn.SetReadLimit(chatMaxFrameSize)
+	conn.SetReadDeadline(time.Now().Add(chatPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(chatPongWait))
+	})
+
+	reply := func(frame chatFrame) bool {
+		select {
+		case outgoing <- frame:
+			return true
+		case <-done:
+			return false
+		}
+	}
+
+	for {
+		var frame chatFrame
+		if err := conn.ReadJSON(&frame); err != nil {
+			return
+		}
+
+		conn.SetReadDeadline(time.Now().Add(chatPongWait))
+
+		switch frame.Type {
+		case "ping":
+			if !reply(chatFrame{Type: "pong"}) {
+				return
+			}
+		case "message":
+			body := strings.TrimSpace(frame.Body)
+			if body == "" || utf8.RuneCountInString(body) > chatMaxMessageSize {
+				if !reply(chatFrame{Type: "error", Error: "Message must be between 1 and 2000 characters"}) {
+					return
+				}
+				continue
+			}
+
+			// Attendees may have been removed since they joined.
+			allowed, err := app.canJoinEventChat(user, event)
+			if err != nil || !allowed {
+				reply(chatFrame{Type: "error", Error: "You can no longer post in this chat"})
+				return
+			}
+
+			message := database.Message{
+				EventId:  event.Id,
+				UserId:   user.Id,
+				UserName: user.Name,
+				Body:     body,
+			}
+
+			if err := app.models.Messages.Insert(&message); err != nil {
+				if !reply(chatFrame{Type: "error", Error: "Failed to send message"}) {
+					return
+				}
+				continue
+			}
+
+			app.hub.Publish(eventChatTopic(event.Id), pubsub.Message{
+				Id:   message.Id,
+				Type: "message",
+				Data: &message,
+			})
+		default:
+			if !reply(chatFrame{Type: "error", Error: "Unknown frame type"}) {
+				return
+			}
+		}
+	}
+}
+
+// writeChat is the only goroutine writing to the connection. It forwards
+// replies and messages broadcast to the chat, closes the connection once the
+// user may no longer take part, and pings the client to detect dead
+// connections.
+func (app *application) writeChat(conn *websocket.Conn, user *database.User, sub, changes *pubsub.Subscription, lastId int, outgoing <-chan chatFrame, done chan<- struct{}) {
+	defer close(done)
+	defer conn.Close()
+
+	ticker := time.NewTicker(chatPingInterval)
+	defer ticker.Stop()
+
+	write := func(frame chatFrame) error {
+		conn.SetWriteDeadline(time.Now().Add(chatWriteWait))
+		return conn.WriteJSON(frame)
+	}
+
+	closeWith := func(code int, reason string) {
+		conn.SetWriteDeadline(time.Now().Add(chatWriteWait))
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason))
+	}
+
+	for {
+		select {
+		case frame := <-outgoing:
+			if err := write(frame); err != nil {
+				return
+			}
+		case msg, ok := <-sub.C:
+			if !ok {
+				closeWith(websocket.ClosePolicyViolation, "client too slow, reconnect to resume")
+				return
+			}
+
+			if msg.Id <= lastId {
+				continue
+			}
+
+			message, _ := msg.Data.(*database.Message)
+			if err := write(chatFrame{Type: "message", Message: message}); err != nil {
+				return
+			}
+		case msg, ok := <-changes.C:
+			if !ok {
+				closeWith(websocket.ClosePolicyViolation, "client too slow, reconnect to resume")
+				return
+			}
+
+			if reason := chatClosingChange(msg, user); reason != "" {
+				closeWith(websocket.ClosePolicyViolation, reason)
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(chatWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// chatClosingChange returns why a change of the event ends the user's chat
+// connection: their removal from the event or its deletion. It returns an
+// empty string for any other change.
+func chatClosingChange(msg pubsub.Message, user *database.User) string {
+	switch msg.Type {
+	case database.TopicEventDeleted:
+		return "event deleted"
+	case database.TopicAttendeeRemoved:
+		data, _ := msg.Data.(json.RawMessage)
+
+		var attendee database.Attendee
+		if err := json.Unmarshal(data, &attendee); err != nil || attendee.UserId != user.Id {
+			return ""
+		}
+
+		return "removed from the event"
+	}
+
+	return ""
+}
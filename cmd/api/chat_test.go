@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+)
+
+// newTestChat creates an event owned by a user with an attendee, and serves
+// app over HTTP.
+func newTestChat(t *testing.T, app *application) (server *httptest.Server, event *database.Event, guest *database.User) {
+	t.Helper()
+
+	owner := &database.User{Name: "Alice", Email: "alice@example.com", Password: "hash"}
+	guest = &database.User{Name: "Bob", Email: "bob@example.com", Password: "hash"}
+	for _, user := range []*database.User{owner, guest} {
+		if err := app.models.Users.Insert(user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	event = &database.Event{OwnerId: owner.Id, Name: "Meetup", Description: "A meetup for the tests",
+		Date: time.Now().Add(time.Hour), Location: "Online"}
+	if err := app.models.Events.Insert(event); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.models.Attendees.Insert(&database.Attendee{EventId: event.Id, UserId: guest.Id}); err != nil {
+		t.Fatal(err)
+	}
+
+	server = httptest.NewServer(app.routes())
+	t.Cleanup(server.Close)
+
+	return server, event, guest
+}
+
+// dialChat joins the chat of an event as user, replaying from after.
+func dialChat(t *testing.T, app *application, server *httptest.Server, event *database.Event, user *database.User, after int) *websocket.Conn {
+	t.Helper()
+
+	token, err := app.createAccessToken(user, allScopes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url := fmt.Sprintf("ws%s/api/v1/events/%d/chat?token=%s&after=%d",
+		strings.TrimPrefix(server.URL, "http"), event.Id, token, after)
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("joining the chat: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func readChatFrame(t *testing.T, conn *websocket.Conn) chatFrame {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	var frame chatFrame
+	if err := conn.ReadJSON(&frame); err != nil {
+		t.Fatalf("reading a frame: %v", err)
+	}
+
+	return frame
+}
+
+func TestChatMessageLengthCountsCharacters(t *testing.T) {
+	app := newTestApp(t)
+	server, event, guest := newTestChat(t, app)
+	conn := dialChat(t, app, server, event, guest, 0)
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"multi-byte characters within the limit", strings.Repeat("会", chatMaxMessageSize), "message"},
+		{"over the limit", strings.Repeat("a", chatMaxMessageSize+1), "error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := conn.WriteJSON(chatFrame{Type: "message", Body: tt.body}); err != nil {
+				t.Fatal(err)
+			}
+
+			if frame := readChatFrame(t, conn); frame.Type != tt.want {
+				t.Errorf("got a %q frame (%s), want %q", frame.Type, frame.Error, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatReplayMarksTruncation(t *testing.T) {
+	app := newTestApp(t)
+	server, event, guest := newTestChat(t, app)
+
+	var ids []int
+	for i := 0; i < chatReplayLimit+2; i++ {
+		message := &database.Message{EventId: event.Id, UserId: guest.Id, Body: fmt.Sprintf("message %d", i)}
+		if err := app.models.Messages.Insert(message); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, message.Id)
+	}
+
+	// Everything after the first message is one more than can be replayed.
+	conn := dialChat(t, app, server, event, guest, ids[0])
+
+	frame := readChatFrame(t, conn)
+	if frame.Type != "truncated" || frame.Before != ids[2] {
+		t.Fatalf("got %+v, want a truncated frame before %d", frame, ids[2])
+	}
+
+	for _, id := range ids[2:] {
+		frame := readChatFrame(t, conn)
+		if frame.Type != "message" || frame.Message.Id != id {
+			t.Fatalf("got %+v, want message %d", frame, id)
+		}
+	}
+}
+
+func TestChatClosedWhenRemovedFromEvent(t *testing.T) {
+	app := newTestApp(t)
+	server, event, guest := newTestChat(t, app)
+	conn := dialChat(t, app, server, event, guest, 0)
+
+	remove := func(userId int) {
+		payload, _ := json.Marshal(database.Attendee{Id: 1, UserId: userId, EventId: event.Id})
+		msg := &database.OutboxMessage{Id: userId, Topic: database.TopicAttendeeRemoved, Payload: string(payload)}
+		if err := app.publishToStreams(context.Background(), msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Someone else leaving doesn't affect the connection.
+	remove(guest.Id + 100)
+	if err := conn.WriteJSON(chatFrame{Type: "ping"}); err != nil {
+		t.Fatal(err)
+	}
+	if frame := readChatFrame(t, conn); frame.Type != "pong" {
+		t.Fatalf("got a %q frame, want pong", frame.Type)
+	}
+
+	remove(guest.Id)
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+		t.Fatalf("got %v, want the connection closed", err)
+	}
+}
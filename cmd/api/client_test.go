@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gumeeee/rest-api-in-gin/pkg/client"
+)
+
+const testPassword = "password1"
+
+// newTestServer serves the routes of a test application, optionally behind
+// wrap.
+func newTestServer(t *testing.T, wrap func(http.Handler) http.Handler) (*application, *httptest.Server) {
+	t.Helper()
+
+	app := newTestApp(t)
+
+	handler := app.routes()
+	if wrap != nil {
+		handler = wrap(handler)
+	}
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return app, server
+}
+
+// newTestUser registers a user and returns a client logged in as them.
+func newTestUser(t *testing.T, server *httptest.Server, email string, options ...client.Option) (*client.Client, *client.User) {
+	t.Helper()
+
+	c := client.New(server.URL, append([]client.Option{client.WithRetries(0, 0, 0)}, options...)...)
+
+	user, err := c.Register(context.Background(), "Test User", email, testPassword)
+	if err != nil {
+		t.Fatalf("registering %s: %v", email, err)
+	}
+
+	if _, err := c.Login(context.Background(), email, testPassword); err != nil {
+		t.Fatalf("logging in %s: %v", email, err)
+	}
+
+	return c, user
+}
+
+func testEventInput(name string) client.EventInput {
+	return client.EventInput{
+		Name:        name,
+		Description: "An event created by the client tests",
+		Date:        time.Now().UTC().Add(48 * time.Hour).Truncate(time.Second),
+		Location:    "Online",
+	}
+}
+
+func TestClientLoginAndRefresh(t *testing.T) {
+	ctx := context.Background()
+	_, server := newTestServer(t, nil)
+
+	c, user := newTestUser(t, server, "alice@example.com")
+
+	me, err := c.Me(ctx)
+	if err != nil {
+		t.Fatalf("Me: %v", err)
+	}
+	if me.Id != user.Id || me.Email != "alice@example.com" {
+		t.Errorf("Me = %+v, want user %d", me, user.Id)
+	}
+
+	// A rejected token is replaced by logging in again with the remembered
+	// credentials.
+	c.SetToken("not-a-valid-token")
+
+	if _, err := c.Me(ctx); err != nil {
+		t.Fatalf("Me with a rejected token: %v", err)
+	}
+	if token := c.Token(); token == "not-a-valid-token" || token == "" {
+		t.Errorf("token wasn't refreshed: %q", token)
+	}
+
+	// Without credentials, the error is returned as is.
+	anonymous := client.New(server.URL, client.WithToken("not-a-valid-token"), client.WithRetries(0, 0, 0))
+	if _, err := anonymous.Me(ctx); !errors.Is(err, client.ErrUnauthorized) {
+		t.Errorf("Me with a rejected token and no credentials: got %v, want ErrUnauthorized", err)
+	}
+
+	if _, err := client.New(server.URL).Me(ctx); !errors.Is(err, client.ErrNoCredentials) {
+		t.Errorf("Me without a token: got %v, want ErrNoCredentials", err)
+	}
+
+	if _, err := client.New(server.URL).Login(ctx, "alice@example.com", "wrong-password"); !errors.Is(err, client.ErrUnauthorized) {
+		t.Errorf("Login with a wrong password: got %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestClientScopedLogin(t *testing.T) {
+	ctx := context.Background()
+	_, server := newTestServer(t, nil)
+
+	newTestUser(t, server, "alice@example.com")
+
+	c := client.New(server.URL, client.WithScopes(scopeEventsRead), client.WithRetries(0, 0, 0))
+	if _, err := c.Login(ctx, "alice@example.com", testPassword); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	if _, err := c.CreateEvent(ctx, testEventInput("Scoped event")); !errors.Is(err, client.ErrForbidden) {
+		t.Errorf("CreateEvent without events:write: got %v, want ErrForbidden", err)
+	}
+}
+
+func TestClientEvents(t *testing.T) {
+	ctx := context.Background()
+	_, server := newTestServer(t, nil)
+
+	c, user := newTestUser(t, server, "alice@example.com")
+
+	input := testEventInput("Go meetup")
+
+	created, err := c.CreateEvent(ctx, input)
+	if err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+	if created.Id == 0 || created.OwnerId != user.Id || created.Name != input.Name {
+		t.Errorf("CreateEvent = %+v", created)
+	}
+
+	got, err := c.GetEvent(ctx, created.Id)
+	if err != nil {
+		t.Fatalf("GetEvent: %v", err)
+	}
+	if got.Name != input.Name || got.Location != input.Location || !got.Date.Equal(input.Date) {
+		t.Errorf("GetEvent = %+v, want %+v", got, input)
+	}
+
+	events, err := c.ListEvents(ctx)
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	if len(events) != 1 || events[0].Id != created.Id {
+		t.Errorf("ListEvents = %+v, want the created event", events)
+	}
+
+	input.Name = "Go meetup, second edition"
+	updated, err := c.UpdateEvent(ctx, created.Id, input)
+	if err != nil {
+		t.Fatalf("UpdateEvent: %v", err)
+	}
+	if updated.Name != input.Name {
+		t.Errorf("UpdateEvent name = %q, want %q", updated.Name, input.Name)
+	}
+
+	other, _ := newTestUser(t, server, "bob@example.com")
+	if _, err := other.UpdateEvent(ctx, created.Id, input); !errors.Is(err, client.ErrForbidden) {
+		t.Errorf("UpdateEvent by another user: got %v, want ErrForbidden", err)
+	}
+	if err := other.DeleteEvent(ctx, created.Id); !errors.Is(err, client.ErrForbidden) {
+		t.Errorf("DeleteEvent by another user: got %v, want ErrForbidden", err)
+	}
+
+	if err := c.DeleteEvent(ctx, created.Id); err != nil {
+		t.Fatalf("DeleteEvent: %v", err)
+	}
+
+	if _, err := c.GetEvent(ctx, created.Id); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("GetEvent after DeleteEvent: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestClientAttendees(t *testing.T) {
+	ctx := context.Background()
+	_, server := newTestServer(t, nil)
+
+	owner, _ := newTestUser(t, server, "alice@example.com")
+	guest, guestUser := newTestUser(t, server, "bob@example.com")
+
+	event, err := owner.CreateEvent(ctx, testEventInput("Go meetup"))
+	if err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+
+	attendee, err := owner.AddAttendee(ctx, event.Id, guestUser.Id)
+	if err != nil {
+		t.Fatalf("AddAttendee: %v", err)
+	}
+	if attendee.EventId != event.Id || attendee.UserId != guestUser.Id {
+		t.Errorf("AddAttendee = %+v", attendee)
+	}
+
+	if _, err := guest.AddAttendee(ctx, event.Id, guestUser.Id); !errors.Is(err, client.ErrForbidden) {
+		t.Errorf("AddAttendee by a user who isn't the owner: got %v, want ErrForbidden", err)
+	}
+
+	attendees, err := guest.ListAttendees(ctx, event.Id)
+	if err != nil {
+		t.Fatalf("ListAttendees: %v", err)
+	}
+	if len(attendees) != 1 || attendees[0].Id != guestUser.Id {
+		t.Errorf("ListAttendees = %+v, want user %d", attendees, guestUser.Id)
+	}
+
+	events, err := guest.ListEventsByAttendee(ctx, guestUser.Id)
+	if err != nil {
+		t.Fatalf("ListEventsByAttendee: %v", err)
+	}
+	if len(events) != 1 || events[0].Id != event.Id {
+		t.Errorf("ListEventsByAttendee = %+v, want event %d", events, event.Id)
+	}
+
+	if err := owner.RemoveAttendee(ctx, event.Id, guestUser.Id); err != nil {
+		t.Fatalf("RemoveAttendee: %v", err)
+	}
+
+	attendees, err = guest.ListAttendees(ctx, event.Id)
+	if err != nil {
+		t.Fatalf("ListAttendees: %v", err)
+	}
+	if len(attendees) != 0 {
+		t.Errorf("ListAttendees after RemoveAttendee = %+v, want none", attendees)
+	}
+
+	if _, err := owner.AddAttendee(ctx, event.Id+100, guestUser.Id); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("AddAttendee to a missing event: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	ctx := context.Background()
+	app, server := newTestServer(t, nil)
+
+	// Long enough for the second failed login below to come before the
+	// delay of the first one is over, however slow password hashing is.
+	app.loginPolicy.baseDelay = time.Minute
+
+	c, _ := newTestUser(t, server, "alice@example.com")
+
+	_, err := c.Register(ctx, "Alice", "alice@example.com", testPassword)
+	if !errors.Is(err, client.ErrConflict) {
+		t.Errorf("Register with a used email: got %v, want ErrConflict", err)
+	}
+
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict || apiErr.Message != "Email is already registered" {
+		t.Errorf("Register with a used email: got %#v, want an *APIError with the server's message", err)
+	}
+
+	if _, err := c.CreateEvent(ctx, client.EventInput{Name: "Go"}); !errors.Is(err, client.ErrBadRequest) {
+		t.Errorf("CreateEvent with invalid fields: got %v, want ErrBadRequest", err)
+	}
+
+	if _, err := c.GetEvent(ctx, 12345); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("GetEvent of a missing event: got %v, want ErrNotFound", err)
+	}
+
+	anonymous := client.New(server.URL, client.WithRetries(0, 0, 0))
+	if _, err := anonymous.Login(ctx, "alice@example.com", "wrong-password"); !errors.Is(err, client.ErrUnauthorized) {
+		t.Fatalf("first failed Login: got %v, want ErrUnauthorized", err)
+	}
+
+	_, err = anonymous.Login(ctx, "alice@example.com", "wrong-password")
+	if !errors.Is(err, client.ErrRateLimited) {
+		t.Fatalf("second failed Login: got %v, want ErrRateLimited", err)
+	}
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter <= 0 {
+		t.Errorf("second failed Login: got %#v, want a Retry-After", err)
+	}
+}
+
+// failFirst answers the first n requests matching method with status, before
+// letting the next ones through, and counts the requests.
+func failFirst(n int32, method string, status int, retryAfter string, count *atomic.Int32) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != method {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if count.Add(1) <= n {
+				if retryAfter != "" {
+					w.Header().Set("Retry-After", retryAfter)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(status)
+				w.Write([]byte(`{"error":"` + http.StatusText(status) + `"}`))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		method     string
+		status     int
+		retryAfter string
+		failures   int32
+		maxRetries int
+		wantErr    error
+		wantCalls  int32
+		minElapsed time.Duration
+	}{
+		{"503 then success", http.MethodGet, http.StatusServiceUnavailable, "", 2, 3, nil, 3, 30 * time.Millisecond},
+		{"429 then success", http.MethodGet, http.StatusTooManyRequests, "", 1, 3, nil, 2, 10 * time.Millisecond},
+		{"429 honors Retry-After", http.MethodGet, http.StatusTooManyRequests, "1", 1, 3, nil, 2, time.Second},
+		{"503 until retries run out", http.MethodGet, http.StatusServiceUnavailable, "", 10, 2, client.ErrServer, 3, 30 * time.Millisecond},
+		{"POST isn't retried", http.MethodPost, http.StatusServiceUnavailable, "", 1, 3, client.ErrServer, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			_, server := newTestServer(t, failFirst(tt.failures, tt.method, tt.status, tt.retryAfter, &calls))
+
+			c := client.New(server.URL, client.WithRetries(tt.maxRetries, 10*time.Millisecond, 20*time.Millisecond))
+
+			start := time.Now()
+			var err error
+			if tt.method == http.MethodGet {
+				_, err = c.ListEvents(ctx)
+			} else {
+				_, err = c.Register(ctx, "Alice", "alice@example.com", testPassword)
+			}
+			elapsed := time.Since(start)
+
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("got %v, want success", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("%d requests, want %d", got, tt.wantCalls)
+			}
+
+			// The backoff doubles from 10ms and is capped at 20ms.
+			if elapsed < tt.minElapsed {
+				t.Errorf("took %v, want at least %v of backoff", elapsed, tt.minElapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gumeeee/rest-api-in-gin/internal/config"
+)
+
+// defaultJWTSecret is only accepted in development.
+const defaultJWTSecret = "secret-jwt-key-123456"
+
+const envDevelopment = "development"
+
+// apiConfig holds the settings of the API server. See package config for
+// where they are read from.
+type apiConfig struct {
+	Env          string `config:"APP_ENV" usage:"environment the API runs in; the default JWT secret is only accepted when it is set to development"`
+	Port         int    `config:"PORT" default:"8080" usage:"port of the HTTP server"`
+	GRPCPort     int    `config:"GRPC_PORT" default:"9090" usage:"port of the gRPC server"`
+	DBPath       string `config:"DB_PATH" default:"./data.db" usage:"path of the SQLite database" required:"true"`
+	JWTSecret    string `config:"JWT_SECRET" default:"secret-jwt-key-123456" usage:"secret signing email verification links, and access tokens when JWT_KEYS_DIR is empty" required:"true"`
+	JWTKeysDir   string `config:"JWT_KEYS_DIR" usage:"directory of the RSA or Ed25519 keys signing access tokens, empty to sign them with JWT_SECRET"`
+	JWTActiveKey string `config:"JWT_ACTIVE_KEY" usage:"id of the key signing new access tokens (default the greatest id of JWT_KEYS_DIR)"`
+	BaseURL      string `config:"APP_URL" default:"http://localhost:8080" usage:"base URL used in the links sent by email" required:"true"`
+	SwaggerURL   string `config:"SWAGGER_URL" usage:"URL of the Swagger document loaded by the Swagger UI (default $APP_URL/swagger/doc.json)"`
+
+	DBBusyTimeout  time.Duration `config:"DB_BUSY_TIMEOUT" default:"5s" usage:"how long a database write waits for the lock held by another one"`
+	DBMaxOpenConns int           `config:"DB_MAX_OPEN_CONNS" default:"8" usage:"maximum number of open database connections"`
+
+	ReadTimeout    time.Duration `config:"HTTP_READ_TIMEOUT" default:"10s" usage:"maximum duration for reading a request"`
+	WriteTimeout   time.Duration `config:"HTTP_WRITE_TIMEOUT" default:"30s" usage:"maximum duration for writing a response"`
+	IdleTimeout    time.Duration `config:"HTTP_IDLE_TIMEOUT" default:"1m" usage:"how long idle keep-alive connections stay open"`
+	WebhookTimeout time.Duration `config:"WEBHOOK_TIMEOUT" default:"10s" usage:"timeout of a webhook delivery"`
+
+	RequireVerifiedEmail  bool `config:"REQUIRE_VERIFIED_EMAIL" default:"false" usage:"require a verified email to create events"`
+	WebhookAllowPrivate   bool `config:"WEBHOOK_ALLOW_PRIVATE" default:"false" usage:"let webhooks reach private and local addresses, for development"`
+	JWTRejectSecretTokens bool `config:"JWT_REJECT_SECRET_TOKENS" default:"false" usage:"refuse the access tokens signed with JWT_SECRET, still accepted when JWT_KEYS_DIR is set until this is"`
+
+	OutboxMaxAttempts int           `config:"OUTBOX_MAX_ATTEMPTS" default:"10" usage:"attempts at the side effects of a change before giving up on them"`
+	OutboxRetention   time.Duration `config:"OUTBOX_RETENTION" default:"168h" usage:"how long processed outbox messages are kept"`
+
+	ReminderOffsets  []time.Duration `config:"REMINDER_OFFSETS" default:"24h,1h" usage:"how long before an event attendees are reminded of it, in whole minutes, empty to disable reminders"`
+	ReminderInterval time.Duration   `config:"REMINDER_INTERVAL" default:"1m" usage:"time between two checks for due reminders"`
+
+	LoginMaxFailures   int           `config:"LOGIN_MAX_FAILURES" default:"5" usage:"failed logins before an account is locked"`
+	LoginLockout       time.Duration `config:"LOGIN_LOCKOUT" default:"15m" usage:"how long an account stays locked"`
+	LoginIPMaxFailures int           `config:"LOGIN_IP_MAX_FAILURES" default:"20" usage:"failed logins from an IP within LOGIN_IP_WINDOW before it is refused"`
+	LoginIPWindow      time.Duration `config:"LOGIN_IP_WINDOW" default:"15m" usage:"window over which failed logins are counted per IP"`
+
+	BackupDir      string        `config:"BACKUP_DIR" usage:"directory of the periodic database backups, empty to disable them"`
+	BackupInterval time.Duration `config:"BACKUP_INTERVAL" default:"24h" usage:"time between two periodic backups"`
+	BackupKeep     int           `config:"BACKUP_KEEP" default:"7" usage:"number of periodic backups kept"`
+
+	OIDCIssuer       string   `config:"OIDC_ISSUER" usage:"issuer URL of the OpenID Connect provider users can log in with, empty to disable it"`
+	OIDCClientID     string   `config:"OIDC_CLIENT_ID" usage:"client id of the API at the OpenID Connect provider"`
+	OIDCClientSecret string   `config:"OIDC_CLIENT_SECRET" usage:"client secret of the API at the OpenID Connect provider, empty for a public client"`
+	OIDCRedirectURL  string   `config:"OIDC_REDIRECT_URL" usage:"callback URL registered at the OpenID Connect provider (default $APP_URL/api/v1/auth/oidc/callback)"`
+	OIDCScopes       []string `config:"OIDC_SCOPES" default:"email,profile" usage:"scopes requested from the OpenID Connect provider besides openid"`
+
+	TOTPIssuer string `config:"TOTP_ISSUER" default:"Events API" usage:"name authenticator apps show for the accounts of two-factor authentication"`
+
+	MailLogFile  string `config:"MAIL_LOG_FILE" usage:"file emails are written to when SMTP_HOST is empty (default stdout)"`
+	SMTPHost     string `config:"SMTP_HOST" usage:"SMTP server sending the notifications"`
+	SMTPPort     int    `config:"SMTP_PORT" default:"587" usage:"port of the SMTP server"`
+	SMTPUsername string `config:"SMTP_USERNAME" usage:"user of the SMTP server"`
+	SMTPPassword string `config:"SMTP_PASSWORD" usage:"password of the SMTP server"`
+	SMTPSender   string `config:"SMTP_SENDER" default:"Events <no-reply@example.com>" usage:"sender of the notifications"`
+}
+
+func loadConfig(args []string) (*apiConfig, error) {
+	var cfg apiConfig
+
+	rest, err := config.Load(&cfg, "api", args)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(rest, " "))
+	}
+
+	if cfg.SwaggerURL == "" {
+		cfg.SwaggerURL = strings.TrimSuffix(cfg.BaseURL, "/") + "/swagger/doc.json"
+	}
+
+	if cfg.OIDCRedirectURL == "" {
+		cfg.OIDCRedirectURL = strings.TrimSuffix(cfg.BaseURL, "/") + "/api/v1/auth/oidc/callback"
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// validate checks the settings depending on each other or on the
+// environment.
+func (c *apiConfig) validate() error {
+	var errs []error
+
+	// Fail closed: a forgotten APP_ENV mustn't let a server run with a
+	// secret anyone can read in the sources.
+	if c.Env != envDevelopment && c.JWTSecret == defaultJWTSecret {
+		errs = append(errs, fmt.Errorf("JWT_SECRET must be changed from its default unless APP_ENV is %q", envDevelopment))
+	}
+
+	if c.Port < 1 || c.Port > 65535 || c.GRPCPort < 1 || c.GRPCPort > 65535 {
+		errs = append(errs, errors.New("PORT and GRPC_PORT must be between 1 and 65535"))
+	}
+
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"HTTP_READ_TIMEOUT", c.ReadTimeout},
+		{"HTTP_WRITE_TIMEOUT", c.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", c.IdleTimeout},
+		{"WEBHOOK_TIMEOUT", c.WebhookTimeout},
+		{"REMINDER_INTERVAL", c.ReminderInterval},
+		{"LOGIN_LOCKOUT", c.LoginLockout},
+		{"LOGIN_IP_WINDOW", c.LoginIPWindow},
+		{"BACKUP_INTERVAL", c.BackupInterval},
+		{"OUTBOX_RETENTION", c.OutboxRetention},
+	}
+
+	for _, d := range durations {
+		if d.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", d.name))
+		}
+	}
+
+	for _, offset := range c.ReminderOffsets {
+		// Sent reminders are recorded by their offset in minutes.
+		if offset <= 0 || offset%time.Minute != 0 {
+			errs = append(errs, fmt.Errorf("REMINDER_OFFSETS must be positive whole minutes, got %s", offset))
+		}
+	}
+
+	if c.LoginMaxFailures < 1 || c.LoginIPMaxFailures < 1 {
+		errs = append(errs, errors.New("LOGIN_MAX_FAILURES and LOGIN_IP_MAX_FAILURES must be at least 1"))
+	}
+
+	if c.OutboxMaxAttempts < 1 {
+		errs = append(errs, errors.New("OUTBOX_MAX_ATTEMPTS must be at least 1"))
+	}
+
+	if c.JWTRejectSecretTokens && c.JWTKeysDir == "" {
+		errs = append(errs, errors.New("JWT_REJECT_SECRET_TOKENS requires JWT_KEYS_DIR"))
+	}
+
+	if c.OIDCIssuer != "" && c.OIDCClientID == "" {
+		errs = append(errs, errors.New("OIDC_CLIENT_ID is required when OIDC_ISSUER is set"))
+	}
+
+	if c.BackupDir != "" && c.BackupKeep < 1 {
+		errs = append(errs, errors.New("BACKUP_KEEP must be at least 1"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// usesDefaultSecret tells whether the development JWT secret is in use, to
+// warn about it.
+func (c *apiConfig) usesDefaultSecret() bool {
+	return c.JWTSecret == defaultJWTSecret
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigDefaultSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		wantErr bool
+	}{
+		{"APP_ENV unset", nil, nil, true},
+		{"APP_ENV empty", nil, map[string]string{"APP_ENV": ""}, true},
+		{"production", []string{"-app-env", "production"}, nil, true},
+		{"development from the environment", nil, map[string]string{"APP_ENV": "development"}, false},
+		{"development from a flag", []string{"-app-env", "development"}, nil, false},
+		{"secret changed", []string{"-jwt-secret", "a-secret-for-the-tests"}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			// t.Setenv restores the variable unset below after the test.
+			t.Setenv("APP_ENV", "")
+			os.Unsetenv("APP_ENV")
+			t.Setenv("JWT_SECRET", defaultJWTSecret)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			_, err := loadConfig(tt.args)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "JWT_SECRET must be changed") {
+					t.Errorf("got %v, want the default secret to be refused", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("loadConfig: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigReminderOffsets(t *testing.T) {
+	tests := []struct {
+		offsets string
+		wantErr bool
+	}{
+		{"24h,1h", false},
+		{"90m", false},
+		{"", false},
+		{"30s", true},
+		{"90m30s", true},
+		{"-1h", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.offsets, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			t.Setenv("APP_ENV", "development")
+			t.Setenv("REMINDER_OFFSETS", tt.offsets)
+
+			_, err := loadConfig(nil)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "REMINDER_OFFSETS") {
+					t.Errorf("got %v, want the offsets to be refused", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("loadConfig: %v", err)
+			}
+		})
+	}
+}
@@ -18,3 +18,23 @@ func (app *application) GetUserFromContext(ctx *gin.Context) *database.User {
 
 	return user
 }
+
+// GetAPIKeyFromContext returns the API key the request was authenticated
+// with, or nil when it used an access token.
+func (app *application) GetAPIKeyFromContext(ctx *gin.Context) *database.APIKey {
+	apiKey, _ := ctx.Get("apiKey")
+
+	key, _ := apiKey.(*database.APIKey)
+
+	return key
+}
+
+// GetScopesFromContext returns the scopes granted to the token or API key the
+// request was authenticated with.
+func (app *application) GetScopesFromContext(ctx *gin.Context) []string {
+	scopes, _ := ctx.Get("scopes")
+
+	granted, _ := scopes.([]string)
+
+	return granted
+}
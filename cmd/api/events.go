@@ -38,6 +38,8 @@ func (app *application) createEvent(ctx *gin.Context) {
 		return
 	}
 
+	app.outbox.Wake()
+
 	ctx.JSON(http.StatusCreated, event)
 }
 
@@ -51,7 +53,7 @@ func (app *application) createEvent(ctx *gin.Context) {
 //	@Param			id	path		int	true	"Event ID"
 //	@Success		200	{object}	database.Event
 func (app *application) getEvent(ctx *gin.Context) {
-	id, err := strconv.Atoi(ctx.Query("id"))
+	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest,
 			gin.H{"error": "Invalid event ID"})
@@ -88,6 +90,7 @@ func (app *application) getAllEvents(ctx *gin.Context) {
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError,
 			gin.H{"error": "Failed to retreive events"})
+		return
 	}
 
 	ctx.JSON(http.StatusOK, events)
@@ -106,7 +109,7 @@ func (app *application) getAllEvents(ctx *gin.Context) {
 //	@Router			/api/v1/events/{id} [put]
 //	@Security		BearerAuth
 func (app *application) updateEvent(ctx *gin.Context) {
-	id, err := strconv.Atoi(ctx.Query("id"))
+	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest,
 			gin.H{"error": "Invalid event ID"})
@@ -127,7 +130,7 @@ func (app *application) updateEvent(ctx *gin.Context) {
 		return
 	}
 
-	if existingEvent.OwnerId != user.Id {
+	if !canManageEvent(user, existingEvent) {
 		ctx.JSON(http.StatusForbidden,
 			gin.H{"error": "You are not authorized to update this event"})
 		return
@@ -142,6 +145,7 @@ func (app *application) updateEvent(ctx *gin.Context) {
 	}
 
 	updatedEvent.Id = id
+	updatedEvent.OwnerId = existingEvent.OwnerId
 
 	if err := app.models.Events.Update(updatedEvent); err != nil {
 		ctx.JSON(http.StatusInternalServerError,
@@ -149,6 +153,8 @@ func (app *application) updateEvent(ctx *gin.Context) {
 		return
 	}
 
+	app.outbox.Wake()
+
 	ctx.JSON(http.StatusOK, updatedEvent)
 }
 
@@ -164,7 +170,7 @@ func (app *application) updateEvent(ctx *gin.Context) {
 //	@Router			/api/v1/events/{id} [delete]
 //	@Security		BearerAuth
 func (app *application) deleteEvent(ctx *gin.Context) {
-	id, err := strconv.Atoi(ctx.Query("id"))
+	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest,
 			gin.H{"error": "Invalid event ID"})
@@ -185,7 +191,7 @@ func (app *application) deleteEvent(ctx *gin.Context) {
 		return
 	}
 
-	if existingEvent.OwnerId != user.Id {
+	if !canManageEvent(user, existingEvent) {
 		ctx.JSON(http.StatusForbidden,
 			gin.H{"error": "You are not authorized to delete this event"})
 		return
@@ -194,8 +200,11 @@ func (app *application) deleteEvent(ctx *gin.Context) {
 	if err := app.models.Events.Delete(id); err != nil {
 		ctx.JSON(http.StatusInternalServerError,
 			gin.H{"error": "Failed to delete event"})
+		return
 	}
 
+	app.outbox.Wake()
+
 	ctx.JSON(http.StatusNoContent, gin.H{"message": "Event deleted successfully"})
 }
 
@@ -211,13 +220,13 @@ func (app *application) deleteEvent(ctx *gin.Context) {
 // @Router			/api/v1/events/{id}/attendees/{userId} [post]
 // @Security		BearerAuth
 func (app *application) AddAttendeeToEvent(ctx *gin.Context) {
-	eventId, err := strconv.Atoi(ctx.Query("id"))
+	eventId, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
 		return
 	}
 
-	userId, err := strconv.Atoi(ctx.Query("userId"))
+	userId, err := strconv.Atoi(ctx.Param("userId"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
 		return
@@ -244,7 +253,7 @@ func (app *application) AddAttendeeToEvent(ctx *gin.Context) {
 	}
 
 	user := app.GetUserFromContext(ctx)
-	if event.OwnerId != user.Id {
+	if !canManageEvent(user, event) {
 		ctx.JSON(http.StatusForbidden,
 			gin.H{"error": "You are not authorized to add an attendee to this event"})
 		return
@@ -271,6 +280,8 @@ func (app *application) AddAttendeeToEvent(ctx *gin.Context) {
 		return
 	}
 
+	app.outbox.Wake()
+
 	ctx.JSON(http.StatusCreated, attendee)
 }
 
@@ -285,7 +296,7 @@ func (app *application) AddAttendeeToEvent(ctx *gin.Context) {
 //	@Success		200	{object}	[]database.User
 //	@Router			/api/v1/events/{id}/attendees [get]
 func (app *application) GetAttendeesForEvent(ctx *gin.Context) {
-	id, err := strconv.Atoi(ctx.Query("id"))
+	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event Id"})
 		return
@@ -313,13 +324,13 @@ func (app *application) GetAttendeesForEvent(ctx *gin.Context) {
 // @Router			/api/v1/events/{id}/attendees/{userId} [delete]
 // @Security		BearerAuth
 func (app *application) DeleteAttendeeFromEvent(ctx *gin.Context) {
-	eventId, err := strconv.Atoi(ctx.Query("id"))
+	eventId, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event Id"})
 		return
 	}
 
-	userId, err := strconv.Atoi(ctx.Query("id"))
+	userId, err := strconv.Atoi(ctx.Param("userId"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user Id"})
 		return
@@ -338,19 +349,21 @@ func (app *application) DeleteAttendeeFromEvent(ctx *gin.Context) {
 	}
 
 	user := app.GetUserFromContext(ctx)
-	if event.OwnerId != user.Id {
+	if !canManageEvent(user, event) {
 		ctx.JSON(http.StatusForbidden,
 			gin.H{"error": "You are not authorized to delete an attendee from this event"})
 		return
 	}
 
-	err = app.models.Attendees.Delete(userId, eventId)
+	err = app.models.Attendees.Delete(eventId, userId)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError,
 			gin.H{"error": "Failed to delete attendee from event"})
 		return
 	}
 
+	app.outbox.Wake()
+
 	ctx.JSON(http.StatusNoContent, gin.H{"message": "Attendee deleted successfully"})
 }
 
@@ -365,7 +378,7 @@ func (app *application) DeleteAttendeeFromEvent(ctx *gin.Context) {
 //	@Success		200	{object}	[]database.Event
 //	@Router			/api/v1/attendees/{id}/events [get]
 func (app *application) GetEventsByAttendee(ctx *gin.Context) {
-	id, err := strconv.Atoi(ctx.Query("id"))
+	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attendee Id"})
 		return
@@ -0,0 +1,487 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+)
+
+//go:embed schema.graphql
+var graphqlSchema string
+
+var errAuthenticationRequired = errors.New("Authentication required")
+
+type graphqlContextKey struct{}
+
+// graphqlRequest is the per-request state resolvers get from their context.
+type graphqlRequest struct {
+	user    *database.User
+	scopes  []string
+	loaders *graphqlLoaders
+}
+
+func graphqlRequestFrom(ctx context.Context) *graphqlRequest {
+	return ctx.Value(graphqlContextKey{}).(*graphqlRequest)
+}
+
+// requireUser returns the authenticated user, or an error for anonymous
+// requests and requests whose token lacks the scope.
+func requireUser(ctx context.Context, scope string) (*database.User, error) {
+	req := graphqlRequestFrom(ctx)
+	if req.user == nil {
+		return nil, errAuthenticationRequired
+	}
+
+	if !hasScope(req.scopes, scope) {
+		return nil, errMissingScope(scope)
+	}
+
+	return req.user, nil
+}
+
+func parseID(id graphql.ID) (int, error) {
+	n, err := strconv.Atoi(string(id))
+	if err != nil {
+		return 0, errors.New("Invalid ID")
+	}
+
+	return n, nil
+}
+
+func formatID(id int) graphql.ID {
+	return graphql.ID(strconv.Itoa(id))
+}
+
+func (app *application) newGraphQLSchema() *graphql.Schema {
+	return graphql.MustParseSchema(graphqlSchema, &graphqlResolver{app: app},
+		graphql.MaxDepth(10), graphql.MaxParallelism(10))
+}
+
+// GraphQL executes a GraphQL query or mutation
+//
+//	@Summary		Executes a GraphQL query or mutation
+//	@Description	Queries users, events and attendees, and changes events with the same rules as the REST API. Mutations require a bearer token or API key granted the events:write or attendees:write scope.
+//	@Tags			graphql
+//	@Accept			json
+//	@Produce		json
+//	@Success		200
+//	@Router			/graphql [post]
+//	@Security		BearerAuth
+func (app *application) graphqlHandler(schema *graphql.Schema) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var params struct {
+			Query         string         `json:"query" binding:"required"`
+			OperationName string         `json:"operationName"`
+			Variables     map[string]any `json:"variables"`
+		}
+
+		if err := ctx.ShouldBindJSON(&params); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		req := &graphqlRequest{loaders: app.newGraphQLLoaders()}
+		if user, ok := ctx.Get("user"); ok {
+			req.user = user.(*database.User)
+			req.scopes = app.GetScopesFromContext(ctx)
+		}
+
+		reqCtx := context.WithValue(ctx.Request.Context(), graphqlContextKey{}, req)
+		response := schema.Exec(reqCtx, params.Query, params.OperationName, params.Variables)
+
+		ctx.JSON(http.StatusOK, response)
+	}
+}
+
+// graphqlResolver resolves the fields of both the Query and Mutation types.
+type graphqlResolver struct {
+	app *application
+}
+
+func (r *graphqlResolver) Me(ctx context.Context) *userResolver {
+	req := graphqlRequestFrom(ctx)
+	if req.user == nil {
+		return nil
+	}
+
+	req.loaders.primeUsers([]*database.User{req.user})
+
+	return &userResolver{user: req.user}
+}
+
+func (r *graphqlResolver) User(ctx context.Context, args struct{ ID graphql.ID }) (*userResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := graphqlRequestFrom(ctx).loaders.users.load(id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, nil
+	}
+
+	return &userResolver{user: user}, nil
+}
+
+func (r *graphqlResolver) Event(ctx context.Context, args struct{ ID graphql.ID }) (*eventResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := graphqlRequestFrom(ctx).loaders.events.load(id)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, nil
+	}
+
+	return &eventResolver{event: event}, nil
+}
+
+func (r *graphqlResolver) Events(ctx context.Context) ([]*eventResolver, error) {
+	events, err := r.app.models.Events.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	graphqlRequestFrom(ctx).loaders.primeEvents(events)
+
+	return eventResolvers(events), nil
+}
+
+type eventInput struct {
+	Name        string
+	Description string
+	Date        graphql.Time
+	Location    string
+}
+
+// toEvent converts the input to an event and validates it with the rules the
+// REST API applies to request bodies.
+func (input eventInput) toEvent() (*database.Event, error) {
+	event := &database.Event{
+		Name:        input.Name,
+		Description: input.Description,
+		Date:        input.Date.Time,
+		Location:    input.Location,
+	}
+
+	if err := binding.Validator.ValidateStruct(event); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+func (r *graphqlResolver) CreateEvent(ctx context.Context, args struct{ Input eventInput }) (*eventResolver, error) {
+	user, err := requireUser(ctx, scopeEventsWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.app.hasVerifiedEmail(user) {
+		return nil, errors.New("Email address must be verified")
+	}
+
+	event, err := args.Input.toEvent()
+	if err != nil {
+		return nil, err
+	}
+	event.OwnerId = user.Id
+
+	if err := r.app.models.Events.Insert(event); err != nil {
+		return nil, errors.New("Failed to create event")
+	}
+
+	r.app.outbox.Wake()
+
+	graphqlRequestFrom(ctx).loaders.primeEvents([]*database.Event{event})
+
+	return &eventResolver{event: event}, nil
+}
+
+// managedEvent loads an event the authenticated user is allowed to manage
+// with a token granted the scope. action completes the error message
+// returned to other users.
+func (r *graphqlResolver) managedEvent(ctx context.Context, id graphql.ID, scope, action string) (*database.Event, error) {
+	user, err := requireUser(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	eventId, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := r.app.models.Events.Get(eventId)
+	if err != nil {
+		return nil, errors.New("Failed to retreive event")
+	}
+	if event == nil {
+		return nil, errors.New("Event not found")
+	}
+
+	if !canManageEvent(user, event) {
+		return nil, errors.New("You are not authorized to " + action)
+	}
+
+	return event, nil
+}
+
+func (r *graphqlResolver) UpdateEvent(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input eventInput
+}) (*eventResolver, error) {
+	existingEvent, err := r.managedEvent(ctx, args.ID, scopeEventsWrite, "update this event")
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := args.Input.toEvent()
+	if err != nil {
+		return nil, err
+	}
+	event.Id = existingEvent.Id
+	event.OwnerId = existingEvent.OwnerId
+
+	if err := r.app.models.Events.Update(event); err != nil {
+		return nil, errors.New("Failed to update event")
+	}
+
+	r.app.outbox.Wake()
+
+	graphqlRequestFrom(ctx).loaders.primeEvents([]*database.Event{event})
+
+	return &eventResolver{event: event}, nil
+}
+
+func (r *graphqlResolver) DeleteEvent(ctx context.Context, args struct{ ID graphql.ID }) (graphql.ID, error) {
+	event, err := r.managedEvent(ctx, args.ID, scopeEventsWrite, "delete this event")
+	if err != nil {
+		return "", err
+	}
+
+	if err := r.app.models.Events.Delete(event.Id); err != nil {
+		return "", errors.New("Failed to delete event")
+	}
+
+	r.app.outbox.Wake()
+
+	return formatID(event.Id), nil
+}
+
+func (r *graphqlResolver) AddAttendee(ctx context.Context, args struct {
+	EventID graphql.ID
+	UserID  graphql.ID
+}) (*attendeeResolver, error) {
+	event, err := r.managedEvent(ctx, args.EventID, scopeAttendeesWrite, "add an attendee to this event")
+	if err != nil {
+		return nil, err
+	}
+
+	userId, err := parseID(args.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	userToAdd, err := r.app.models.Users.Get(userId)
+	if err != nil {
+		return nil, errors.New("Failed to retreive user")
+	}
+	if userToAdd == nil {
+		return nil, errors.New("User not found")
+	}
+
+	existingAttendee, err := r.app.models.Attendees.GetByEventAndAttendeeId(event.Id, userToAdd.Id)
+	if err != nil {
+		return nil, errors.New("Failed to retreive attendee")
+	}
+	if existingAttendee != nil {
+		return nil, errors.New("Attendee already exists")
+	}
+
+	attendee := &database.Attendee{
+		EventId: event.Id,
+		UserId:  userToAdd.Id,
+	}
+
+	if _, err := r.app.models.Attendees.Insert(attendee); err != nil {
+		return nil, errors.New("Failed to add attendee")
+	}
+
+	r.app.outbox.Wake()
+
+	loaders := graphqlRequestFrom(ctx).loaders
+	loaders.events.prime(attendee.EventId)
+	loaders.users.prime(attendee.UserId)
+
+	return &attendeeResolver{attendee: attendee}, nil
+}
+
+func (r *graphqlResolver) RemoveAttendee(ctx context.Context, args struct {
+	EventID graphql.ID
+	UserID  graphql.ID
+}) (bool, error) {
+	event, err := r.managedEvent(ctx, args.EventID, scopeAttendeesWrite, "delete an attendee from this event")
+	if err != nil {
+		return false, err
+	}
+
+	userId, err := parseID(args.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.app.models.Attendees.Delete(event.Id, userId); err != nil {
+		return false, errors.New("Failed to delete attendee from event")
+	}
+
+	r.app.outbox.Wake()
+
+	return true, nil
+}
+
+type userResolver struct {
+	user *database.User
+}
+
+func (r *userResolver) ID() graphql.ID {
+	return formatID(r.user.Id)
+}
+
+func (r *userResolver) Name() string {
+	return r.user.Name
+}
+
+// Email is only shown to the user themself, like on the REST API where
+// public profiles have no email.
+func (r *userResolver) Email(ctx context.Context) *string {
+	req := graphqlRequestFrom(ctx)
+	if req.user == nil || req.user.Id != r.user.Id || !hasScope(req.scopes, scopeAccount) {
+		return nil
+	}
+
+	return &r.user.Email
+}
+
+func (r *userResolver) Events(ctx context.Context) ([]*eventResolver, error) {
+	events, err := graphqlRequestFrom(ctx).loaders.ownedEvents.load(r.user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return eventResolvers(events), nil
+}
+
+func (r *userResolver) Attending(ctx context.Context) ([]*eventResolver, error) {
+	events, err := graphqlRequestFrom(ctx).loaders.attending.load(r.user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return eventResolvers(events), nil
+}
+
+type eventResolver struct {
+	event *database.Event
+}
+
+func eventResolvers(events []*database.Event) []*eventResolver {
+	resolvers := make([]*eventResolver, len(events))
+	for i, event := range events {
+		resolvers[i] = &eventResolver{event: event}
+	}
+
+	return resolvers
+}
+
+func (r *eventResolver) ID() graphql.ID {
+	return formatID(r.event.Id)
+}
+
+func (r *eventResolver) Name() string {
+	return r.event.Name
+}
+
+func (r *eventResolver) Description() string {
+	return r.event.Description
+}
+
+func (r *eventResolver) Date() graphql.Time {
+	return graphql.Time{Time: r.event.Date}
+}
+
+func (r *eventResolver) Location() string {
+	return r.event.Location
+}
+
+func (r *eventResolver) Owner(ctx context.Context) (*userResolver, error) {
+	owner, err := graphqlRequestFrom(ctx).loaders.users.load(r.event.OwnerId)
+	if err != nil {
+		return nil, err
+	}
+	if owner == nil {
+		return nil, errors.New("Owner not found")
+	}
+
+	return &userResolver{user: owner}, nil
+}
+
+func (r *eventResolver) Attendees(ctx context.Context) ([]*userResolver, error) {
+	users, err := graphqlRequestFrom(ctx).loaders.attendees.load(r.event.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*userResolver, len(users))
+	for i, user := range users {
+		resolvers[i] = &userResolver{user: user}
+	}
+
+	return resolvers, nil
+}
+
+type attendeeResolver struct {
+	attendee *database.Attendee
+}
+
+func (r *attendeeResolver) ID() graphql.ID {
+	return formatID(r.attendee.Id)
+}
+
+func (r *attendeeResolver) Event(ctx context.Context) (*eventResolver, error) {
+	event, err := graphqlRequestFrom(ctx).loaders.events.load(r.attendee.EventId)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, errors.New("Event not found")
+	}
+
+	return &eventResolver{event: event}, nil
+}
+
+func (r *attendeeResolver) User(ctx context.Context) (*userResolver, error) {
+	user, err := graphqlRequestFrom(ctx).loaders.users.load(r.attendee.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("User not found")
+	}
+
+	return &userResolver{user: user}, nil
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+)
+
+// loader batches the lookups made while resolving a single GraphQL request to
+// avoid issuing one query per object. Keys are primed as soon as the objects
+// referencing them are known; the first load then fetches every primed key at
+// once and the others are served from the cache.
+type loader[K comparable, V any] struct {
+	fetch func(keys []K) (map[K]V, error)
+
+	mu       sync.Mutex
+	pending  map[K]struct{}
+	inFlight map[K]*loaderBatch[K, V]
+	results  map[K]V
+}
+
+type loaderBatch[K comparable, V any] struct {
+	done    chan struct{}
+	results map[K]V
+	err     error
+}
+
+func newLoader[K comparable, V any](fetch func(keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:    fetch,
+		pending:  make(map[K]struct{}),
+		inFlight: make(map[K]*loaderBatch[K, V]),
+		results:  make(map[K]V),
+	}
+}
+
+// prime queues key to be fetched with the next batch.
+func (l *loader[K, V]) prime(key K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.results[key]; ok {
+		return
+	}
+	if _, ok := l.inFlight[key]; ok {
+		return
+	}
+
+	l.pending[key] = struct{}{}
+}
+
+// load returns the value for key, fetching it together with every pending key
+// unless a batch containing it is already being fetched. Keys the fetch
+// function doesn't return get the zero value.
+func (l *loader[K, V]) load(key K) (V, error) {
+	l.mu.Lock()
+
+	if value, ok := l.results[key]; ok {
+		l.mu.Unlock()
+		return value, nil
+	}
+
+	batch, ok := l.inFlight[key]
+	if !ok {
+		batch = &loaderBatch[K, V]{done: make(chan struct{})}
+
+		l.pending[key] = struct{}{}
+		keys := make([]K, 0, len(l.pending))
+		for k := range l.pending {
+			keys = append(keys, k)
+			l.inFlight[k] = batch
+		}
+		clear(l.pending)
+
+		// Fetch without holding the lock: fetch functions prime other loaders,
+		// which may be fetching themselves.
+		l.mu.Unlock()
+		batch.results, batch.err = l.fetch(keys)
+		l.mu.Lock()
+
+		for _, k := range keys {
+			delete(l.inFlight, k)
+			if batch.err == nil {
+				l.results[k] = batch.results[k]
+			}
+		}
+		close(batch.done)
+	}
+
+	l.mu.Unlock()
+	<-batch.done
+
+	if batch.err != nil {
+		var zero V
+		return zero, batch.err
+	}
+
+	return batch.results[key], nil
+}
+
+// graphqlLoaders holds the loaders of a single GraphQL request. Whenever users
+// or events are loaded, the keys needed to resolve their fields are primed so
+// that each level of a query costs one query per field.
+type graphqlLoaders struct {
+	users       *loader[int, *database.User]
+	events      *loader[int, *database.Event]
+	attendees   *loader[int, []*database.User]
+	ownedEvents *loader[int, []*database.Event]
+	attending   *loader[int, []*database.Event]
+}
+
+func (app *application) newGraphQLLoaders() *graphqlLoaders {
+	l := &graphqlLoaders{}
+
+	l.users = newLoader(func(ids []int) (map[int]*database.User, error) {
+		users, err := app.models.Users.GetByIds(ids)
+		if err != nil {
+			return nil, err
+		}
+
+		l.primeUsers(users)
+
+		byId := make(map[int]*database.User, len(users))
+		for _, user := range users {
+			byId[user.Id] = user
+		}
+
+		return byId, nil
+	})
+
+	l.events = newLoader(func(ids []int) (map[int]*database.Event, error) {
+		events, err := app.models.Events.GetByIds(ids)
+		if err != nil {
+			return nil, err
+		}
+
+		l.primeEvents(events)
+
+		byId := make(map[int]*database.Event, len(events))
+		for _, event := range events {
+			byId[event.Id] = event
+		}
+
+		return byId, nil
+	})
+
+	l.attendees = newLoader(func(eventIds []int) (map[int][]*database.User, error) {
+		attendees, err := app.models.Attendees.GetAttendeesByEventIds(eventIds)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, users := range attendees {
+			l.primeUsers(users)
+		}
+
+		return attendees, nil
+	})
+
+	l.ownedEvents = newLoader(func(ownerIds []int) (map[int][]*database.Event, error) {
+		events, err := app.models.Events.GetByOwnerIds(ownerIds)
+		if err != nil {
+			return nil, err
+		}
+
+		l.primeEvents(events)
+
+		byOwner := make(map[int][]*database.Event)
+		for _, event := range events {
+			byOwner[event.OwnerId] = append(byOwner[event.OwnerId], event)
+		}
+
+		return byOwner, nil
+	})
+
+	l.attending = newLoader(func(userIds []int) (map[int][]*database.Event, error) {
+		events, err := app.models.Attendees.GetEventsByAttendeeIds(userIds)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, attended := range events {
+			l.primeEvents(attended)
+		}
+
+		return events, nil
+	})
+
+	return l
+}
+
+func (l *graphqlLoaders) primeUsers(users []*database.User) {
+	for _, user := range users {
+		l.ownedEvents.prime(user.Id)
+		l.attending.prime(user.Id)
+	}
+}
+
+func (l *graphqlLoaders) primeEvents(events []*database.Event) {
+	for _, event := range events {
+		l.users.prime(event.OwnerId)
+		l.attendees.prime(event.Id)
+	}
+}
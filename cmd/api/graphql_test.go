@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+)
+
+// graphqlQuery runs query against handler, authenticated with
+// token when it isn't empty, and decodes the data of the response.
+func graphqlQuery(t *testing.T, handler http.Handler, token, query string, data any) {
+	t.Helper()
+
+	body, _ := json.Marshal(map[string]string{"query": query})
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []any           `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decoding %s: %v", rec.Body, err)
+	}
+	if len(response.Errors) > 0 {
+		t.Fatalf("query failed: %v", response.Errors)
+	}
+
+	if err := json.Unmarshal(response.Data, data); err != nil {
+		t.Fatalf("decoding data %s: %v", response.Data, err)
+	}
+}
+
+func TestGraphQLEmailOnlyForSelf(t *testing.T) {
+	app := newTestApp(t)
+	handler := app.routes()
+
+	owner := &database.User{Name: "Alice", Email: "alice@example.com", Password: "hash"}
+	guest := &database.User{Name: "Bob", Email: "bob@example.com", Password: "hash"}
+	for _, user := range []*database.User{owner, guest} {
+		if err := app.models.Users.Insert(user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	event := &database.Event{OwnerId: owner.Id, Name: "Meetup", Description: "A meetup for the tests",
+		Date: time.Now().Add(time.Hour), Location: "Online"}
+	if err := app.models.Events.Insert(event); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.models.Attendees.Insert(&database.Attendee{EventId: event.Id, UserId: guest.Id}); err != nil {
+		t.Fatal(err)
+	}
+
+	guestToken, err := app.createAccessToken(guest, allScopes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readOnlyToken, err := app.createAccessToken(guest, []string{scopeEventsRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := `{
+	  user(id: ` + strconv.Itoa(owner.Id) + `) { email }
+	  event(id: ` + strconv.Itoa(event.Id) + `) { owner { email } attendees { id email } }
+	}`
+
+	type user struct {
+		Id    string  `json:"id"`
+		Email *string `json:"email"`
+	}
+	var data struct {
+		User  user `json:"user"`
+		Event struct {
+			Owner     user   `json:"owner"`
+			Attendees []user `json:"attendees"`
+		} `json:"event"`
+	}
+
+	tests := []struct {
+		name      string
+		token     string
+		wantGuest *string
+	}{
+		{"anonymous", "", nil},
+		{"attendee", guestToken, &guest.Email},
+		{"attendee without the account scope", readOnlyToken, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graphqlQuery(t, handler, tt.token, query, &data)
+
+			if data.User.Email != nil {
+				t.Errorf("user(id).email = %q, want null", *data.User.Email)
+			}
+			if data.Event.Owner.Email != nil {
+				t.Errorf("event.owner.email = %q, want null", *data.Event.Owner.Email)
+			}
+
+			if len(data.Event.Attendees) != 1 {
+				t.Fatalf("event.attendees = %+v, want 1 attendee", data.Event.Attendees)
+			}
+			got := data.Event.Attendees[0].Email
+			if (got == nil) != (tt.wantGuest == nil) || (got != nil && *got != *tt.wantGuest) {
+				t.Errorf("event.attendees.email = %v, want %v", got, tt.wantGuest)
+			}
+		})
+	}
+}
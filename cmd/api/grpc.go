@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+	eventsv1 "github.com/gumeeee/rest-api-in-gin/pkg/pb/events/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// publicGRPCMethods can be called without an access token, like the matching
+// REST routes.
+var publicGRPCMethods = map[string]bool{
+	eventsv1.EventService_ListEvents_FullMethodName:            true,
+	eventsv1.EventService_GetEvent_FullMethodName:              true,
+	eventsv1.AttendeeService_ListEventAttendees_FullMethodName: true,
+	eventsv1.AttendeeService_ListAttendeeEvents_FullMethodName: true,
+}
+
+// grpcMethodScopes lists the scope each method requires beyond a valid
+// token, like the routes in app.routes().
+var grpcMethodScopes = map[string]string{
+	eventsv1.UserService_GetCurrentUser_FullMethodName:     scopeAccount,
+	eventsv1.UserService_GetUser_FullMethodName:            scopeAccount,
+	eventsv1.EventService_CreateEvent_FullMethodName:       scopeEventsWrite,
+	eventsv1.EventService_UpdateEvent_FullMethodName:       scopeEventsWrite,
+	eventsv1.EventService_DeleteEvent_FullMethodName:       scopeEventsWrite,
+	eventsv1.AttendeeService_AddAttendee_FullMethodName:    scopeAttendeesWrite,
+	eventsv1.AttendeeService_RemoveAttendee_FullMethodName: scopeAttendeesWrite,
+}
+
+type grpcPrincipalKey struct{}
+
+// grpcUser returns the user authenticated by grpcAuthInterceptor, or nil for
+// anonymous calls to public methods.
+func grpcUser(ctx context.Context) *database.User {
+	if auth, ok := ctx.Value(grpcPrincipalKey{}).(*principal); ok {
+		return auth.user
+	}
+
+	return nil
+}
+
+// grpcScopes returns the scopes granted to the caller, or nil for anonymous
+// calls to public methods.
+func grpcScopes(ctx context.Context) []string {
+	if auth, ok := ctx.Value(grpcPrincipalKey{}).(*principal); ok {
+		return auth.scopes
+	}
+
+	return nil
+}
+
+// grpcAuthInterceptor authenticates calls with the bearer token or API key in
+// their authorization or x-api-key metadata, applying the same checks as
+// AuthMiddleware.
+func (app *application) grpcAuthInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	authorization := firstValue(md, "authorization")
+	apiKey := firstValue(md, "x-api-key")
+	if authorization == "" && apiKey == "" && publicGRPCMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+
+	auth, err := app.authenticateRequest(authorization, apiKey)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	if scope, ok := grpcMethodScopes[info.FullMethod]; ok && !hasScope(auth.scopes, scope) {
+		return nil, status.Error(codes.PermissionDenied, errMissingScope(scope).Error())
+	}
+
+	return handler(context.WithValue(ctx, grpcPrincipalKey{}, auth), req)
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+func (app *application) serveGRPC() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", app.grpcPort))
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Starting gRPC server on port %d", app.grpcPort)
+
+	return app.grpcServer().Serve(listener)
+}
+
+func (app *application) grpcServer() *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(app.grpcAuthInterceptor))
+
+	eventsv1.RegisterUserServiceServer(server, &userService{app: app})
+	eventsv1.RegisterEventServiceServer(server, &eventService{app: app})
+	eventsv1.RegisterAttendeeServiceServer(server, &attendeeService{app: app})
+	reflection.Register(server)
+
+	return server
+}
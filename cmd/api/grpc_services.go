@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+	eventsv1 "github.com/gumeeee/rest-api-in-gin/pkg/pb/events/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// userToProto converts user to a message, filling in the email address only
+// for the caller themself with the account scope, like publicProfile.
+func userToProto(ctx context.Context, user *database.User) *eventsv1.User {
+	message := &eventsv1.User{
+		Id:   int64(user.Id),
+		Name: user.Name,
+	}
+	if viewer := grpcUser(ctx); viewer != nil && viewer.Id == user.Id && hasScope(grpcScopes(ctx), scopeAccount) {
+		message.Email = user.Email
+	}
+
+	return message
+}
+
+func eventToProto(event *database.Event) *eventsv1.Event {
+	return &eventsv1.Event{
+		Id:          int64(event.Id),
+		OwnerId:     int64(event.OwnerId),
+		Name:        event.Name,
+		Description: event.Description,
+		Date:        timestamppb.New(event.Date),
+		Location:    event.Location,
+	}
+}
+
+func eventsToProto(events []*database.Event) []*eventsv1.Event {
+	messages := make([]*eventsv1.Event, len(events))
+	for i, event := range events {
+		messages[i] = eventToProto(event)
+	}
+
+	return messages
+}
+
+// eventFromProto converts the input to an event and validates it with the
+// rules the REST API applies to request bodies.
+func eventFromProto(input *eventsv1.EventInput) (*database.Event, error) {
+	if input == nil {
+		return nil, status.Error(codes.InvalidArgument, "Event is required")
+	}
+
+	event := &database.Event{
+		Name:        input.Name,
+		Description: input.Description,
+		Location:    input.Location,
+	}
+	if input.Date != nil {
+		event.Date = input.Date.AsTime()
+	}
+
+	if err := binding.Validator.ValidateStruct(event); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return event, nil
+}
+
+type userService struct {
+	eventsv1.UnimplementedUserServiceServer
+	app *application
+}
+
+func (s *userService) GetCurrentUser(ctx context.Context, req *eventsv1.GetCurrentUserRequest) (*eventsv1.User, error) {
+	return userToProto(ctx, grpcUser(ctx)), nil
+}
+
+func (s *userService) GetUser(ctx context.Context, req *eventsv1.GetUserRequest) (*eventsv1.User, error) {
+	user, err := s.app.models.Users.Get(int(req.Id))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to retreive user")
+	}
+	if user == nil {
+		return nil, status.Error(codes.NotFound, "User not found")
+	}
+
+	return userToProto(ctx, user), nil
+}
+
+type eventService struct {
+	eventsv1.UnimplementedEventServiceServer
+	app *application
+}
+
+func (s *eventService) ListEvents(ctx context.Context, req *eventsv1.ListEventsRequest) (*eventsv1.ListEventsResponse, error) {
+	events, err := s.app.models.Events.GetAll()
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to retreive events")
+	}
+
+	return &eventsv1.ListEventsResponse{Events: eventsToProto(events)}, nil
+}
+
+func (s *eventService) GetEvent(ctx context.Context, req *eventsv1.GetEventRequest) (*eventsv1.Event, error) {
+	event, err := s.app.models.Events.Get(int(req.Id))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to retreive event")
+	}
+	if event == nil {
+		return nil, status.Error(codes.NotFound, "Event not found")
+	}
+
+	return eventToProto(event), nil
+}
+
+func (s *eventService) CreateEvent(ctx context.Context, req *eventsv1.CreateEventRequest) (*eventsv1.Event, error) {
+	user := grpcUser(ctx)
+	if !s.app.hasVerifiedEmail(user) {
+		return nil, status.Error(codes.PermissionDenied, "Email address must be verified")
+	}
+
+	event, err := eventFromProto(req.Event)
+	if err != nil {
+		return nil, err
+	}
+	event.OwnerId = user.Id
+
+	if err := s.app.models.Events.Insert(event); err != nil {
+		return nil, status.Error(codes.Internal, "Failed to create event")
+	}
+
+	s.app.outbox.Wake()
+
+	return eventToProto(event), nil
+}
+
+// grpcManagedEvent loads an event the caller is allowed to manage. action
+// completes the error message returned to other users.
+func (app *application) grpcManagedEvent(ctx context.Context, id int64, action string) (*database.Event, error) {
+	event, err := app.models.Events.Get(int(id))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to retreive event")
+	}
+	if event == nil {
+		return nil, status.Error(codes.NotFound, "Event not found")
+	}
+
+	if !canManageEvent(grpcUser(ctx), event) {
+		return nil, status.Error(codes.PermissionDenied, "You are not authorized to "+action)
+	}
+
+	return event, nil
+}
+
+func (s *eventService) UpdateEvent(ctx context.Context, req *eventsv1.UpdateEventRequest) (*eventsv1.Event, error) {
+	existingEvent, err := s.app.grpcManagedEvent(ctx, req.Id, "update this event")
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := eventFromProto(req.Event)
+	if err != nil {
+		return nil, err
+	}
+	event.Id = existingEvent.Id
+	event.OwnerId = existingEvent.OwnerId
+
+	if err := s.app.models.Events.Update(event); err != nil {
+		return nil, status.Error(codes.Internal, "Failed to update event")
+	}
+
+	s.app.outbox.Wake()
+
+	return eventToProto(event), nil
+}
+
+func (s *eventService) DeleteEvent(ctx context.Context, req *eventsv1.DeleteEventRequest) (*eventsv1.DeleteEventResponse, error) {
+	event, err := s.app.grpcManagedEvent(ctx, req.Id, "delete this event")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.app.models.Events.Delete(event.Id); err != nil {
+		return nil, status.Error(codes.Internal, "Failed to delete event")
+	}
+
+	s.app.outbox.Wake()
+
+	return &eventsv1.DeleteEventResponse{}, nil
+}
+
+type attendeeService struct {
+	eventsv1.UnimplementedAttendeeServiceServer
+	app *application
+}
+
+func (s *attendeeService) ListEventAttendees(ctx context.Context, req *eventsv1.ListEventAttendeesRequest) (*eventsv1.ListEventAttendeesResponse, error) {
+	users, err := s.app.models.Attendees.GetAttendeesByEventId(int(req.EventId))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to retreive attendees for event")
+	}
+
+	response := &eventsv1.ListEventAttendeesResponse{}
+	for _, user := range users {
+		response.Users = append(response.Users, userToProto(ctx, user))
+	}
+
+	return response, nil
+}
+
+func (s *attendeeService) ListAttendeeEvents(ctx context.Context, req *eventsv1.ListAttendeeEventsRequest) (*eventsv1.ListAttendeeEventsResponse, error) {
+	events, err := s.app.models.Attendees.GetEventsByAttendee(int(req.UserId))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to get events")
+	}
+
+	return &eventsv1.ListAttendeeEventsResponse{Events: eventsToProto(events)}, nil
+}
+
+func (s *attendeeService) AddAttendee(ctx context.Context, req *eventsv1.AddAttendeeRequest) (*eventsv1.Attendee, error) {
+	event, err := s.app.grpcManagedEvent(ctx, req.EventId, "add an attendee to this event")
+	if err != nil {
+		return nil, err
+	}
+
+	userToAdd, err := s.app.models.Users.Get(int(req.UserId))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to retreive user")
+	}
+	if userToAdd == nil {
+		return nil, status.Error(codes.NotFound, "User not found")
+	}
+
+	existingAttendee, err := s.app.models.Attendees.GetByEventAndAttendeeId(event.Id, userToAdd.Id)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to retreive attendee")
+	}
+	if existingAttendee != nil {
+		return nil, status.Error(codes.AlreadyExists, "Attendee already exists")
+	}
+
+	attendee := database.Attendee{
+		EventId: event.Id,
+		UserId:  userToAdd.Id,
+	}
+
+	if _, err := s.app.models.Attendees.Insert(&attendee); err != nil {
+		return nil, status.Error(codes.Internal, "Failed to add attendee")
+	}
+
+	s.app.outbox.Wake()
+
+	return &eventsv1.Attendee{
+		Id:      int64(attendee.Id),
+		EventId: int64(attendee.EventId),
+		UserId:  int64(attendee.UserId),
+	}, nil
+}
+
+func (s *attendeeService) RemoveAttendee(ctx context.Context, req *eventsv1.RemoveAttendeeRequest) (*eventsv1.RemoveAttendeeResponse, error) {
+	event, err := s.app.grpcManagedEvent(ctx, req.EventId, "delete an attendee from this event")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.app.models.Attendees.Delete(event.Id, int(req.UserId)); err != nil {
+		return nil, status.Error(codes.Internal, "Failed to delete attendee from event")
+	}
+
+	s.app.outbox.Wake()
+
+	return &eventsv1.RemoveAttendeeResponse{}, nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+	eventsv1 "github.com/gumeeee/rest-api-in-gin/pkg/pb/events/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestGRPCConn serves app's gRPC services over an in-memory listener and
+// returns a connection to them.
+func newTestGRPCConn(t *testing.T, app *application) *grpc.ClientConn {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := app.grpcServer()
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func withToken(token string) context.Context {
+	if token == "" {
+		return context.Background()
+	}
+
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestGRPCEmailOnlyForSelf(t *testing.T) {
+	app := newTestApp(t)
+	conn := newTestGRPCConn(t, app)
+	users := eventsv1.NewUserServiceClient(conn)
+	attendees := eventsv1.NewAttendeeServiceClient(conn)
+
+	owner := &database.User{Name: "Alice", Email: "alice@example.com", Password: "hash"}
+	guest := &database.User{Name: "Bob", Email: "bob@example.com", Password: "hash"}
+	for _, user := range []*database.User{owner, guest} {
+		if err := app.models.Users.Insert(user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	event := &database.Event{OwnerId: owner.Id, Name: "Meetup", Description: "A meetup for the tests",
+		Date: time.Now().Add(time.Hour), Location: "Online"}
+	if err := app.models.Events.Insert(event); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.models.Attendees.Insert(&database.Attendee{EventId: event.Id, UserId: guest.Id}); err != nil {
+		t.Fatal(err)
+	}
+
+	guestToken, err := app.createAccessToken(guest, allScopes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readOnlyToken, err := app.createAccessToken(guest, []string{scopeEventsRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	me, err := users.GetCurrentUser(withToken(guestToken), &eventsv1.GetCurrentUserRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if me.Email != guest.Email {
+		t.Errorf("GetCurrentUser email = %q, want %q", me.Email, guest.Email)
+	}
+
+	other, err := users.GetUser(withToken(guestToken), &eventsv1.GetUserRequest{Id: int64(owner.Id)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Email != "" {
+		t.Errorf("GetUser of another user exposed email %q", other.Email)
+	}
+
+	for _, method := range []func() error{
+		func() error {
+			_, err := users.GetCurrentUser(withToken(readOnlyToken), &eventsv1.GetCurrentUserRequest{})
+			return err
+		},
+		func() error {
+			_, err := users.GetUser(withToken(readOnlyToken), &eventsv1.GetUserRequest{Id: int64(guest.Id)})
+			return err
+		},
+	} {
+		if err := method(); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("call without the account scope: got %v, want PermissionDenied", err)
+		}
+	}
+
+	for name, token := range map[string]string{"anonymous": "", "attendee": guestToken, "read-only attendee": readOnlyToken} {
+		response, err := attendees.ListEventAttendees(withToken(token), &eventsv1.ListEventAttendeesRequest{EventId: int64(event.Id)})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(response.Users) != 1 {
+			t.Fatalf("%s: got %d attendees, want 1", name, len(response.Users))
+		}
+
+		want := ""
+		if token == guestToken {
+			want = guest.Email
+		}
+		if got := response.Users[0].Email; got != want {
+			t.Errorf("%s: attendee email = %q, want %q", name, got, want)
+		}
+	}
+}
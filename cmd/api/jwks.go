@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gumeeee/rest-api-in-gin/internal/jwtkeys"
+)
+
+// GetJWKS returns the public keys verifying access tokens
+//
+//	@Summary		Returns the public keys verifying access tokens
+//	@Description	JSON Web Key Set of the keys that sign access tokens, selected by the kid header of a token. Empty when tokens are signed with a shared secret.
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	jwtkeys.JWKS
+//	@Router			/.well-known/jwks.json [get]
+func (app *application) getJWKS(ctx *gin.Context) {
+	jwks := jwtkeys.JWKS{Keys: []jwtkeys.JWK{}}
+	if app.signingKeys != nil {
+		jwks = app.signingKeys.JWKS()
+	}
+
+	// Verifiers may cache the keys for a while: a new key is published
+	// before it signs anything once rotation is done by adding it first.
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, jwks)
+}
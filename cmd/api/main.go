@@ -1,14 +1,24 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"flag"
 	"log"
+	"net/http"
+	"os"
+	"time"
 
 	_ "github.com/gumeeee/rest-api-in-gin/docs"
 	"github.com/gumeeee/rest-api-in-gin/internal/database"
-	"github.com/gumeeee/rest-api-in-gin/internal/env"
+	"github.com/gumeeee/rest-api-in-gin/internal/jwtkeys"
+	"github.com/gumeeee/rest-api-in-gin/internal/notifications"
+	"github.com/gumeeee/rest-api-in-gin/internal/oidc"
+	"github.com/gumeeee/rest-api-in-gin/internal/outbox"
+	"github.com/gumeeee/rest-api-in-gin/internal/pubsub"
+	"github.com/gumeeee/rest-api-in-gin/internal/webhooks"
 
-	_ "github.com/joho/godotenv/autoload"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -20,26 +30,146 @@ import (
 // @name Authorization
 // @description Enter your Bearer token in the format **Bearer &alt;token&gt;**
 type application struct {
-	port      int
-	jwtSecret string
-	models    database.Models
+	port                 int
+	grpcPort             int
+	jwtSecret            string
+	signingKeys          *jwtkeys.Set
+	rejectSecretTokens   bool
+	oidc                 *oidc.Provider
+	oidcIssuer           string
+	totpIssuer           string
+	baseURL              string
+	swaggerURL           string
+	timeouts             serverTimeouts
+	db                   *sql.DB
+	models               database.Models
+	notifier             notifications.Notifier
+	loginPolicy          loginPolicy
+	reminders            reminderSchedule
+	backups              backupSchedule
+	webhookClient        *webhooks.Client
+	webhookAllowPrivate  bool
+	webhookWake          chan struct{}
+	outbox               *outbox.Dispatcher
+	hub                  *pubsub.Hub
+	requireVerifiedEmail bool
 }
 
 func main() {
-	db, err := sql.Open("sqlite3", "./data.db")
+	cfg, err := loadConfig(os.Args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+
+		log.Fatal("Invalid configuration: ", err)
+	}
+
+	if cfg.usesDefaultSecret() {
+		log.Print("Warning: using the default JWT_SECRET, which is only accepted in development")
+	}
+
+	var signingKeys *jwtkeys.Set
+	if cfg.JWTKeysDir != "" {
+		signingKeys, err = jwtkeys.LoadDir(cfg.JWTKeysDir, cfg.JWTActiveKey)
+		if err != nil {
+			log.Fatal("Failed to load the JWT signing keys: ", err)
+		}
+
+		log.Printf("Signing access tokens with key %s (%s)", signingKeys.Active().Id, signingKeys.Active().Method.Alg())
+	}
+
+	db, err := database.Open(cfg.DBPath, database.Options{
+		BusyTimeout:  cfg.DBBusyTimeout,
+		MaxOpenConns: cfg.DBMaxOpenConns,
+	})
 	if err != nil {
 		log.Fatal("Failed to connect to the database: ", err)
 	}
 	defer db.Close()
 
+	notifier, err := newNotifier(cfg)
+	if err != nil {
+		log.Fatal("Failed to configure notifications: ", err)
+	}
+
 	models := database.NewModels(db)
 	app := &application{
-		port:      env.GetEnvInt("PORT", 8080),
-		jwtSecret: env.GetEnvString("JWT_SECRET", "secret-jwt-key-123456"),
-		models:    models,
+		port:        cfg.Port,
+		grpcPort:    cfg.GRPCPort,
+		jwtSecret:   cfg.JWTSecret,
+		signingKeys: signingKeys,
+		baseURL:     cfg.BaseURL,
+		swaggerURL:  cfg.SwaggerURL,
+		timeouts: serverTimeouts{
+			read:  cfg.ReadTimeout,
+			write: cfg.WriteTimeout,
+			idle:  cfg.IdleTimeout,
+		},
+		db:       db,
+		models:   models,
+		notifier: notifier,
+		loginPolicy: loginPolicy{
+			maxFailures:   cfg.LoginMaxFailures,
+			lockout:       cfg.LoginLockout,
+			baseDelay:     time.Second,
+			maxDelay:      30 * time.Second,
+			ipMaxFailures: cfg.LoginIPMaxFailures,
+			ipWindow:      cfg.LoginIPWindow,
+		},
+		reminders: reminderSchedule{
+			offsets:  sortOffsets(cfg.ReminderOffsets),
+			interval: cfg.ReminderInterval,
+		},
+		backups: backupSchedule{
+			dir:      cfg.BackupDir,
+			interval: cfg.BackupInterval,
+			keep:     cfg.BackupKeep,
+		},
+		requireVerifiedEmail: cfg.RequireVerifiedEmail,
+		rejectSecretTokens:   cfg.JWTRejectSecretTokens,
+		webhookClient:        webhooks.NewClient(cfg.WebhookTimeout, cfg.WebhookAllowPrivate),
+		webhookAllowPrivate:  cfg.WebhookAllowPrivate,
+		oidcIssuer:           cfg.OIDCIssuer,
+		totpIssuer:           cfg.TOTPIssuer,
+		webhookWake:          make(chan struct{}, 1),
+		outbox:               outbox.NewDispatcher(&models.Outbox, time.Second, cfg.OutboxMaxAttempts, cfg.OutboxRetention),
+		hub:                  pubsub.NewHub(64),
+	}
+
+	if cfg.OIDCIssuer != "" {
+		app.oidc = oidc.New(oidc.Config{
+			Issuer:       cfg.OIDCIssuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       cfg.OIDCScopes,
+		}, &http.Client{Timeout: 10 * time.Second})
 	}
 
+	app.registerOutboxHandlers()
+
+	go app.runReminders(context.Background())
+	go app.runWebhookDeliveries(context.Background())
+	go app.outbox.Run(context.Background())
+	go app.runBackups(context.Background())
+
 	if err := app.serve(); err != nil {
 		log.Fatal("Failed to start the server: ", err)
 	}
 }
+
+// newNotifier delivers notifications through SMTP when SMTP_HOST is set and
+// falls back to writing them to MAIL_LOG_FILE (or stdout) otherwise.
+func newNotifier(cfg *apiConfig) (notifications.Notifier, error) {
+	if cfg.SMTPHost != "" {
+		return notifications.NewSMTPNotifier(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword,
+			cfg.SMTPSender)
+	}
+
+	if cfg.MailLogFile != "" {
+		return notifications.NewFileNotifier(cfg.MailLogFile)
+	}
+
+	return notifications.NewLogNotifier(os.Stdout), nil
+}
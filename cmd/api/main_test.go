@@ -0,0 +1,81 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-migrate/migrate"
+	"github.com/golang-migrate/migrate/database/sqlite3"
+	"github.com/golang-migrate/migrate/source/file"
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+	"github.com/gumeeee/rest-api-in-gin/internal/notifications"
+	"github.com/gumeeee/rest-api-in-gin/internal/outbox"
+	"github.com/gumeeee/rest-api-in-gin/internal/pubsub"
+	"github.com/gumeeee/rest-api-in-gin/internal/webhooks"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+
+	os.Exit(m.Run())
+}
+
+// newTestApp returns an application backed by a migrated database in a
+// temporary directory, configured like main does with the defaults. Its
+// background workers aren't started.
+func newTestApp(t *testing.T) *application {
+	t.Helper()
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "data.db"), database.Options{})
+	if err != nil {
+		t.Fatalf("opening the database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	instance, err := sqlite3.WithInstance(db, &sqlite3.Config{})
+	if err != nil {
+		t.Fatalf("creating the migration instance: %v", err)
+	}
+
+	source, err := (&file.File{}).Open("../migrate/migrations")
+	if err != nil {
+		t.Fatalf("opening the migrations: %v", err)
+	}
+
+	m, err := migrate.NewWithInstance("file", source, "sqlite3", instance)
+	if err != nil {
+		t.Fatalf("creating the migration: %v", err)
+	}
+
+	if err := m.Up(); err != nil {
+		t.Fatalf("running the migrations: %v", err)
+	}
+
+	models := database.NewModels(db)
+
+	return &application{
+		jwtSecret: "test-jwt-secret",
+		baseURL:   "http://localhost:8080",
+		db:        db,
+		models:    models,
+		notifier:  notifications.NewLogNotifier(io.Discard),
+		loginPolicy: loginPolicy{
+			maxFailures:   5,
+			lockout:       15 * time.Minute,
+			baseDelay:     time.Second,
+			maxDelay:      30 * time.Second,
+			ipMaxFailures: 20,
+			ipWindow:      15 * time.Minute,
+		},
+		webhookClient: webhooks.NewClient(time.Second, false),
+		totpIssuer:    "Events",
+		webhookWake:   make(chan struct{}, 1),
+		outbox:        outbox.NewDispatcher(&models.Outbox, time.Second, 10, 7*24*time.Hour),
+		hub:           pubsub.NewHub(64),
+	}
+}
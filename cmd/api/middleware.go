@@ -1,66 +1,282 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
 )
 
+var (
+	errInvalidToken = errors.New("Invalid token")
+	errUnauthorized = errors.New("Unauthorized access")
+	errTokenRevoked = errors.New("Token has been revoked")
+	errDisabled     = errors.New("Account is disabled")
+	errInvalidKey   = errors.New("Invalid API key")
+	errKeyExpired   = errors.New("API key has expired")
+)
+
+// principal is what a request is authenticated as: a user, limited to the
+// scopes of the access token or API key it presented.
+type principal struct {
+	user *database.User
+	// apiKey is nil when the request used an access token.
+	apiKey *database.APIKey
+	scopes []string
+}
+
+// authenticate validates an access token and returns the user it was issued
+// to. The errors it returns are meant to be shown to the client.
+func (app *application) authenticate(tokenString string) (*principal, error) {
+	token, err := jwt.Parse(tokenString, app.accessTokenKey)
+	if err != nil || !token.Valid {
+		return nil, errInvalidToken
+	}
+
+	// Tokens with a purpose (e.g. email verification links) are signed
+	// with the same secret but must never be accepted as access tokens.
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if _, hasPurpose := claims["purpose"]; !ok || hasPurpose {
+		return nil, errInvalidToken
+	}
+
+	// Older tokens carry their expiry in a non-standard expr claim, which
+	// the JWT library doesn't check.
+	if expr, ok := claims["expr"].(float64); ok && claims["exp"] == nil && time.Now().Unix() > int64(expr) {
+		return nil, errInvalidToken
+	}
+
+	userId, _ := claims["userId"].(float64)
+
+	user, err := app.models.Users.Get(int(userId))
+	if err != nil || user == nil {
+		return nil, errUnauthorized
+	}
+
+	// Tokens issued before the user's sessions were revoked (e.g. by a
+	// password reset) carry an older version.
+	tokenVersion, _ := claims["tokenVersion"].(float64)
+	if int(tokenVersion) != user.TokenVersion {
+		return nil, errTokenRevoked
+	}
+
+	if user.IsDisabled() {
+		return nil, errDisabled
+	}
+
+	return &principal{user: user, scopes: tokenScopes(claims)}, nil
+}
+
+// apiKeyTouchInterval limits how often the last use of an API key is
+// written, so busy integrations don't turn every request into a write.
+const apiKeyTouchInterval = time.Minute
+
+// authenticateAPIKey returns the user an API key belongs to, limited to the
+// scopes of the key.
+func (app *application) authenticateAPIKey(key string) (*principal, error) {
+	apiKey, err := app.models.APIKeys.GetByHash(hashToken(key))
+	if err != nil || apiKey == nil {
+		return nil, errInvalidKey
+	}
+
+	now := time.Now()
+	if apiKey.IsExpired(now) {
+		return nil, errKeyExpired
+	}
+
+	user, err := app.models.Users.Get(apiKey.UserId)
+	if err != nil || user == nil {
+		return nil, errUnauthorized
+	}
+
+	if user.IsDisabled() {
+		return nil, errDisabled
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchInterval {
+		if err := app.models.APIKeys.TouchLastUsed(apiKey.Id, now); err != nil {
+			log.Printf("api keys: recording use of key %d: %v", apiKey.Id, err)
+		}
+	}
+
+	return &principal{user: user, apiKey: apiKey, scopes: apiKey.Scopes}, nil
+}
+
+// authenticateRequest checks the credentials sent with a request: an API key
+// in the X-API-Key header or as "Authorization: ApiKey <key>", or a bearer
+// access token.
+func (app *application) authenticateRequest(authHeader, apiKeyHeader string) (*principal, error) {
+	if apiKeyHeader != "" {
+		return app.authenticateAPIKey(apiKeyHeader)
+	}
+
+	if authHeader == "" {
+		return nil, errors.New("Authorization header is required")
+	}
+
+	if key, ok := strings.CutPrefix(authHeader, "ApiKey "); ok {
+		return app.authenticateAPIKey(key)
+	}
+
+	tokenString, ok := strings.CutPrefix(authHeader, "Bearer ")
+	if !ok {
+		return nil, errors.New("Bearer token is required")
+	}
+
+	return app.authenticate(tokenString)
+}
+
 func (app *application) AuthMiddleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		authHeader := ctx.GetHeader("Authorization")
-		if authHeader == "" {
+		auth, err := app.authenticateRequest(ctx.GetHeader("Authorization"), ctx.GetHeader("X-API-Key"))
+		if err != nil {
 			ctx.JSON(http.StatusUnauthorized,
-				gin.H{"error": "Authorization header is required"})
+				gin.H{"error": err.Error()})
 			ctx.Abort()
 			return
 		}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == authHeader {
-			ctx.JSON(http.StatusUnauthorized,
-				gin.H{"error": "Bearer token is required"})
-			ctx.Abort()
-			return
+		ctx.Set("user", auth.user)
+		ctx.Set("scopes", auth.scopes)
+		if auth.apiKey != nil {
+			ctx.Set("apiKey", auth.apiKey)
 		}
 
-		token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
+		ctx.Next()
+	}
+}
 
-			return []byte(app.jwtSecret), nil
-		})
+// OptionalAuthMiddleware authenticates requests that carry credentials like
+// AuthMiddleware, and lets anonymous requests through.
+func (app *application) OptionalAuthMiddleware() gin.HandlerFunc {
+	auth := app.AuthMiddleware()
 
-		if err != nil || !token.Valid {
-			ctx.JSON(http.StatusUnauthorized,
-				gin.H{"error": "Invalid token"})
+	return func(ctx *gin.Context) {
+		if ctx.GetHeader("Authorization") == "" && ctx.GetHeader("X-API-Key") == "" {
+			ctx.Next()
+			return
+		}
+
+		auth(ctx)
+	}
+}
+
+// RequireSession must run after AuthMiddleware and refuses requests made with
+// an API key, for actions that need the user's own login.
+func (app *application) RequireSession() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if app.GetAPIKeyFromContext(ctx) != nil {
+			ctx.JSON(http.StatusForbidden,
+				gin.H{"error": "This action can't be performed with an API key"})
 			ctx.Abort()
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			ctx.JSON(http.StatusUnauthorized,
-				gin.H{"error": "Invalid token"})
+		ctx.Next()
+	}
+}
+
+// RequireScope must run after AuthMiddleware and only lets through requests
+// whose token or API key was granted the scope.
+func (app *application) RequireScope(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !hasScope(app.GetScopesFromContext(ctx), scope) {
+			ctx.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, scope))
+			ctx.JSON(http.StatusForbidden,
+				gin.H{"error": errMissingScope(scope).Error()})
 			ctx.Abort()
 			return
 		}
 
-		userId := claims["userId"].(float64)
+		ctx.Next()
+	}
+}
 
-		user, err := app.models.Users.Get(int(userId))
-		if err != nil {
-			ctx.JSON(http.StatusUnauthorized,
-				gin.H{"error": "Unauthorized access"})
+// RequireAdmin must run after AuthMiddleware and only lets administrators
+// through.
+func (app *application) RequireAdmin() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user := app.GetUserFromContext(ctx)
+		if user.Role != database.RoleAdmin {
+			ctx.JSON(http.StatusForbidden,
+				gin.H{"error": "Administrator access required"})
 			ctx.Abort()
 			return
 		}
 
-		ctx.Set("user", user)
+		ctx.Next()
+	}
+}
+
+// RequireVerifiedEmail must run after AuthMiddleware. When the application is
+// configured to require verified emails, it rejects users who haven't
+// confirmed their address yet.
+func (app *application) RequireVerifiedEmail() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !app.hasVerifiedEmail(app.GetUserFromContext(ctx)) {
+			ctx.JSON(http.StatusForbidden,
+				gin.H{"error": "Email address must be verified"})
+			ctx.Abort()
+			return
+		}
 
 		ctx.Next()
 	}
 }
+
+// requestLogger logs requests like gin's default logger, except for the
+// token query parameter of tokenFromQuery, which is redacted so that access
+// tokens don't end up in the logs.
+func requestLogger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		var statusColor, methodColor, resetColor string
+		if param.IsOutputColor() {
+			statusColor = param.StatusCodeColor()
+			methodColor = param.MethodColor()
+			resetColor = param.ResetColor()
+		}
+
+		if param.Latency > time.Minute {
+			param.Latency = param.Latency.Truncate(time.Second)
+		}
+
+		return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			statusColor, param.StatusCode, resetColor,
+			param.Latency,
+			param.ClientIP,
+			methodColor, param.Method, resetColor,
+			redactQuery(param.Path),
+			param.ErrorMessage,
+		)
+	})
+}
+
+// redactQuery hides the value of the token parameter of a path with its
+// query string.
+func redactQuery(path string) string {
+	path, rawQuery, ok := strings.Cut(path, "?")
+	if !ok {
+		return path
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		// Don't risk logging a token that couldn't be found.
+		return path + "?REDACTED"
+	}
+
+	if query.Has("token") {
+		query.Set("token", "REDACTED")
+		rawQuery = query.Encode()
+	}
+
+	return path + "?" + rawQuery
+}
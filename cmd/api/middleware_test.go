@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRedactQuery(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/api/v1/events", "/api/v1/events"},
+		{"/api/v1/events?page=2", "/api/v1/events?page=2"},
+		{"/api/v1/events/1/chat?token=secret", "/api/v1/events/1/chat?token=REDACTED"},
+		{"/api/v1/events/1/chat?a=1&token=secret&token=other", "/api/v1/events/1/chat?a=1&token=REDACTED"},
+		{"/api/v1/events/1/chat?token=%zz", "/api/v1/events/1/chat?REDACTED"},
+	}
+
+	for _, tt := range tests {
+		if got := redactQuery(tt.path); got != tt.want {
+			t.Errorf("redactQuery(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRequestLoggerRedactsToken(t *testing.T) {
+	var logs bytes.Buffer
+
+	previous := gin.DefaultWriter
+	gin.DefaultWriter = &logs
+	t.Cleanup(func() { gin.DefaultWriter = previous })
+
+	app := newTestApp(t)
+	handler := app.routes()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/events/1/chat?token=secret-token", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if strings.Contains(logs.String(), "secret-token") {
+		t.Errorf("the token was logged: %s", logs.String())
+	}
+	if !strings.Contains(logs.String(), "/api/v1/events/1/chat?token=REDACTED") {
+		t.Errorf("the request wasn't logged: %s", logs.String())
+	}
+}
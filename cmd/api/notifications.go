@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"maps"
+
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+	"github.com/gumeeee/rest-api-in-gin/internal/notifications"
+)
+
+// background runs fn in its own goroutine, logging instead of crashing the
+// server if it panics.
+func (app *application) background(fn func()) {
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				log.Printf("Background task panicked: %v", err)
+			}
+		}()
+
+		fn()
+	}()
+}
+
+// notify renders the named template for a user and delivers it in the
+// background. The user's name is available to templates as .Name.
+func (app *application) notify(user *database.User, templateName string, data map[string]any) {
+	app.background(func() {
+		if err := app.sendNotification(user, templateName, data); err != nil {
+			log.Printf("Failed to send %s notification to %s: %v", templateName, user.Email, err)
+		}
+	})
+}
+
+// sendNotification is the synchronous version of notify, for callers that
+// need to know whether the notification was delivered.
+func (app *application) sendNotification(user *database.User, templateName string, data map[string]any) error {
+	templateData := map[string]any{"Name": user.Name}
+	maps.Copy(templateData, data)
+
+	msg, err := notifications.NewMessage(user.Email, templateName, templateData)
+	if err != nil {
+		return err
+	}
+
+	return app.notifier.Send(msg)
+}
+
+// queuedNotification is the payload of database.TopicNotification: the
+// notification of an event change for one of its attendees.
+type queuedNotification struct {
+	UserId   int             `json:"userId"`
+	Template string          `json:"template"`
+	Event    *database.Event `json:"event"`
+}
+
+// queueNotifications queues the notification of an event change for each
+// user, delivered by sendQueuedNotification.
+func (app *application) queueNotifications(userIds []int, templateName string, event *database.Event) error {
+	if len(userIds) == 0 {
+		return nil
+	}
+
+	payloads := make([]any, len(userIds))
+	for i, userId := range userIds {
+		payloads[i] = queuedNotification{UserId: userId, Template: templateName, Event: event}
+	}
+
+	if err := app.models.Outbox.Insert(database.TopicNotification, payloads...); err != nil {
+		return err
+	}
+
+	app.outbox.Wake()
+
+	return nil
+}
+
+// sendQueuedNotification is the outbox handler delivering a notification
+// queued by queueNotifications.
+func (app *application) sendQueuedNotification(ctx context.Context, msg *database.OutboxMessage) error {
+	var n queuedNotification
+	if err := json.Unmarshal([]byte(msg.Payload), &n); err != nil {
+		return err
+	}
+
+	user, err := app.models.Users.Get(n.UserId)
+	if err != nil || user == nil {
+		return err
+	}
+
+	return app.sendNotification(user, n.Template, map[string]any{"Event": n.Event})
+}
+
+// notifyEventUpdated is the outbox handler telling attendees about changes to
+// an event. Each attendee gets their own message, so that a failure doesn't
+// send the notification again to those who already got it.
+func (app *application) notifyEventUpdated(ctx context.Context, msg *database.OutboxMessage) error {
+	var event database.Event
+	if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+		return err
+	}
+
+	attendees, err := app.models.Attendees.GetAttendeesByEventId(event.Id)
+	if err != nil {
+		return err
+	}
+
+	userIds := make([]int, len(attendees))
+	for i, attendee := range attendees {
+		userIds[i] = attendee.Id
+	}
+
+	return app.queueNotifications(userIds, "event_updated", &event)
+}
+
+// notifyEventCancelled is the outbox handler telling the former attendees of a
+// deleted event that it was cancelled, each through their own message like
+// notifyEventUpdated.
+func (app *application) notifyEventCancelled(ctx context.Context, msg *database.OutboxMessage) error {
+	var deleted database.DeletedEvent
+	if err := json.Unmarshal([]byte(msg.Payload), &deleted); err != nil {
+		return err
+	}
+
+	return app.queueNotifications(deleted.AttendeeIds, "event_cancelled", deleted.Event)
+}
+
+// notifyAttendeeAdded is the outbox handler telling a user they were added to
+// an event.
+func (app *application) notifyAttendeeAdded(ctx context.Context, msg *database.OutboxMessage) error {
+	var attendee database.Attendee
+	if err := json.Unmarshal([]byte(msg.Payload), &attendee); err != nil {
+		return err
+	}
+
+	event, err := app.models.Events.Get(attendee.EventId)
+	if err != nil || event == nil {
+		return err
+	}
+
+	user, err := app.models.Users.Get(attendee.UserId)
+	if err != nil || user == nil {
+		return err
+	}
+
+	return app.sendNotification(user, "attendee_added", map[string]any{"Event": event})
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+	"github.com/gumeeee/rest-api-in-gin/internal/notifications"
+)
+
+// flakyNotifier records the messages it sends, failing the first failures
+// sends to each address of failing.
+type flakyNotifier struct {
+	mu       sync.Mutex
+	failing  map[string]int
+	failures map[string]int
+	sent     map[string][]notifications.Message
+}
+
+func (n *flakyNotifier) Send(msg notifications.Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.failures[msg.To] < n.failing[msg.To] {
+		n.failures[msg.To]++
+		return errors.New("mailbox unavailable")
+	}
+
+	n.sent[msg.To] = append(n.sent[msg.To], msg)
+
+	return nil
+}
+
+// pendingMessages returns the pending outbox messages of a topic.
+func pendingMessages(t *testing.T, app *application, topic string) []*database.OutboxMessage {
+	t.Helper()
+
+	all, err := app.models.Outbox.GetPending(time.Now().Add(time.Hour), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var messages []*database.OutboxMessage
+	for _, msg := range all {
+		if msg.Topic == topic {
+			messages = append(messages, msg)
+		}
+	}
+
+	return messages
+}
+
+func TestNotifyEventUpdatedRetriesPerRecipient(t *testing.T) {
+	app := newTestApp(t)
+	notifier := &flakyNotifier{
+		failing:  map[string]int{"bob@example.com": 1},
+		failures: map[string]int{},
+		sent:     map[string][]notifications.Message{},
+	}
+	app.notifier = notifier
+
+	owner := &database.User{Name: "Owner", Email: "owner@example.com", Password: "hash"}
+	alice := &database.User{Name: "Alice", Email: "alice@example.com", Password: "hash"}
+	bob := &database.User{Name: "Bob", Email: "bob@example.com", Password: "hash"}
+	for _, user := range []*database.User{owner, alice, bob} {
+		if err := app.models.Users.Insert(user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	event := &database.Event{OwnerId: owner.Id, Name: "Meetup", Description: "A meetup for the tests",
+		Date: time.Now().Add(time.Hour), Location: "Online"}
+	if err := app.models.Events.Insert(event); err != nil {
+		t.Fatal(err)
+	}
+	for _, user := range []*database.User{alice, bob} {
+		if _, err := app.models.Attendees.Insert(&database.Attendee{EventId: event.Id, UserId: user.Id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	payload, _ := json.Marshal(event)
+	if err := app.notifyEventUpdated(context.Background(), &database.OutboxMessage{Payload: string(payload)}); err != nil {
+		t.Fatalf("notifyEventUpdated: %v", err)
+	}
+
+	queued := pendingMessages(t, app, database.TopicNotification)
+	if len(queued) != 2 {
+		t.Fatalf("%d notifications queued, want one per attendee", len(queued))
+	}
+
+	var failed []*database.OutboxMessage
+	for _, msg := range queued {
+		if err := app.sendQueuedNotification(context.Background(), msg); err != nil {
+			failed = append(failed, msg)
+		}
+	}
+
+	if len(failed) != 1 {
+		t.Fatalf("%d notifications failed, want Bob's only", len(failed))
+	}
+
+	// Only Bob's notification is tried again.
+	if err := app.sendQueuedNotification(context.Background(), failed[0]); err != nil {
+		t.Fatalf("retrying Bob's notification: %v", err)
+	}
+
+	for _, email := range []string{alice.Email, bob.Email} {
+		sent := notifier.sent[email]
+		if len(sent) != 1 {
+			t.Errorf("%s got %d notifications, want 1", email, len(sent))
+			continue
+		}
+		if sent[0].Subject != "Meetup has been updated" {
+			t.Errorf("%s got %q", email, sent[0].Subject)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+	"github.com/gumeeee/rest-api-in-gin/internal/oidc"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	oidcLoginPurpose = "oidc-login"
+	oidcLoginTTL     = 10 * time.Minute
+	oidcLoginCookie  = "oidc_login"
+	oidcCookiePath   = "/api/v1/auth/oidc"
+)
+
+var (
+	errInvalidOIDCLogin = errors.New("invalid OpenID Connect login state")
+	errEmailNotVerified = errors.New("The provider hasn't verified this email address")
+)
+
+// oidcLogin is what the login endpoint remembers, in a signed cookie, until
+// the provider redirects the user back to the callback.
+type oidcLogin struct {
+	state    string
+	nonce    string
+	verifier string
+	scopes   []string
+}
+
+func (app *application) createOIDCLoginToken(login oidcLogin) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"state":    login.state,
+		"nonce":    login.nonce,
+		"verifier": login.verifier,
+		"scope":    strings.Join(login.scopes, " "),
+		"purpose":  oidcLoginPurpose,
+		"exp":      time.Now().Add(oidcLoginTTL).Unix(),
+	})
+
+	return token.SignedString([]byte(app.jwtSecret))
+}
+
+func (app *application) parseOIDCLoginToken(tokenString string) (*oidcLogin, error) {
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+
+		return []byte(app.jwtSecret), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, errInvalidOIDCLogin
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != oidcLoginPurpose {
+		return nil, errInvalidOIDCLogin
+	}
+
+	var login oidcLogin
+	login.state, _ = claims["state"].(string)
+	login.nonce, _ = claims["nonce"].(string)
+	login.verifier, _ = claims["verifier"].(string)
+	scope, _ := claims["scope"].(string)
+	login.scopes = strings.Fields(scope)
+
+	if login.state == "" || login.nonce == "" || login.verifier == "" {
+		return nil, errInvalidOIDCLogin
+	}
+
+	return &login, nil
+}
+
+// setOIDCLoginCookie stores the login state for the callback. An empty value
+// with a negative max age removes the cookie.
+func (app *application) setOIDCLoginCookie(ctx *gin.Context, value string, maxAge int) {
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oidcLoginCookie, value, maxAge, oidcCookiePath, "",
+		strings.HasPrefix(app.baseURL, "https://"), true)
+}
+
+// OIDCLogin starts a login with the OpenID Connect provider
+//
+//	@Summary		Starts a login with the OpenID Connect provider
+//	@Description	Redirects to the login page of the provider configured by OIDC_ISSUER, using the authorization code flow with PKCE. The provider sends the user back to the callback, which returns an access token limited to the requested scopes (all of them by default).
+//	@Tags			auth
+//	@Param			scopes	query	string	false	"Comma-separated scopes of the access token"
+//	@Success		302
+//	@Failure		502	{object}	map[string]string
+//	@Router			/api/v1/auth/oidc/login [get]
+func (app *application) oidcLogin(ctx *gin.Context) {
+	scopes := allScopes
+	if requested := ctx.Query("scopes"); requested != "" {
+		var err error
+		if scopes, err = normalizeScopes(strings.Split(requested, ",")); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	state, errState := oidc.RandomString()
+	nonce, errNonce := oidc.RandomString()
+	verifier, challenge, errVerifier := oidc.NewVerifier()
+	if err := errors.Join(errState, errNonce, errVerifier); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	authURL, err := app.oidc.AuthCodeURL(ctx.Request.Context(), state, nonce, challenge)
+	if err != nil {
+		log.Printf("oidc: %v", err)
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "OpenID Connect provider is unavailable"})
+		return
+	}
+
+	loginToken, err := app.createOIDCLoginToken(oidcLogin{state: state, nonce: nonce, verifier: verifier, scopes: scopes})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	app.setOIDCLoginCookie(ctx, loginToken, int(oidcLoginTTL.Seconds()))
+
+	ctx.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback completes a login with the OpenID Connect provider
+//
+//	@Summary		Completes a login with the OpenID Connect provider
+//	@Description	Exchanges the authorization code for the user's ID token and returns an access token of this API. The user is found by a previous login with the provider, or by their email when the provider has verified it, and created otherwise. When the user has two-factor authentication enabled, a challenge token is returned instead, to be completed with /auth/login/2fa.
+//	@Tags			auth
+//	@Produce		json
+//	@Param			code	query		string	true	"Authorization code"
+//	@Param			state	query		string	true	"State of the login"
+//	@Success		200		{object}	loginResponse
+//	@Failure		401		{object}	map[string]string
+//	@Failure		403		{object}	map[string]string
+//	@Router			/api/v1/auth/oidc/callback [get]
+func (app *application) oidcCallback(ctx *gin.Context) {
+	cookie, err := ctx.Cookie(oidcLoginCookie)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "No OpenID Connect login in progress"})
+		return
+	}
+
+	// The state can only be used once.
+	app.setOIDCLoginCookie(ctx, "", -1)
+
+	login, err := app.parseOIDCLoginToken(cookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(login.state), []byte(ctx.Query("state"))) != 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired login state"})
+		return
+	}
+
+	if providerError := ctx.Query("error"); providerError != "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Login refused by the provider: " + providerError})
+		return
+	}
+
+	claims, err := app.oidc.Exchange(ctx.Request.Context(), ctx.Query("code"), login.verifier, login.nonce)
+	if err != nil {
+		log.Printf("oidc: %v", err)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "OpenID Connect login failed"})
+		return
+	}
+
+	user, err := app.oidcUser(claims)
+	if err != nil {
+		if errors.Is(err, errEmailNotVerified) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	if user.IsDisabled() {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+		return
+	}
+
+	twoFactor, err := app.models.TwoFactor.Get(user.Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	// The provider vouches for the password, not for the second factor,
+	// which is checked like after a password login.
+	if twoFactor.IsEnabled() {
+		challenge, err := app.createLoginChallengeToken(user, login.scopes)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, loginResponse{TwoFactorRequired: true, ChallengeToken: challenge})
+		return
+	}
+
+	tokenString, err := app.createAccessToken(user, login.scopes)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, loginResponse{Token: tokenString, Scopes: login.scopes})
+}
+
+// oidcUser returns the user an identity of the provider belongs to. Unknown
+// identities are linked to the user with the same email, or to a new user,
+// as long as the provider has verified the email.
+func (app *application) oidcUser(claims *oidc.Claims) (*database.User, error) {
+	now := time.Now()
+
+	identity, err := app.models.Identities.GetBySubject(app.oidcIssuer, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if identity != nil {
+		if err := app.models.Identities.RecordLogin(identity.Id, claims.Email, now); err != nil {
+			return nil, err
+		}
+
+		user, err := app.models.Users.Get(identity.UserId)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, errors.New("identity of a missing user")
+		}
+
+		return user, nil
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, errEmailNotVerified
+	}
+
+	user, err := app.models.Users.GetByEmail(claims.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		if user, err = app.provisionOIDCUser(claims); err != nil {
+			return nil, err
+		}
+	}
+
+	if !user.EmailVerified {
+		if err := app.models.Users.MarkEmailVerified(user.Id); err != nil {
+			return nil, err
+		}
+		user.EmailVerified = true
+	}
+
+	err = app.models.Identities.Insert(&database.Identity{
+		UserId:    user.Id,
+		Issuer:    app.oidcIssuer,
+		Subject:   claims.Subject,
+		Email:     claims.Email,
+		CreatedAt: now.UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// provisionOIDCUser creates the account of someone logging in with the
+// provider for the first time. It gets a random password, which they can
+// replace through a password reset.
+func (app *application) provisionOIDCUser(claims *oidc.Claims) (*database.User, error) {
+	password, err := oidc.RandomString()
+	if err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	name := claims.Name
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	user := database.User{
+		Email:    claims.Email,
+		Password: string(hashedPassword),
+		Name:     name,
+	}
+
+	if err := app.models.Users.Insert(&user); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import "github.com/gumeeee/rest-api-in-gin/internal/database"
+
+// registerOutboxHandlers wires the side effects of data changes. Handler names
+// are stored in the outbox to track progress, so don't rename them.
+func (app *application) registerOutboxHandlers() {
+	topics := []string{
+		database.TopicEventCreated,
+		database.TopicEventUpdated,
+		database.TopicEventDeleted,
+		database.TopicAttendeeAdded,
+		database.TopicAttendeeRemoved,
+	}
+
+	for _, topic := range topics {
+		app.outbox.Register(topic, "webhooks", app.queueWebhookDeliveries)
+		app.outbox.Register(topic, "streams", app.publishToStreams)
+	}
+
+	app.outbox.Register(database.TopicEventUpdated, "notifications", app.notifyEventUpdated)
+	app.outbox.Register(database.TopicEventDeleted, "notifications", app.notifyEventCancelled)
+	app.outbox.Register(database.TopicAttendeeAdded, "notifications", app.notifyAttendeeAdded)
+	app.outbox.Register(database.TopicNotification, "notifications", app.sendQueuedNotification)
+}
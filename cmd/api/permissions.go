@@ -0,0 +1,18 @@
+package main
+
+import "github.com/gumeeee/rest-api-in-gin/internal/database"
+
+// The checks below are shared by the REST handlers and the GraphQL resolvers
+// so both APIs enforce the same rules.
+
+// canManageEvent reports whether the user may change or delete the event and
+// manage its attendees.
+func canManageEvent(user *database.User, event *database.Event) bool {
+	return event.OwnerId == user.Id
+}
+
+// hasVerifiedEmail reports whether the user passes the email verification
+// requirement, if the application enforces one.
+func (app *application) hasVerifiedEmail(user *database.User) bool {
+	return !app.requireVerifiedEmail || user.EmailVerified
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+)
+
+// reminderSchedule configures when attendees are reminded of an event.
+type reminderSchedule struct {
+	offsets  []time.Duration // how long before an event reminders are sent, ascending
+	interval time.Duration   // how often the scheduler looks for due reminders
+}
+
+// sortOffsets returns the reminder offsets in ascending order, without
+// duplicates.
+func sortOffsets(offsets []time.Duration) []time.Duration {
+	return slices.Compact(slices.Sorted(slices.Values(offsets)))
+}
+
+// runReminders sends event reminders until ctx is cancelled.
+func (app *application) runReminders(ctx context.Context) {
+	if len(app.reminders.offsets) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(app.reminders.interval)
+	defer ticker.Stop()
+
+	for {
+		app.sendDueReminders(time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (app *application) sendDueReminders(now time.Time) {
+	offsets := app.reminders.offsets
+	horizon := offsets[len(offsets)-1]
+
+	events, err := app.models.Events.GetUpcoming(now, now.Add(horizon))
+	if err != nil {
+		log.Printf("Failed to retreive upcoming events: %v", err)
+		return
+	}
+
+	for _, event := range events {
+		startsIn := event.Date.Sub(now)
+
+		var due []time.Duration
+		for _, offset := range offsets {
+			if startsIn <= offset {
+				due = append(due, offset)
+			}
+		}
+
+		attendees, err := app.models.Attendees.GetAttendeesByEventId(event.Id)
+		if err != nil {
+			log.Printf("Failed to retreive attendees of event %d: %v", event.Id, err)
+			continue
+		}
+
+		for _, attendee := range attendees {
+			app.sendReminder(event, attendee, due, now)
+		}
+	}
+}
+
+// sendReminder sends the closest due reminder to an attendee. Larger offsets
+// that are also due are marked as sent without sending anything, so attendees
+// added shortly before an event get a single reminder.
+func (app *application) sendReminder(event *database.Event, attendee *database.User, due []time.Duration, now time.Time) {
+	claimed, err := app.models.Reminders.Claim(event.Id, attendee.Id, due[0], now)
+	if err != nil {
+		log.Printf("Failed to claim reminder for event %d: %v", event.Id, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	for _, offset := range due[1:] {
+		if _, err := app.models.Reminders.Claim(event.Id, attendee.Id, offset, now); err != nil {
+			log.Printf("Failed to claim reminder for event %d: %v", event.Id, err)
+		}
+	}
+
+	err = app.sendNotification(attendee, "event_reminder", map[string]any{
+		"Event":    event,
+		"StartsIn": formatStartsIn(event.Date.Sub(now)),
+	})
+	if err != nil {
+		log.Printf("Failed to send reminder for event %d to %s: %v", event.Id, attendee.Email, err)
+
+		if err := app.models.Reminders.Release(event.Id, attendee.Id, due[0]); err != nil {
+			log.Printf("Failed to release reminder for event %d: %v", event.Id, err)
+		}
+	}
+}
+
+func formatStartsIn(d time.Duration) string {
+	if d >= time.Hour {
+		hours := int(d.Round(time.Hour).Hours())
+		if hours == 1 {
+			return "1 hour"
+		}
+
+		return fmt.Sprintf("%d hours", hours)
+	}
+
+	minutes := max(int(d.Round(time.Minute).Minutes()), 1)
+	if minutes == 1 {
+		return "1 minute"
+	}
+
+	return fmt.Sprintf("%d minutes", minutes)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+	"github.com/gumeeee/rest-api-in-gin/internal/notifications"
+)
+
+func TestSendDueRemindersExactlyOnce(t *testing.T) {
+	app := newTestApp(t)
+	notifier := &flakyNotifier{
+		failing:  map[string]int{"carol@example.com": 1},
+		failures: map[string]int{},
+		sent:     map[string][]notifications.Message{},
+	}
+	app.notifier = notifier
+	app.reminders = reminderSchedule{offsets: []time.Duration{time.Hour, 24 * time.Hour}, interval: time.Minute}
+
+	owner := &database.User{Name: "Owner", Email: "owner@example.com", Password: "hash"}
+	alice := &database.User{Name: "Alice", Email: "alice@example.com", Password: "hash"}
+	bob := &database.User{Name: "Bob", Email: "bob@example.com", Password: "hash"}
+	carol := &database.User{Name: "Carol", Email: "carol@example.com", Password: "hash"}
+	for _, user := range []*database.User{owner, alice, bob, carol} {
+		if err := app.models.Users.Insert(user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := time.Now().UTC().Truncate(time.Second)
+	event := &database.Event{OwnerId: owner.Id, Name: "Meetup", Description: "A meetup for the tests",
+		Date: start.Add(25 * time.Hour), Location: "Online"}
+	if err := app.models.Events.Insert(event); err != nil {
+		t.Fatal(err)
+	}
+
+	attend := func(user *database.User) {
+		if _, err := app.models.Attendees.Insert(&database.Attendee{EventId: event.Id, UserId: user.Id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func(when string, want map[*database.User]int) {
+		t.Helper()
+
+		for user, count := range want {
+			if got := len(notifier.sent[user.Email]); got != count {
+				t.Errorf("%s: %s got %d reminders, want %d", when, user.Name, got, count)
+			}
+		}
+	}
+
+	attend(alice)
+
+	app.sendDueReminders(start)
+	check("a day and an hour before", map[*database.User]int{alice: 0})
+
+	app.sendDueReminders(start.Add(2 * time.Hour))
+	app.sendDueReminders(start.Add(3 * time.Hour))
+	check("less than a day before", map[*database.User]int{alice: 1})
+
+	// Bob joins late, when both reminders are due, and Carol's reminder can't
+	// be delivered the first time.
+	attend(bob)
+	attend(carol)
+
+	halfHourBefore := start.Add(24*time.Hour + 30*time.Minute)
+	app.sendDueReminders(halfHourBefore)
+	check("half an hour before", map[*database.User]int{alice: 2, bob: 1, carol: 0})
+
+	// Claims are kept in the database: a restarted server doesn't send them
+	// again, but retries the reminder that failed.
+	restarted := &application{models: app.models, notifier: notifier, reminders: app.reminders}
+	restarted.sendDueReminders(halfHourBefore.Add(time.Minute))
+	check("after a restart", map[*database.User]int{alice: 2, bob: 1, carol: 1})
+
+	if subject := notifier.sent[bob.Email][0].Subject; subject != "Reminder: Meetup starts in 30 minutes" {
+		t.Errorf("Bob got %q", subject)
+	}
+}
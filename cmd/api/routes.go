@@ -9,7 +9,8 @@ import (
 )
 
 func (app *application) routes() http.Handler {
-	g := gin.Default()
+	g := gin.New()
+	g.Use(requestLogger(), gin.Recovery())
 
 	v1 := g.Group("/api/v1")
 	{
@@ -18,29 +19,77 @@ func (app *application) routes() http.Handler {
 		v1.GET("/events/:id", app.getEvent)
 
 		v1.GET("/events/:id/attendees", app.GetAttendeesForEvent)
+		v1.GET("/events/:id/stream", app.streamEvent)
+		v1.GET("/events/:id/chat", tokenFromQuery(), app.AuthMiddleware(), app.RequireScope(scopeChat), app.eventChat)
 
 		v1.GET("/attendees/:id/events", app.GetEventsByAttendee)
 
+		v1.GET("/users/:id", app.getUserProfile)
+
 		v1.POST("/auth/register", app.registerUser)
 		v1.POST("/auth/login", app.login)
+		v1.POST("/auth/login/2fa", app.loginTwoFactor)
+		v1.POST("/auth/forgot-password", app.forgotPassword)
+		v1.POST("/auth/reset-password", app.resetPassword)
+		v1.GET("/auth/verify-email", app.verifyEmail)
+
+		if app.oidc != nil {
+			v1.GET("/auth/oidc/login", app.oidcLogin)
+			v1.GET("/auth/oidc/callback", app.oidcCallback)
+		}
 	}
 
 	authGroup := v1.Group("/")
 	authGroup.Use(app.AuthMiddleware())
 	{
-		authGroup.POST("/events", app.createEvent)
-		authGroup.PUT("/events/:id", app.updateEvent)
-		authGroup.DELETE("/events/:id", app.deleteEvent)
-		authGroup.POST("/events/:id/attendees/:userId", app.AddAttendeeToEvent)
-		authGroup.DELETE("/events/:id/attendees/:userId", app.DeleteAttendeeFromEvent)
+		authGroup.POST("/auth/verify-email/resend", app.RequireScope(scopeAccount), app.resendVerificationEmail)
+		authGroup.POST("/events", app.RequireScope(scopeEventsWrite), app.RequireVerifiedEmail(), app.createEvent)
+		authGroup.PUT("/events/:id", app.RequireScope(scopeEventsWrite), app.updateEvent)
+		authGroup.DELETE("/events/:id", app.RequireScope(scopeEventsWrite), app.deleteEvent)
+		authGroup.POST("/events/:id/attendees/:userId", app.RequireScope(scopeAttendeesWrite), app.AddAttendeeToEvent)
+		authGroup.DELETE("/events/:id/attendees/:userId", app.RequireScope(scopeAttendeesWrite), app.DeleteAttendeeFromEvent)
+		authGroup.GET("/events/:id/messages", app.RequireScope(scopeEventsRead), app.getEventMessages)
+
+		authGroup.POST("/webhooks", app.RequireScope(scopeWebhooksWrite), app.createWebhook)
+		authGroup.GET("/webhooks", app.RequireScope(scopeWebhooksRead), app.getWebhooks)
+		authGroup.DELETE("/webhooks/:id", app.RequireScope(scopeWebhooksWrite), app.deleteWebhook)
+		authGroup.GET("/webhooks/:id/deliveries", app.RequireScope(scopeWebhooksRead), app.getWebhookDeliveries)
+		authGroup.POST("/webhooks/:id/deliveries/:deliveryId/redeliver", app.RequireScope(scopeWebhooksWrite), app.redeliverWebhook)
+
+		authGroup.GET("/users/me", app.RequireScope(scopeAccount), app.getMe)
+		authGroup.PATCH("/users/me", app.RequireScope(scopeAccount), app.updateMe)
+		authGroup.DELETE("/users/me", app.RequireSession(), app.RequireScope(scopeAccount), app.deleteAccount)
+		authGroup.GET("/users/me/export", app.RequireSession(), app.RequireScope(scopeAccount), app.exportPersonalData)
+		authGroup.POST("/users/me/password", app.RequireSession(), app.RequireScope(scopeAccount), app.changePassword)
+		authGroup.POST("/users/me/email", app.RequireSession(), app.RequireScope(scopeAccount), app.changeEmail)
+
+		authGroup.GET("/auth/2fa", app.RequireSession(), app.RequireScope(scopeAccount), app.getTwoFactorStatus)
+		authGroup.POST("/auth/2fa/enroll", app.RequireSession(), app.RequireScope(scopeAccount), app.enrollTwoFactor)
+		authGroup.POST("/auth/2fa/verify", app.RequireSession(), app.RequireScope(scopeAccount), app.verifyTwoFactor)
+		authGroup.POST("/auth/2fa/recovery-codes", app.RequireSession(), app.RequireScope(scopeAccount), app.regenerateRecoveryCodes)
+		authGroup.POST("/auth/2fa/disable", app.RequireSession(), app.RequireScope(scopeAccount), app.disableTwoFactor)
+
+		authGroup.POST("/api-keys", app.RequireSession(), app.RequireScope(scopeAccount), app.createAPIKey)
+		authGroup.GET("/api-keys", app.RequireSession(), app.RequireScope(scopeAccount), app.getAPIKeys)
+		authGroup.DELETE("/api-keys/:id", app.RequireSession(), app.RequireScope(scopeAccount), app.revokeAPIKey)
+	}
+
+	adminGroup := v1.Group("/admin")
+	adminGroup.Use(app.AuthMiddleware(), app.RequireAdmin(), app.RequireScope(scopeAdmin))
+	{
+		adminGroup.POST("/users/:id/unlock", app.unlockUser)
 	}
 
+	g.GET("/.well-known/jwks.json", app.getJWKS)
+
+	g.POST("/graphql", app.OptionalAuthMiddleware(), app.graphqlHandler(app.newGraphQLSchema()))
+
 	g.GET("/swagger/*any", func(ctx *gin.Context) {
 		if ctx.Request.RequestURI == "/swagger/" {
 			ctx.Redirect(302, "/swagger/index.html")
 		}
 
-		ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("http://localhost:8080/swagger/doc.json"))(ctx)
+		ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL(app.swaggerURL))(ctx)
 	})
 
 	return g
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Scopes limit what an access token or API key may do on behalf of its user.
+// Routes declare the scope they require in app.routes().
+const (
+	scopeEventsRead     = "events:read"
+	scopeEventsWrite    = "events:write"
+	scopeAttendeesWrite = "attendees:write"
+	scopeChat           = "chat"
+	scopeWebhooksRead   = "webhooks:read"
+	scopeWebhooksWrite  = "webhooks:write"
+	scopeAccount        = "account"
+	scopeAdmin          = "admin"
+)
+
+// allScopes is granted to tokens issued without an explicit list of scopes.
+var allScopes = []string{
+	scopeEventsRead,
+	scopeEventsWrite,
+	scopeAttendeesWrite,
+	scopeChat,
+	scopeWebhooksRead,
+	scopeWebhooksWrite,
+	scopeAccount,
+	scopeAdmin,
+}
+
+func errMissingScope(scope string) error {
+	return fmt.Errorf("Missing required scope: %s", scope)
+}
+
+func hasScope(granted []string, scope string) bool {
+	return slices.Contains(granted, scope)
+}
+
+// normalizeScopes sorts and deduplicates requested scopes, returning an error
+// naming the first one that doesn't exist.
+func normalizeScopes(scopes []string) ([]string, error) {
+	for _, scope := range scopes {
+		if !slices.Contains(allScopes, scope) {
+			return nil, fmt.Errorf("Unknown scope: %s", scope)
+		}
+	}
+
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+
+	return slices.Compact(scopes), nil
+}
+
+// tokenScopes returns the scopes in the space-separated scope claim of an
+// access token. Tokens issued before scopes existed have full access.
+func tokenScopes(claims jwt.MapClaims) []string {
+	scope, ok := claims["scope"].(string)
+	if !ok {
+		return allScopes
+	}
+
+	return strings.Fields(scope)
+}
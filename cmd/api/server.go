@@ -7,16 +7,33 @@ import (
 	"time"
 )
 
+// serverTimeouts bound how long the HTTP server waits on clients.
+type serverTimeouts struct {
+	read  time.Duration
+	write time.Duration
+	idle  time.Duration
+}
+
 func (app *application) serve() error {
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", app.port),
 		Handler:      app.routes(),
-		IdleTimeout:  time.Minute,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 30 * time.Second,
+		IdleTimeout:  app.timeouts.idle,
+		ReadTimeout:  app.timeouts.read,
+		WriteTimeout: app.timeouts.write,
 	}
 
-	log.Printf("Starting server on port %d", app.port)
+	errs := make(chan error, 2)
+
+	go func() {
+		errs <- app.serveGRPC()
+	}()
+
+	go func() {
+		log.Printf("Starting server on port %d", app.port)
+		errs <- server.ListenAndServe()
+	}()
 
-	return server.ListenAndServe()
+	// Both servers run until one of them fails.
+	return <-errs
 }
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+	"github.com/gumeeee/rest-api-in-gin/internal/pubsub"
+)
+
+const streamHeartbeatInterval = 15 * time.Second
+
+func eventStreamTopic(eventId int) string {
+	return "event:" + strconv.Itoa(eventId)
+}
+
+// publishToStreams is the outbox handler forwarding event and attendee changes
+// to the clients streaming the event.
+func (app *application) publishToStreams(ctx context.Context, msg *database.OutboxMessage) error {
+	var ref struct {
+		Id      int `json:"id"`
+		EventId int `json:"eventId"`
+	}
+
+	if err := json.Unmarshal([]byte(msg.Payload), &ref); err != nil {
+		return err
+	}
+
+	eventId := ref.EventId
+	if eventId == 0 {
+		eventId = ref.Id
+	}
+
+	data := []byte(msg.Payload)
+
+	// Streams are public: the ids of the attendees of a deleted event, only
+	// kept to notify them, are left out.
+	if msg.Topic == database.TopicEventDeleted {
+		var deleted database.DeletedEvent
+		if err := json.Unmarshal(data, &deleted); err != nil {
+			return err
+		}
+
+		var err error
+		if data, err = json.Marshal(deleted.Event); err != nil {
+			return err
+		}
+	}
+
+	app.hub.Publish(eventStreamTopic(eventId), pubsub.Message{
+		Id:   msg.Id,
+		Type: msg.Topic,
+		Data: json.RawMessage(data),
+	})
+
+	return nil
+}
+
+// StreamEvent streams live changes of an event
+//
+//	@Summary		Streams changes of an event
+//	@Description	Server-Sent Events stream of the event's edits and attendee joins and leaves. Event names are event.updated, event.deleted (with the event, without its attendees), attendee.added and attendee.removed; a ping is sent every 15 seconds. Clients that can't keep up receive an evicted event and should reconnect.
+//	@Tags			events
+//	@Produce		text/event-stream
+//	@Param			id	path	int	true	"Event ID"
+//	@Success		200
+//	@Router			/api/v1/events/{id}/stream [get]
+func (app *application) streamEvent(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := app.models.Events.Get(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retreive event"})
+		return
+	}
+	if event == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	// The server's write timeout would otherwise cut long-lived streams.
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Failed to clear write deadline for event stream: %v", err)
+	}
+
+	sub := app.hub.Subscribe(eventStreamTopic(event.Id))
+	defer sub.Close()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Render(http.StatusOK, sse.Event{Event: "ready", Data: gin.H{"eventId": event.Id}})
+	ctx.Writer.Flush()
+
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case msg, ok := <-sub.C:
+			if !ok {
+				if sub.Evicted() {
+					ctx.SSEvent("evicted", gin.H{"error": "Client is too slow, reconnect to resume"})
+				}
+				return false
+			}
+
+			ctx.Render(-1, sse.Event{
+				Id:    strconv.Itoa(msg.Id),
+				Event: msg.Type,
+				Data:  msg.Data,
+			})
+
+			return msg.Type != database.TopicEventDeleted
+		case <-heartbeat.C:
+			ctx.SSEvent("ping", time.Now().Unix())
+			return true
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+)
+
+func TestPublishToStreamsHidesAttendeesOfDeletedEvent(t *testing.T) {
+	app := newTestApp(t)
+
+	event := &database.Event{Id: 7, OwnerId: 1, Name: "Meetup", Description: "A meetup for the tests",
+		Date: time.Now(), Location: "Online"}
+
+	tests := []struct {
+		topic   string
+		payload any
+	}{
+		{database.TopicEventUpdated, event},
+		{database.TopicEventDeleted, database.DeletedEvent{Event: event, AttendeeIds: []int{2, 3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.topic, func(t *testing.T) {
+			sub := app.hub.Subscribe(eventStreamTopic(event.Id))
+			defer sub.Close()
+
+			payload, _ := json.Marshal(tt.payload)
+			msg := &database.OutboxMessage{Id: 1, Topic: tt.topic, Payload: string(payload)}
+			if err := app.publishToStreams(context.Background(), msg); err != nil {
+				t.Fatal(err)
+			}
+
+			var published []byte
+			select {
+			case m := <-sub.C:
+				published = m.Data.(json.RawMessage)
+			case <-time.After(time.Second):
+				t.Fatal("nothing published")
+			}
+
+			if strings.Contains(string(published), "attendeeIds") {
+				t.Errorf("published %s, with the attendees", published)
+			}
+
+			var got database.Event
+			if err := json.Unmarshal(published, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Id != event.Id || got.Name != event.Name {
+				t.Errorf("published %+v, want event %d", got, event.Id)
+			}
+		})
+	}
+}
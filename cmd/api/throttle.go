@@ -0,0 +1,167 @@
+package main
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+)
+
+// loginPolicy controls how failed logins are throttled, both per account and
+// per client IP.
+type loginPolicy struct {
+	maxFailures   int
+	lockout       time.Duration
+	baseDelay     time.Duration
+	maxDelay      time.Duration
+	ipMaxFailures int
+	ipWindow      time.Duration
+}
+
+// delay returns how long an account has to wait before the next login attempt
+// after the given number of consecutive failures. It doubles with every
+// failure, up to maxDelay.
+func (p loginPolicy) delay(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+
+	delay := p.baseDelay
+	for i := 1; i < failures && delay < p.maxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, p.maxDelay)
+}
+
+// retryAfter returns how long the user still has to wait before trying to log
+// in again, or zero if a new attempt is allowed.
+func (p loginPolicy) retryAfter(user *database.User, now time.Time) time.Duration {
+	if user.IsLocked(now) {
+		return user.LockedUntil.Sub(now)
+	}
+
+	if user.LastFailedLoginAt == nil {
+		return 0
+	}
+
+	return max(user.LastFailedLoginAt.Add(p.delay(user.FailedLogins)).Sub(now), 0)
+}
+
+// ipThrottled refuses logins from client IPs with too many recent failures.
+// It writes the error response itself and returns true when the handler
+// should stop.
+func (app *application) ipThrottled(ctx *gin.Context, ip string, now time.Time) bool {
+	ipFailures, err := app.models.LoginAttempts.CountFailuresByIP(ip, now.Add(-app.loginPolicy.ipWindow))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return true
+	}
+
+	if ipFailures >= app.loginPolicy.ipMaxFailures {
+		setRetryAfter(ctx, app.loginPolicy.ipWindow)
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts, try again later"})
+		return true
+	}
+
+	return false
+}
+
+// passwordResetsThrottled refuses password reset requests from client IPs
+// which made too many of them recently, with the limits of ipThrottled.
+func (app *application) passwordResetsThrottled(ctx *gin.Context, ip string, now time.Time) bool {
+	requests, err := app.models.PasswordResets.CountRequestsByIP(ip, now.Add(-app.loginPolicy.ipWindow))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return true
+	}
+
+	if requests >= app.loginPolicy.ipMaxFailures {
+		setRetryAfter(ctx, app.loginPolicy.ipWindow)
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many password reset requests, try again later"})
+		return true
+	}
+
+	return false
+}
+
+// accountThrottled refuses logins to locked accounts and to accounts which
+// must wait after their last failure, like ipThrottled.
+func (app *application) accountThrottled(ctx *gin.Context, user *database.User, now time.Time) bool {
+	if user.IsLocked(now) {
+		setRetryAfter(ctx, app.loginPolicy.retryAfter(user, now))
+		ctx.JSON(http.StatusLocked, gin.H{"error": "Account is temporarily locked"})
+		return true
+	}
+
+	if wait := app.loginPolicy.retryAfter(user, now); wait > 0 {
+		setRetryAfter(ctx, wait)
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts, try again later"})
+		return true
+	}
+
+	return false
+}
+
+// unknownAccount returns a stand-in for an email without an account, whose
+// failed logins are counted from the login attempts made with it, so that
+// accountThrottled answers for it as it would for a real account.
+func (app *application) unknownAccount(email string) (*database.User, error) {
+	failures, lastFailure, err := app.models.LoginAttempts.GetFailureStreak(email)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &database.User{Email: email, FailedLogins: failures, LastFailedLoginAt: lastFailure}
+	if failures >= app.loginPolicy.maxFailures {
+		lockedUntil := lastFailure.Add(app.loginPolicy.lockout)
+		user.LockedUntil = &lockedUntil
+	}
+
+	return user, nil
+}
+
+func setRetryAfter(ctx *gin.Context, wait time.Duration) {
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+}
+
+func (app *application) recordLoginAttempt(email, ip string, success bool) {
+	attempt := database.LoginAttempt{
+		Email:     email,
+		IPAddress: ip,
+		Success:   success,
+	}
+
+	if err := app.models.LoginAttempts.Insert(&attempt); err != nil {
+		log.Printf("Failed to record login attempt for %s: %v", email, err)
+	}
+}
+
+// registerFailedLogin counts a failed password check against the user and
+// locks the account once the policy's limit is reached.
+func (app *application) registerFailedLogin(user *database.User, now time.Time) error {
+	failures, err := app.models.Users.RecordFailedLogin(user.Id, now)
+	if err != nil {
+		return err
+	}
+
+	if failures < app.loginPolicy.maxFailures {
+		return nil
+	}
+
+	lockedUntil := now.Add(app.loginPolicy.lockout)
+	if err := app.models.Users.Lock(user.Id, lockedUntil); err != nil {
+		return err
+	}
+
+	app.notify(user, "account_locked", map[string]any{
+		"Failures":    failures,
+		"LockedUntil": lockedUntil,
+	})
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+	"github.com/gumeeee/rest-api-in-gin/internal/jwtkeys"
+)
+
+// generateOneTimeToken returns a random token to hand out to the user together
+// with the hash that should be stored in the database.
+func generateOneTimeToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+const accessTokenTTL = 24 * time.Hour
+
+// createAccessToken issues the JWT returned to clients after a successful
+// login, limited to the given scopes. The token version lets us revoke every
+// token of a user at once.
+// Tokens are signed by the active asymmetric key when JWT_KEYS_DIR is set,
+// and with the shared secret otherwise.
+func (app *application) createAccessToken(user *database.User, scopes []string) (string, error) {
+	claims := jwt.MapClaims{
+		"userId":       user.Id,
+		"tokenVersion": user.TokenVersion,
+		"scope":        strings.Join(scopes, " "),
+		"exp":          time.Now().Add(accessTokenTTL).Unix(),
+	}
+
+	if app.signingKeys != nil {
+		return app.signingKeys.Sign(claims)
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(app.jwtSecret))
+}
+
+// accessTokenKey returns the key verifying an access token: the key named by
+// its kid header, or the shared secret for tokens without one, which were
+// signed before asymmetric keys were configured. Those are refused once
+// rejectSecretTokens is set.
+func (app *application) accessTokenKey(t *jwt.Token) (interface{}, error) {
+	if kid, ok := t.Header["kid"].(string); ok {
+		if app.signingKeys == nil {
+			return nil, jwtkeys.ErrUnknownKey
+		}
+
+		return app.signingKeys.VerificationKey(kid, t.Method)
+	}
+
+	if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	if app.signingKeys != nil && app.rejectSecretTokens {
+		return nil, jwtkeys.ErrUnknownKey
+	}
+
+	return []byte(app.jwtSecret), nil
+}
+
+const (
+	emailVerificationPurpose = "verify-email"
+	emailVerificationTTL     = 48 * time.Hour
+)
+
+var errInvalidVerificationToken = errors.New("invalid verification token")
+
+// createEmailVerificationToken signs a token proving that whoever holds it
+// received mail at the user's current address. Changing the email makes
+// previously issued tokens useless.
+func (app *application) createEmailVerificationToken(user *database.User) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"userId":  user.Id,
+		"email":   user.Email,
+		"purpose": emailVerificationPurpose,
+		"exp":     time.Now().Add(emailVerificationTTL).Unix(),
+	})
+
+	return token.SignedString([]byte(app.jwtSecret))
+}
+
+// parseEmailVerificationToken validates a token created by
+// createEmailVerificationToken and returns the user id and email it was
+// issued for.
+func (app *application) parseEmailVerificationToken(tokenString string) (int, string, error) {
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+
+		return []byte(app.jwtSecret), nil
+	})
+	if err != nil || !token.Valid {
+		return 0, "", errInvalidVerificationToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != emailVerificationPurpose {
+		return 0, "", errInvalidVerificationToken
+	}
+
+	userId, ok := claims["userId"].(float64)
+	if !ok {
+		return 0, "", errInvalidVerificationToken
+	}
+
+	email, ok := claims["email"].(string)
+	if !ok {
+		return 0, "", errInvalidVerificationToken
+	}
+
+	return int(userId), email, nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+	"github.com/gumeeee/rest-api-in-gin/internal/jwtkeys"
+)
+
+func TestAccessTokenKey(t *testing.T) {
+	pemKey, err := jwtkeys.Generate(jwtkeys.RS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := jwtkeys.ParseKey("2024-01", pemKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := jwtkeys.NewSet(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM, err := key.PublicPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &database.User{Id: 1}
+	claims := jwt.MapClaims{"userId": 1, "exp": time.Now().Add(time.Hour).Unix()}
+
+	sign := func(method jwt.SigningMethod, kid string, secret any) string {
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+
+		signed, err := token.SignedString(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return signed
+	}
+
+	withSecret := sign(jwt.SigningMethodHS256, "", []byte("test-jwt-secret"))
+	withKey, err := keys.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		keys         *jwtkeys.Set
+		rejectSecret bool
+		token        string
+		valid        bool
+	}{
+		{"secret without keys", nil, false, withSecret, true},
+		{"secret during the rotation", keys, false, withSecret, true},
+		{"secret after the rotation", keys, true, withSecret, false},
+		{"key", keys, true, withKey, true},
+		{"key without keys", nil, false, withKey, false},
+		{"RS256 without kid", keys, false, sign(jwt.SigningMethodRS256, "", rsaKey), false},
+		// The public key, which anyone can get from the JWKS, used as an
+		// HMAC secret.
+		{"HS256 naming the key", keys, false, sign(jwt.SigningMethodHS256, key.Id, publicPEM), false},
+		{"unknown kid", keys, false, sign(jwt.SigningMethodRS256, "2023-12", rsaKey), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(t)
+			app.signingKeys = tt.keys
+			app.rejectSecretTokens = tt.rejectSecret
+
+			token, err := jwt.Parse(tt.token, app.accessTokenKey)
+			if valid := err == nil && token.Valid; valid != tt.valid {
+				t.Errorf("valid = %t (%v), want %t", valid, err, tt.valid)
+			}
+		})
+	}
+
+	// Once the rotation is over, new tokens are still accepted.
+	app := newTestApp(t)
+	app.signingKeys = keys
+	app.rejectSecretTokens = true
+
+	issued, err := app.createAccessToken(user, allScopes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jwt.Parse(issued, app.accessTokenKey); err != nil {
+		t.Errorf("a new token was refused: %v", err)
+	}
+}
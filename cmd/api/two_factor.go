@@ -0,0 +1,476 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+	"github.com/gumeeee/rest-api-in-gin/internal/totp"
+)
+
+const (
+	loginChallengePurpose = "login-2fa"
+	loginChallengeTTL     = 5 * time.Minute
+	recoveryCodeCount     = 10
+)
+
+var errInvalidChallenge = errors.New("Invalid or expired challenge token")
+
+type twoFactorStatusResponse struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabledAt"`
+	RecoveryCodesRemaining int        `json:"recoveryCodesRemaining"`
+}
+
+type twoFactorEnrollResponse struct {
+	Secret string `json:"secret"`
+	// ProvisioningURI is the otpauth:// URI to show as a QR code.
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// twoFactorRequest carries either a code of the authenticator app or a
+// recovery code.
+type twoFactorRequest struct {
+	Code         string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type loginTwoFactorRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	twoFactorRequest
+}
+
+// createLoginChallengeToken signs the token returned by app.login when the
+// password was right but a second factor is still needed. It carries the
+// scopes of the access token to issue.
+func (app *application) createLoginChallengeToken(user *database.User, scopes []string) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"userId":       user.Id,
+		"tokenVersion": user.TokenVersion,
+		"scope":        strings.Join(scopes, " "),
+		"purpose":      loginChallengePurpose,
+		"exp":          time.Now().Add(loginChallengeTTL).Unix(),
+	})
+
+	return token.SignedString([]byte(app.jwtSecret))
+}
+
+// parseLoginChallengeToken validates a token created by
+// createLoginChallengeToken and returns the user it was issued to with the
+// requested scopes.
+func (app *application) parseLoginChallengeToken(tokenString string) (*database.User, []string, error) {
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+
+		return []byte(app.jwtSecret), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, nil, errInvalidChallenge
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != loginChallengePurpose {
+		return nil, nil, errInvalidChallenge
+	}
+
+	userId, _ := claims["userId"].(float64)
+
+	user, err := app.models.Users.Get(int(userId))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// A password change since the first step voids the challenge.
+	tokenVersion, _ := claims["tokenVersion"].(float64)
+	if user == nil || int(tokenVersion) != user.TokenVersion {
+		return nil, nil, errInvalidChallenge
+	}
+
+	return user, tokenScopes(claims), nil
+}
+
+// generateRecoveryCodes returns new recovery codes, formatted as xxxxx-xxxxx,
+// together with the hashes to store.
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(encoding.EncodeToString(b))[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hashRecoveryCode(code)
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode hashes a recovery code the way it was typed, ignoring case,
+// spaces and dashes.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+
+	return hashToken(code)
+}
+
+// verifySecondFactor checks a code of the authenticator app, which can't be
+// used again afterwards, or spends a recovery code.
+func (app *application) verifySecondFactor(twoFactor *database.TwoFactor, request twoFactorRequest) (bool, error) {
+	now := time.Now()
+
+	if request.Code != "" {
+		counter, ok := totp.Validate(twoFactor.Secret, request.Code, now, twoFactor.LastCounter)
+		if !ok {
+			return false, nil
+		}
+
+		return app.models.TwoFactor.UseCounter(twoFactor.UserId, counter)
+	}
+
+	return app.models.TwoFactor.UseRecoveryCode(twoFactor.UserId, hashRecoveryCode(request.RecoveryCode), now)
+}
+
+// enabledTwoFactor loads the two-factor secret of the authenticated user. It
+// writes the error response itself and returns false when the handler should
+// stop.
+func (app *application) enabledTwoFactor(ctx *gin.Context) (*database.TwoFactor, bool) {
+	user := app.GetUserFromContext(ctx)
+
+	twoFactor, err := app.models.TwoFactor.Get(user.Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return nil, false
+	}
+
+	if !twoFactor.IsEnabled() {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is not enabled"})
+		return nil, false
+	}
+
+	return twoFactor, true
+}
+
+// GetTwoFactorStatus returns the two-factor authentication status of the user
+//
+//	@Summary		Returns the two-factor authentication status
+//	@Description	Tells whether two-factor authentication is enabled for the authenticated user and how many recovery codes are left
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	twoFactorStatusResponse
+//	@Router			/api/v1/auth/2fa [get]
+//	@Security		BearerAuth
+func (app *application) getTwoFactorStatus(ctx *gin.Context) {
+	user := app.GetUserFromContext(ctx)
+
+	twoFactor, err := app.models.TwoFactor.Get(user.Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	response := twoFactorStatusResponse{Enabled: twoFactor.IsEnabled()}
+	if response.Enabled {
+		response.EnabledAt = twoFactor.EnabledAt
+
+		response.RecoveryCodesRemaining, err = app.models.TwoFactor.CountRecoveryCodes(user.Id)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// EnrollTwoFactor starts the enrollment of an authenticator app
+//
+//	@Summary		Starts two-factor authentication enrollment
+//	@Description	Generates a TOTP secret and returns it with its otpauth:// provisioning URI, to be shown as a QR code. Two-factor authentication is only enabled once a code is confirmed with /auth/2fa/verify.
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	twoFactorEnrollResponse
+//	@Failure		409	{object}	map[string]string
+//	@Router			/api/v1/auth/2fa/enroll [post]
+//	@Security		BearerAuth
+func (app *application) enrollTwoFactor(ctx *gin.Context) {
+	user := app.GetUserFromContext(ctx)
+
+	twoFactor, err := app.models.TwoFactor.Get(user.Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	if twoFactor.IsEnabled() {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	if err := app.models.TwoFactor.Begin(user.Id, secret, time.Now()); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, twoFactorEnrollResponse{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(app.totpIssuer, user.Email, secret),
+	})
+}
+
+// VerifyTwoFactor confirms the enrollment of an authenticator app
+//
+//	@Summary		Enables two-factor authentication
+//	@Description	Confirms the enrollment with a code of the authenticator app and enables two-factor authentication. The recovery codes, which can each replace a code once, are only returned here.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		twoFactorCodeRequest	true	"Code"
+//	@Success		200		{object}	recoveryCodesResponse
+//	@Failure		409		{object}	map[string]string
+//	@Failure		422		{object}	map[string]string
+//	@Router			/api/v1/auth/2fa/verify [post]
+//	@Security		BearerAuth
+func (app *application) verifyTwoFactor(ctx *gin.Context) {
+	var request twoFactorCodeRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := app.GetUserFromContext(ctx)
+
+	twoFactor, err := app.models.TwoFactor.Get(user.Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	if twoFactor == nil || twoFactor.IsEnabled() {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "No two-factor enrollment in progress"})
+		return
+	}
+
+	counter, ok := totp.Validate(twoFactor.Secret, request.Code, time.Now(), twoFactor.LastCounter)
+	if !ok {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid authentication code"})
+		return
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	err = app.models.TwoFactor.Enable(user.Id, counter, hashes, time.Now())
+	if err != nil {
+		if errors.Is(err, database.ErrNoEnrollment) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "No two-factor enrollment in progress"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user
+//
+//	@Summary		Regenerates the recovery codes
+//	@Description	Replaces every recovery code of the authenticated user with new ones, which are only returned here. Requires a code of the authenticator app.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		twoFactorCodeRequest	true	"Code"
+//	@Success		200		{object}	recoveryCodesResponse
+//	@Failure		409		{object}	map[string]string
+//	@Failure		422		{object}	map[string]string
+//	@Router			/api/v1/auth/2fa/recovery-codes [post]
+//	@Security		BearerAuth
+func (app *application) regenerateRecoveryCodes(ctx *gin.Context) {
+	var request twoFactorCodeRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	twoFactor, ok := app.enabledTwoFactor(ctx)
+	if !ok {
+		return
+	}
+
+	valid, err := app.verifySecondFactor(twoFactor, twoFactorRequest{Code: request.Code})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+	if !valid {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid authentication code"})
+		return
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	if err := app.models.TwoFactor.ReplaceRecoveryCodes(twoFactor.UserId, hashes); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor turns two-factor authentication off
+//
+//	@Summary		Disables two-factor authentication
+//	@Description	Removes the TOTP secret and recovery codes of the authenticated user. Requires a code of the authenticator app or a recovery code.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body	twoFactorRequest	true	"Code or recovery code"
+//	@Success		204
+//	@Failure		409	{object}	map[string]string
+//	@Failure		422	{object}	map[string]string
+//	@Router			/api/v1/auth/2fa/disable [post]
+//	@Security		BearerAuth
+func (app *application) disableTwoFactor(ctx *gin.Context) {
+	var request twoFactorRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	twoFactor, ok := app.enabledTwoFactor(ctx)
+	if !ok {
+		return
+	}
+
+	valid, err := app.verifySecondFactor(twoFactor, request)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+	if !valid {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid authentication code"})
+		return
+	}
+
+	if err := app.models.TwoFactor.Disable(twoFactor.UserId); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// LoginTwoFactor completes a login with a second factor
+//
+//	@Summary		Completes a two-factor login
+//	@Description	Exchanges the challenge token returned by /auth/login, together with a code of the authenticator app or a recovery code, for an access token. Wrong codes count as failed logins.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		loginTwoFactorRequest	true	"Challenge and code"
+//	@Success		200		{object}	loginResponse
+//	@Failure		401		{object}	map[string]string
+//	@Failure		423		{object}	map[string]string
+//	@Failure		429		{object}	map[string]string
+//	@Router			/api/v1/auth/login/2fa [post]
+func (app *application) loginTwoFactor(ctx *gin.Context) {
+	var request loginTwoFactorRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, scopes, err := app.parseLoginChallengeToken(request.ChallengeToken)
+	if err != nil {
+		if errors.Is(err, errInvalidChallenge) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	now := time.Now().UTC()
+	if app.ipThrottled(ctx, ctx.ClientIP(), now) || app.accountThrottled(ctx, user, now) {
+		return
+	}
+
+	if user.IsDisabled() {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+		return
+	}
+
+	twoFactor, err := app.models.TwoFactor.Get(user.Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	// Two-factor authentication was disabled since the first step.
+	if !twoFactor.IsEnabled() {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": errInvalidChallenge.Error()})
+		return
+	}
+
+	valid, err := app.verifySecondFactor(twoFactor, request.twoFactorRequest)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	if !valid {
+		app.recordLoginAttempt(user.Email, ctx.ClientIP(), false)
+		if err := app.registerFailedLogin(user, now); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+			return
+		}
+
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authentication code"})
+		return
+	}
+
+	app.recordLoginAttempt(user.Email, ctx.ClientIP(), true)
+
+	app.issueLoginToken(ctx, user, scopes)
+}
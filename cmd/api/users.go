@@ -0,0 +1,263 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type updateProfileRequest struct {
+	Name *string `json:"name" binding:"omitempty,min=1,max=100"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required,min=8"`
+}
+
+type changeEmailRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// publicProfile is what anyone can see of a user: no email nor role.
+type publicProfile struct {
+	Id     int               `json:"id"`
+	Name   string            `json:"name"`
+	Events []*database.Event `json:"events"`
+}
+
+// GetMe returns the authenticated user
+//
+//	@Summary		Returns the authenticated user
+//	@Description	Returns the account of the authenticated user
+//	@Tags			users
+//	@Produce		json
+//	@Success		200	{object}	database.User
+//	@Router			/api/v1/users/me [get]
+//	@Security		BearerAuth
+func (app *application) getMe(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, app.GetUserFromContext(ctx))
+}
+
+// UpdateMe updates the profile of the authenticated user
+//
+//	@Summary		Updates the authenticated user
+//	@Description	Updates the profile of the authenticated user. Only the fields present are changed. The email and password have their own endpoints.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			profile	body		updateProfileRequest	true	"Profile"
+//	@Success		200		{object}	database.User
+//	@Router			/api/v1/users/me [patch]
+//	@Security		BearerAuth
+func (app *application) updateMe(ctx *gin.Context) {
+	var request updateProfileRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := app.GetUserFromContext(ctx)
+
+	if request.Name != nil {
+		name := strings.TrimSpace(*request.Name)
+		if name == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Name can't be blank"})
+			return
+		}
+
+		if err := app.models.Users.SetName(user.Id, name); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+			return
+		}
+		user.Name = name
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
+
+// checkCurrentPassword makes sure the user knows their password before a
+// sensitive change, with the same throttling as logins so that a stolen token
+// can't be used to guess it. It writes the error response itself and returns
+// false when the handler should stop.
+func (app *application) checkCurrentPassword(ctx *gin.Context, user *database.User, password string) bool {
+	now := time.Now().UTC()
+
+	if app.accountThrottled(ctx, user, now) {
+		return false
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		if err := app.registerFailedLogin(user, now); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+			return false
+		}
+
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Current password is incorrect"})
+		return false
+	}
+
+	if user.FailedLogins > 0 {
+		if err := app.models.Users.ResetLoginFailures(user.Id); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+			return false
+		}
+	}
+
+	return true
+}
+
+// ChangePassword changes the password of the authenticated user
+//
+//	@Summary		Changes the password
+//	@Description	Replaces the password of the authenticated user, who must confirm the current one. Every existing session is signed out, and a new token with the scopes of the current one is returned.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		changePasswordRequest	true	"Current and new password"
+//	@Success		200		{object}	loginResponse
+//	@Failure		403		{object}	map[string]string
+//	@Failure		423		{object}	map[string]string
+//	@Router			/api/v1/users/me/password [post]
+//	@Security		BearerAuth
+func (app *application) changePassword(ctx *gin.Context) {
+	var request changePasswordRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := app.GetUserFromContext(ctx)
+
+	if !app.checkCurrentPassword(ctx, user, request.CurrentPassword) {
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	if err := app.models.Users.SetPassword(user.Id, string(hashedPassword)); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not change password"})
+		return
+	}
+
+	// SetPassword revoked the current token along with the others, so the
+	// caller gets a new one.
+	user, err = app.models.Users.Get(user.Id)
+	if err != nil || user == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+		return
+	}
+
+	scopes := app.GetScopesFromContext(ctx)
+
+	tokenString, err := app.createAccessToken(user, scopes)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, loginResponse{Token: tokenString, Scopes: scopes})
+}
+
+// ChangeEmail changes the email of the authenticated user
+//
+//	@Summary		Changes the email address
+//	@Description	Replaces the email of the authenticated user, who must confirm their password. The new address has to be verified again with the link sent to it, and the previous address is told about the change.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		changeEmailRequest	true	"New email and password"
+//	@Success		200		{object}	database.User
+//	@Failure		403		{object}	map[string]string
+//	@Failure		409		{object}	map[string]string
+//	@Router			/api/v1/users/me/email [post]
+//	@Security		BearerAuth
+func (app *application) changeEmail(ctx *gin.Context) {
+	var request changeEmailRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request.Email = database.NormalizeEmail(request.Email)
+	user := app.GetUserFromContext(ctx)
+
+	if request.Email == user.Email {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "This is already your email"})
+		return
+	}
+
+	if !app.checkCurrentPassword(ctx, user, request.Password) {
+		return
+	}
+
+	if err := app.models.Users.SetEmail(user.Id, request.Email); err != nil {
+		if errors.Is(err, database.ErrDuplicateEmail) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Email is already registered"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not change email"})
+		return
+	}
+
+	previous := *user
+	user.Email = request.Email
+	user.EmailVerified = false
+
+	app.notify(&previous, "email_changed", map[string]any{"NewEmail": user.Email})
+	app.sendVerificationEmail(user)
+
+	ctx.JSON(http.StatusOK, user)
+}
+
+// GetUserProfile returns the public profile of a user
+//
+//	@Summary		Returns the public profile of a user
+//	@Description	Returns the name of a user and the events they organize
+//	@Tags			users
+//	@Produce		json
+//	@Param			id	path		int	true	"User ID"
+//	@Success		200	{object}	publicProfile
+//	@Failure		404	{object}	map[string]string
+//	@Router			/api/v1/users/{id} [get]
+func (app *application) getUserProfile(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	user, err := app.models.Users.Get(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retreive user"})
+		return
+	}
+
+	if user == nil || user.IsDisabled() {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	events, err := app.models.Events.GetByOwnerIds([]int{user.Id})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retreive events"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, publicProfile{Id: user.Id, Name: user.Name, Events: events})
+}
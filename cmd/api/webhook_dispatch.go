@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+	"github.com/gumeeee/rest-api-in-gin/internal/webhooks"
+)
+
+const (
+	webhookMaxAttempts  = 8
+	webhookRetryBackoff = 30 * time.Second
+	webhookPollInterval = 5 * time.Second
+	webhookBatchSize    = 50
+)
+
+// queueWebhookDeliveries is the outbox handler queuing a delivery for every
+// webhook of the event's owner subscribed to the message topic. Deliveries are
+// sent by runWebhookDeliveries.
+func (app *application) queueWebhookDeliveries(ctx context.Context, msg *database.OutboxMessage) error {
+	ownerId, err := app.eventOwnerOf(msg)
+	if err != nil {
+		return err
+	}
+	if ownerId == 0 {
+		return nil
+	}
+
+	hooks, err := app.models.Webhooks.GetByUser(ownerId)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(webhooks.Payload{
+		Type:      msg.Topic,
+		CreatedAt: msg.CreatedAt,
+		Data:      json.RawMessage(msg.Payload),
+	})
+	if err != nil {
+		return err
+	}
+
+	queued := false
+	for _, hook := range hooks {
+		if !hook.Subscribes(msg.Topic) {
+			continue
+		}
+
+		delivery := database.WebhookDelivery{
+			WebhookId: hook.Id,
+			EventType: msg.Topic,
+			Payload:   string(body),
+		}
+
+		if err := app.models.WebhookDeliveries.Insert(&delivery); err != nil {
+			return err
+		}
+
+		queued = true
+	}
+
+	if queued {
+		app.wakeWebhookWorker()
+	}
+
+	return nil
+}
+
+// eventOwnerOf returns the owner of the event an outbox message is about, or
+// zero if the event no longer exists.
+func (app *application) eventOwnerOf(msg *database.OutboxMessage) (int, error) {
+	var ref struct {
+		OwnerId int `json:"ownerId"`
+		EventId int `json:"eventId"`
+	}
+
+	if err := json.Unmarshal([]byte(msg.Payload), &ref); err != nil {
+		return 0, err
+	}
+
+	if ref.OwnerId != 0 {
+		return ref.OwnerId, nil
+	}
+
+	event, err := app.models.Events.Get(ref.EventId)
+	if err != nil || event == nil {
+		return 0, err
+	}
+
+	return event.OwnerId, nil
+}
+
+func (app *application) wakeWebhookWorker() {
+	select {
+	case app.webhookWake <- struct{}{}:
+	default:
+	}
+}
+
+// runWebhookDeliveries sends due webhook deliveries until ctx is cancelled.
+func (app *application) runWebhookDeliveries(ctx context.Context) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		app.deliverDueWebhooks(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-app.webhookWake:
+		}
+	}
+}
+
+func (app *application) deliverDueWebhooks(ctx context.Context, now time.Time) {
+	deliveries, err := app.models.WebhookDeliveries.GetDue(now, webhookBatchSize)
+	if err != nil {
+		log.Printf("Failed to retreive due webhook deliveries: %v", err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+
+		app.attemptDelivery(ctx, delivery)
+	}
+}
+
+// attemptDelivery sends a delivery once and schedules the next attempt with
+// exponential backoff if it fails.
+func (app *application) attemptDelivery(ctx context.Context, delivery *database.WebhookDelivery) {
+	hook, err := app.models.Webhooks.Get(delivery.WebhookId)
+	if err != nil {
+		log.Printf("Failed to retreive webhook %d: %v", delivery.WebhookId, err)
+		return
+	}
+
+	delivery.Attempts++
+	delivery.ResponseStatus = nil
+	delivery.LastError = nil
+	delivery.NextAttemptAt = nil
+
+	if hook == nil {
+		message := "webhook no longer exists"
+		delivery.Status = database.DeliveryFailed
+		delivery.LastError = &message
+	} else {
+		status, err := app.webhookClient.Deliver(ctx, webhooks.Request{
+			URL:        hook.URL,
+			Secret:     hook.Secret,
+			DeliveryId: delivery.Id,
+			EventType:  delivery.EventType,
+			Body:       []byte(delivery.Payload),
+		})
+
+		now := time.Now().UTC()
+		if status != 0 {
+			delivery.ResponseStatus = &status
+		}
+
+		switch {
+		case err == nil:
+			delivery.Status = database.DeliverySucceeded
+			delivery.DeliveredAt = &now
+		case delivery.Attempts >= webhookMaxAttempts:
+			message := err.Error()
+			delivery.Status = database.DeliveryFailed
+			delivery.LastError = &message
+		default:
+			message := err.Error()
+			next := now.Add(webhookRetryBackoff << (delivery.Attempts - 1))
+			delivery.Status = database.DeliveryPending
+			delivery.LastError = &message
+			delivery.NextAttemptAt = &next
+		}
+	}
+
+	if err := app.models.WebhookDeliveries.RecordAttempt(delivery); err != nil {
+		log.Printf("Failed to record attempt of webhook delivery %d: %v", delivery.Id, err)
+	}
+}
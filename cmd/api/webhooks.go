@@ -0,0 +1,218 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+	"github.com/gumeeee/rest-api-in-gin/internal/webhooks"
+)
+
+type webhookRequest struct {
+	URL        string   `json:"url" binding:"required,url"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16"`
+	EventTypes []string `json:"eventTypes" binding:"required,min=1,dive,oneof=event.created event.updated event.deleted attendee.added attendee.removed"`
+}
+
+// webhookResponse is only returned when a webhook is created, the single time
+// its secret is shown.
+type webhookResponse struct {
+	*database.Webhook
+	Secret string `json:"secret"`
+}
+
+// CreateWebhook subscribes a URL to changes on the user's events
+//
+//	@Summary		Creates a webhook
+//	@Description	Subscribes a URL to changes on the events owned by the user. Payloads are signed with HMAC-SHA256 in the X-Webhook-Signature header; the secret is generated when omitted and only returned here. Only http and https URLs are accepted, and deliveries to private, loopback and link-local addresses are refused.
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			webhook	body		webhookRequest	true	"Webhook"
+//	@Success		201		{object}	webhookResponse
+//	@Router			/api/v1/webhooks [post]
+//	@Security		BearerAuth
+func (app *application) createWebhook(ctx *gin.Context) {
+	var request webhookRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := webhooks.CheckURL(request.URL, app.webhookAllowPrivate); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook URL: " + err.Error()})
+		return
+	}
+
+	if request.Secret == "" {
+		secret, _, err := generateOneTimeToken()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Something went wrong"})
+			return
+		}
+
+		request.Secret = secret
+	}
+
+	user := app.GetUserFromContext(ctx)
+	webhook := database.Webhook{
+		UserId:     user.Id,
+		URL:        request.URL,
+		Secret:     request.Secret,
+		EventTypes: request.EventTypes,
+	}
+
+	if err := app.models.Webhooks.Insert(&webhook); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, webhookResponse{Webhook: &webhook, Secret: webhook.Secret})
+}
+
+// GetWebhooks returns the webhooks of the user
+//
+//	@Summary		Returns the user's webhooks
+//	@Description	Returns the webhooks of the authenticated user
+//	@Tags			webhooks
+//	@Produce		json
+//	@Success		200	{object}	[]database.Webhook
+//	@Router			/api/v1/webhooks [get]
+//	@Security		BearerAuth
+func (app *application) getWebhooks(ctx *gin.Context) {
+	user := app.GetUserFromContext(ctx)
+
+	webhooks, err := app.models.Webhooks.GetByUser(user.Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retreive webhooks"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, webhooks)
+}
+
+// DeleteWebhook deletes a webhook
+//
+//	@Summary		Deletes a webhook
+//	@Description	Deletes a webhook and its delivery log
+//	@Tags			webhooks
+//	@Produce		json
+//	@Param			id	path	int	true	"Webhook ID"
+//	@Success		204
+//	@Router			/api/v1/webhooks/{id} [delete]
+//	@Security		BearerAuth
+func (app *application) deleteWebhook(ctx *gin.Context) {
+	webhook, ok := app.webhookFromPath(ctx)
+	if !ok {
+		return
+	}
+
+	if err := app.models.Webhooks.Delete(webhook.Id); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// GetWebhookDeliveries returns the delivery log of a webhook
+//
+//	@Summary		Returns the deliveries of a webhook
+//	@Description	Returns every delivery of a webhook with its status, most recent first
+//	@Tags			webhooks
+//	@Produce		json
+//	@Param			id	path		int	true	"Webhook ID"
+//	@Success		200	{object}	[]database.WebhookDelivery
+//	@Router			/api/v1/webhooks/{id}/deliveries [get]
+//	@Security		BearerAuth
+func (app *application) getWebhookDeliveries(ctx *gin.Context) {
+	webhook, ok := app.webhookFromPath(ctx)
+	if !ok {
+		return
+	}
+
+	deliveries, err := app.models.WebhookDeliveries.GetByWebhook(webhook.Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retreive deliveries"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, deliveries)
+}
+
+// RedeliverWebhook sends a delivery again
+//
+//	@Summary		Redelivers a webhook payload
+//	@Description	Queues a past delivery to be sent again with the same payload
+//	@Tags			webhooks
+//	@Produce		json
+//	@Param			id			path		int	true	"Webhook ID"
+//	@Param			deliveryId	path		int	true	"Delivery ID"
+//	@Success		202			{object}	database.WebhookDelivery
+//	@Router			/api/v1/webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+//	@Security		BearerAuth
+func (app *application) redeliverWebhook(ctx *gin.Context) {
+	webhook, ok := app.webhookFromPath(ctx)
+	if !ok {
+		return
+	}
+
+	deliveryId, err := strconv.Atoi(ctx.Param("deliveryId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	delivery, err := app.models.WebhookDeliveries.Get(deliveryId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retreive delivery"})
+		return
+	}
+	if delivery == nil || delivery.WebhookId != webhook.Id {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+
+	if err := app.models.WebhookDeliveries.Redeliver(delivery.Id, time.Now()); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to redeliver webhook"})
+		return
+	}
+
+	app.wakeWebhookWorker()
+
+	delivery, err = app.models.WebhookDeliveries.Get(delivery.Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retreive delivery"})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, delivery)
+}
+
+// webhookFromPath loads the webhook named by the :id path parameter and makes
+// sure it belongs to the authenticated user. It writes the error response
+// itself and returns false when the handler should stop.
+func (app *application) webhookFromPath(ctx *gin.Context) (*database.Webhook, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return nil, false
+	}
+
+	webhook, err := app.models.Webhooks.Get(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retreive webhook"})
+		return nil, false
+	}
+
+	user := app.GetUserFromContext(ctx)
+	if webhook == nil || webhook.UserId != user.Id {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return nil, false
+	}
+
+	return webhook, true
+}
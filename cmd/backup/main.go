@@ -0,0 +1,232 @@
+// Command backup takes hot backups of the SQLite database, checks them and
+// restores them.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gumeeee/rest-api-in-gin/internal/backup"
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const usage = `Usage: backup [flags] <command> [arguments]
+
+Commands:
+  create [FILE]     back the database up to FILE, or to a timestamped file in -dir
+  list              list the backups in -dir
+  check FILE...     run the integrity check and show the schema version
+  prune -keep N     delete all but the N most recent backups in -dir
+  restore [-force] FILE
+                    replace the database with FILE; stop the API first
+
+Backups can be taken while the API is running.
+
+Flags:
+`
+
+type backups struct {
+	dbPath        string
+	dir           string
+	migrationsDir string
+	out           io.Writer
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "backup:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	b := &backups{out: out}
+
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&b.dbPath, "db", "./data.db", "path of the SQLite database")
+	flags.StringVar(&b.dir, "dir", "./backups", "directory of the timestamped backups")
+	flags.StringVar(&b.migrationsDir, "migrations", "cmd/migrate/migrations", "directory of the migrations, giving the expected schema version")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("missing command")
+	}
+
+	ctx := context.Background()
+	command, rest := flags.Arg(0), flags.Args()[1:]
+
+	switch command {
+	case "create":
+		return b.create(ctx, rest)
+	case "list":
+		return b.list(ctx)
+	case "check":
+		return b.check(ctx, rest)
+	case "prune":
+		return b.prune(rest)
+	case "restore":
+		return b.restore(ctx, rest)
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+func (b *backups) create(ctx context.Context, args []string) error {
+	if len(args) > 1 {
+		return errors.New("create: expected at most one FILE argument")
+	}
+
+	if _, err := os.Stat(b.dbPath); err != nil {
+		return err
+	}
+
+	db, err := database.Open(b.dbPath, database.Options{})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var info *backup.Info
+	if len(args) == 1 {
+		info, err = backup.Create(ctx, db, args[0])
+	} else {
+		info, err = backup.CreateInDir(ctx, db, b.dir, time.Now())
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(b.out, "Backed up %s to %s (%d bytes, schema version %d)\n", b.dbPath, info.Path, info.Size, info.Version)
+
+	return nil
+}
+
+func (b *backups) list(ctx context.Context) error {
+	paths, err := backup.List(b.dir)
+	if err != nil {
+		return err
+	}
+
+	return b.printInfo(ctx, paths)
+}
+
+func (b *backups) check(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("check: missing FILE argument")
+	}
+
+	return b.printInfo(ctx, args)
+}
+
+// printInfo checks every file and fails if any of them is damaged.
+func (b *backups) printInfo(ctx context.Context, paths []string) error {
+	w := tabwriter.NewWriter(b.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tSIZE\tVERSION\tSTATUS")
+
+	failed := 0
+	for _, path := range paths {
+		info, err := backup.Check(ctx, path)
+		if err != nil {
+			failed++
+			fmt.Fprintf(w, "%s\t\t\t%v\n", path, err)
+			continue
+		}
+
+		status := "ok"
+		if info.Dirty {
+			status = "ok, dirty migration"
+		}
+
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", path, info.Size, info.Version, status)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed the check", failed, len(paths))
+	}
+
+	return nil
+}
+
+func (b *backups) prune(args []string) error {
+	flags := flag.NewFlagSet("prune", flag.ContinueOnError)
+	keep := flags.Int("keep", 7, "number of backups to keep")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *keep < 1 {
+		return errors.New("prune: -keep must be at least 1")
+	}
+
+	removed, err := backup.Prune(b.dir, *keep)
+	for _, path := range removed {
+		fmt.Fprintf(b.out, "Deleted %s\n", path)
+	}
+
+	return err
+}
+
+func (b *backups) restore(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	force := flags.Bool("force", false, "restore even if the schema version differs from the latest migration")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("restore: expected a single FILE argument")
+	}
+
+	src := flags.Arg(0)
+
+	want, err := backup.LatestMigration(b.migrationsDir)
+	if err != nil {
+		return fmt.Errorf("restore: reading the expected schema version: %w", err)
+	}
+
+	if *force {
+		info, err := backup.Check(ctx, src)
+		if err != nil {
+			return err
+		}
+
+		want = info.Version
+	}
+
+	previous, err := backup.Restore(ctx, src, b.dbPath, want)
+	if err != nil {
+		if errors.Is(err, backup.ErrSchemaMismatch) {
+			return fmt.Errorf("%w (run the migrations on a copy, or pass -force)", err)
+		}
+
+		return err
+	}
+
+	fmt.Fprintf(b.out, "Restored %s from %s\n", b.dbPath, src)
+	if previous != "" {
+		fmt.Fprintf(b.out, "The previous database was kept as %s\n", previous)
+	}
+
+	return nil
+}
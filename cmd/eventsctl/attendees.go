@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gumeeee/rest-api-in-gin/pkg/client"
+)
+
+func (c *cli) attendees(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("attendees: missing subcommand (list, events, add or remove)")
+	}
+
+	switch args[0] {
+	case "list":
+		ids, err := parseIds(args[1:], "EVENT_ID")
+		if err != nil {
+			return err
+		}
+
+		users, err := c.client.ListAttendees(ctx, ids[0])
+		if err != nil {
+			return err
+		}
+
+		return usersTable(users).render(c.out, c.format)
+	case "events":
+		ids, err := parseIds(args[1:], "USER_ID")
+		if err != nil {
+			return err
+		}
+
+		events, err := c.client.ListEventsByAttendee(ctx, ids[0])
+		if err != nil {
+			return err
+		}
+
+		return eventsTable(events).render(c.out, c.format)
+	case "add":
+		ids, err := parseIds(args[1:], "EVENT_ID", "USER_ID")
+		if err != nil {
+			return err
+		}
+
+		attendee, err := c.client.AddAttendee(ctx, ids[0], ids[1])
+		if err != nil {
+			return authError(err)
+		}
+
+		t := &table{
+			headers: []string{"id", "event", "user"},
+			rows:    [][]string{{strconv.Itoa(attendee.Id), strconv.Itoa(attendee.EventId), strconv.Itoa(attendee.UserId)}},
+			value:   attendee,
+		}
+
+		return t.render(c.out, c.format)
+	case "remove":
+		ids, err := parseIds(args[1:], "EVENT_ID", "USER_ID")
+		if err != nil {
+			return err
+		}
+
+		return authError(c.client.RemoveAttendee(ctx, ids[0], ids[1]))
+	default:
+		return fmt.Errorf("attendees: unknown subcommand %q", args[0])
+	}
+}
+
+func usersTable(users []*client.User) *table {
+	if users == nil {
+		users = []*client.User{}
+	}
+
+	t := &table{
+		headers: []string{"id", "name", "email"},
+		value:   users,
+	}
+
+	for _, user := range users {
+		t.rows = append(t.rows, []string{strconv.Itoa(user.Id), user.Name, user.Email})
+	}
+
+	return t
+}
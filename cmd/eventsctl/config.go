@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// config is persisted between runs so users only log in once.
+type config struct {
+	URL   string `json:"url"`
+	Email string `json:"email,omitempty"`
+	Token string `json:"token,omitempty"`
+}
+
+// defaultConfigPath returns $EVENTSCTL_CONFIG or eventsctl/config.json in the
+// user's configuration directory.
+func defaultConfigPath() string {
+	if path := os.Getenv("EVENTSCTL_CONFIG"); path != "" {
+		return path
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ".eventsctl.json"
+	}
+
+	return filepath.Join(dir, "eventsctl", "config.json")
+}
+
+// loadConfig reads the configuration file. A missing file is not an error.
+func loadConfig(path string) (*config, error) {
+	cfg := &config{}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return cfg, nil
+		}
+
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// save writes the configuration file, readable only by the user since it
+// holds an access token.
+func (cfg *config) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gumeeee/rest-api-in-gin/pkg/client"
+)
+
+var dateLayouts = []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02"}
+
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+func eventsTable(events []*client.Event) *table {
+	if events == nil {
+		events = []*client.Event{}
+	}
+
+	t := &table{
+		headers: []string{"id", "owner", "name", "date", "location", "description"},
+		value:   events,
+	}
+
+	for _, event := range events {
+		t.rows = append(t.rows, []string{
+			strconv.Itoa(event.Id),
+			strconv.Itoa(event.OwnerId),
+			event.Name,
+			event.Date.Format(time.RFC3339),
+			event.Location,
+			event.Description,
+		})
+	}
+
+	return t
+}
+
+func (c *cli) events(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("events: missing subcommand (list, get, create, update or delete)")
+	}
+
+	switch args[0] {
+	case "list":
+		events, err := c.client.ListEvents(ctx)
+		if err != nil {
+			return err
+		}
+
+		return eventsTable(events).render(c.out, c.format)
+	case "get":
+		ids, err := parseIds(args[1:], "ID")
+		if err != nil {
+			return err
+		}
+
+		event, err := c.client.GetEvent(ctx, ids[0])
+		if err != nil {
+			return err
+		}
+
+		return c.renderEvent(event)
+	case "create":
+		return c.createEvent(ctx, args[1:])
+	case "update":
+		return c.updateEvent(ctx, args[1:])
+	case "delete":
+		ids, err := parseIds(args[1:], "ID")
+		if err != nil {
+			return err
+		}
+
+		return authError(c.client.DeleteEvent(ctx, ids[0]))
+	default:
+		return fmt.Errorf("events: unknown subcommand %q", args[0])
+	}
+}
+
+// renderEvent prints a single event, as an object rather than a list in JSON.
+func (c *cli) renderEvent(event *client.Event) error {
+	t := eventsTable([]*client.Event{event})
+	t.value = event
+
+	return t.render(c.out, c.format)
+}
+
+// eventFlags registers the flags setting the fields of an event.
+func eventFlags(flags *flag.FlagSet, input *client.EventInput, date *string) {
+	flags.StringVar(&input.Name, "name", input.Name, "name of the event")
+	flags.StringVar(&input.Description, "description", input.Description, "description of the event")
+	flags.StringVar(&input.Location, "location", input.Location, "where the event takes place")
+	flags.StringVar(date, "date", *date, "when the event takes place")
+}
+
+func (c *cli) createEvent(ctx context.Context, args []string) error {
+	var input client.EventInput
+	var date string
+
+	flags := flag.NewFlagSet("events create", flag.ContinueOnError)
+	eventFlags(flags, &input, &date)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if date == "" {
+		return errors.New("events create: -date is required")
+	}
+
+	var err error
+	if input.Date, err = parseDate(date); err != nil {
+		return err
+	}
+
+	event, err := c.client.CreateEvent(ctx, input)
+	if err != nil {
+		return authError(err)
+	}
+
+	return c.renderEvent(event)
+}
+
+// updateEvent only changes the fields given as flags: the API replaces whole
+// events, so the others are copied from the current version.
+func (c *cli) updateEvent(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("events update: missing event ID")
+	}
+
+	ids, err := parseIds(args[:1], "ID")
+	if err != nil {
+		return err
+	}
+
+	event, err := c.client.GetEvent(ctx, ids[0])
+	if err != nil {
+		return err
+	}
+
+	input := client.EventInput{
+		Name:        event.Name,
+		Description: event.Description,
+		Date:        event.Date,
+		Location:    event.Location,
+	}
+	date := event.Date.Format(time.RFC3339)
+
+	flags := flag.NewFlagSet("events update", flag.ContinueOnError)
+	eventFlags(flags, &input, &date)
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if input.Date, err = parseDate(date); err != nil {
+		return err
+	}
+
+	event, err = c.client.UpdateEvent(ctx, ids[0], input)
+	if err != nil {
+		return authError(err)
+	}
+
+	return c.renderEvent(event)
+}
@@ -0,0 +1,202 @@
+// Command eventsctl is a command-line client for the events API.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/gumeeee/rest-api-in-gin/pkg/client"
+)
+
+const usage = `Usage: eventsctl [flags] <command> [arguments]
+
+Commands:
+  login -email EMAIL [-password PASSWORD] [-code CODE]
+                                            log in and store the token
+  logout                                    forget the stored token
+  whoami                                    show the logged in account
+  events list
+  events get ID
+  events create -name NAME -description TEXT -date DATE -location PLACE
+  events update ID [-name NAME] [-description TEXT] [-date DATE] [-location PLACE]
+  events delete ID
+  attendees list EVENT_ID
+  attendees events USER_ID                  events a user attends
+  attendees add EVENT_ID USER_ID
+  attendees remove EVENT_ID USER_ID
+
+Dates are RFC 3339 timestamps, "2006-01-02 15:04" or "2006-01-02" in UTC.
+
+Flags:
+`
+
+type cli struct {
+	cfg        *config
+	configPath string
+	client     *client.Client
+	format     string
+	out        io.Writer
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "eventsctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("eventsctl", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+
+	configPath := flags.String("config", defaultConfigPath(), "path of the configuration file")
+	url := flags.String("url", os.Getenv("EVENTSCTL_URL"), "base URL of the API (default from the configuration file, then http://localhost:8080)")
+	format := flags.String("o", formatTable, "output format: table, json or csv")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if !validFormat(*format) {
+		return fmt.Errorf("unknown output format %q", *format)
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("missing command")
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("reading configuration: %w", err)
+	}
+	if *url != "" {
+		cfg.URL = *url
+	}
+	if cfg.URL == "" {
+		cfg.URL = "http://localhost:8080"
+	}
+
+	c := &cli{
+		cfg:        cfg,
+		configPath: *configPath,
+		client:     client.New(cfg.URL, client.WithToken(cfg.Token)),
+		format:     *format,
+		out:        out,
+	}
+
+	ctx := context.Background()
+	command, rest := flags.Arg(0), flags.Args()[1:]
+
+	switch command {
+	case "login":
+		return c.login(ctx, rest)
+	case "logout":
+		return c.logout()
+	case "whoami":
+		return c.whoami(ctx)
+	case "events":
+		return c.events(ctx, rest)
+	case "attendees":
+		return c.attendees(ctx, rest)
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+func (c *cli) login(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("login", flag.ContinueOnError)
+	email := flags.String("email", c.cfg.Email, "email of the account")
+	password := flags.String("password", os.Getenv("EVENTSCTL_PASSWORD"), "password of the account (default $EVENTSCTL_PASSWORD, else read from stdin)")
+	code := flags.String("code", "", "two-factor authentication or recovery code (read from stdin when needed)")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		return errors.New("login: -email is required")
+	}
+
+	if *password == "" {
+		fmt.Fprint(os.Stderr, "Password: ")
+		if _, err := fmt.Fscanln(os.Stdin, password); err != nil {
+			return fmt.Errorf("login: reading password: %w", err)
+		}
+	}
+
+	token, err := c.client.Login(ctx, *email, *password)
+
+	var twoFactor *client.TwoFactorError
+	if errors.As(err, &twoFactor) {
+		if *code == "" {
+			fmt.Fprint(os.Stderr, "Authentication code: ")
+			if _, err := fmt.Fscanln(os.Stdin, code); err != nil {
+				return fmt.Errorf("login: reading code: %w", err)
+			}
+		}
+
+		token, err = c.client.LoginTwoFactor(ctx, twoFactor.ChallengeToken, *code)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.cfg.Email = *email
+	c.cfg.Token = token
+	if err := c.cfg.save(c.configPath); err != nil {
+		return fmt.Errorf("saving configuration: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Logged in as %s\n", *email)
+
+	return nil
+}
+
+func (c *cli) logout() error {
+	c.cfg.Token = ""
+
+	return c.cfg.save(c.configPath)
+}
+
+func (c *cli) whoami(ctx context.Context) error {
+	user, err := c.client.Me(ctx)
+	if err != nil {
+		return authError(err)
+	}
+
+	return usersTable([]*client.User{user}).render(c.out, c.format)
+}
+
+// authError explains what to do when the stored token is missing or no longer
+// accepted.
+func authError(err error) error {
+	if errors.Is(err, client.ErrUnauthorized) || errors.Is(err, client.ErrNoCredentials) {
+		return fmt.Errorf("%w (run eventsctl login)", err)
+	}
+
+	return err
+}
+
+func parseIds(args []string, names ...string) ([]int, error) {
+	if len(args) != len(names) {
+		return nil, fmt.Errorf("expected arguments: %v", names)
+	}
+
+	ids := make([]int, len(args))
+	for i, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q", names[i], arg)
+		}
+		ids[i] = id
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+func validFormat(format string) bool {
+	return format == formatTable || format == formatJSON || format == formatCSV
+}
+
+// table is the tabular view of a command's result. value is what gets
+// encoded in JSON output.
+type table struct {
+	headers []string
+	rows    [][]string
+	value   any
+}
+
+func (t *table) render(w io.Writer, format string) error {
+	switch format {
+	case formatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(t.value)
+	case formatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(t.headers); err != nil {
+			return err
+		}
+		if err := writer.WriteAll(t.rows); err != nil {
+			return err
+		}
+		return writer.Error()
+	default:
+		writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, strings.ToUpper(strings.Join(t.headers, "\t")))
+		for _, row := range t.rows {
+			fmt.Fprintln(writer, strings.Join(row, "\t"))
+		}
+		return writer.Flush()
+	}
+}
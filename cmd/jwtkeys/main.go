@@ -0,0 +1,172 @@
+// Command jwtkeys creates and rotates the keys signing access tokens, stored
+// as PEM files in the directory given to the API by JWT_KEYS_DIR.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gumeeee/rest-api-in-gin/internal/jwtkeys"
+)
+
+const usage = `Usage: jwtkeys [flags] <command> [arguments]
+
+Commands:
+  list                          list the keys and the one signing new tokens
+  generate [-alg ALG] [-id ID]  create a private key, RS256 or EdDSA
+  retire ID                     keep only the public part of a key
+
+Rotating keys:
+  1. jwtkeys generate, then restart the API: the new key, having the
+     greatest id, signs new tokens while the others still verify theirs.
+  2. Once the tokens of a previous key have expired (24 hours), retire it
+     so it can no longer sign, then delete its file.
+
+Flags:
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "jwtkeys:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("jwtkeys", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+
+	dir := flags.String("dir", os.Getenv("JWT_KEYS_DIR"), "directory of the keys (default $JWT_KEYS_DIR)")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("missing command")
+	}
+	if *dir == "" {
+		return errors.New("-dir or JWT_KEYS_DIR is required")
+	}
+
+	command, rest := flags.Arg(0), flags.Args()[1:]
+
+	switch command {
+	case "list":
+		return list(out, *dir)
+	case "generate":
+		return generate(out, *dir, rest)
+	case "retire":
+		return retire(out, *dir, rest)
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+func list(out io.Writer, dir string) error {
+	set, err := jwtkeys.LoadDir(dir, "")
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tALG\tSTATUS")
+
+	for _, key := range set.Keys() {
+		status := "verify only"
+		switch {
+		case key == set.Active():
+			status = "active"
+		case key.CanSign():
+			status = "can sign"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\n", key.Id, key.Method.Alg(), status)
+	}
+
+	return w.Flush()
+}
+
+func generate(out io.Writer, dir string, args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	alg := flags.String("alg", jwtkeys.EdDSA, "signing algorithm, RS256 or EdDSA")
+	id := flags.String("id", time.Now().UTC().Format("20060102T150405Z"), "id of the key")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, *id+".pem")
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+
+	private, err := jwtkeys.Generate(*alg)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, private, 0o600); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Created key %s in %s\n", *id, path)
+
+	return nil
+}
+
+// retire replaces a private key by its public part, so the key keeps
+// verifying the tokens it signed but can't sign new ones.
+func retire(out io.Writer, dir string, args []string) error {
+	if len(args) != 1 {
+		return errors.New("retire: expected a single ID argument")
+	}
+
+	id := args[0]
+	path := filepath.Join(dir, id+".pem")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	key, err := jwtkeys.ParseKey(id, data)
+	if err != nil {
+		return err
+	}
+	if !key.CanSign() {
+		return fmt.Errorf("key %s is already retired", id)
+	}
+
+	public, err := key.PublicPEM()
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, public, 0o644); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Retired key %s, it now only verifies tokens\n", id)
+
+	return nil
+}
@@ -2,22 +2,40 @@ package main
 
 import (
 	"database/sql"
+	"errors"
+	"flag"
 	"log"
 	"os"
 
 	"github.com/golang-migrate/migrate"
 	"github.com/golang-migrate/migrate/database/sqlite3"
 	"github.com/golang-migrate/migrate/source/file"
+	"github.com/gumeeee/rest-api-in-gin/internal/config"
 )
 
+type migrateConfig struct {
+	DBPath string `config:"DB_PATH" default:"./data.db" usage:"path of the SQLite database" required:"true"`
+}
+
 func main() {
-	if len(os.Args) < 2 {
+	var cfg migrateConfig
+
+	args, err := config.Load(&cfg, "migrate", os.Args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+
+		log.Fatal("Invalid configuration: ", err)
+	}
+
+	if len(args) < 1 {
 		log.Fatal("Please provide a migration direction: 'up' or 'down'")
 	}
 
-	direction := os.Args[1]
+	direction := args[0]
 
-	db, err := sql.Open("sqlite3", "./data.db")
+	db, err := sql.Open("sqlite3", cfg.DBPath)
 	if err != nil {
 		log.Fatal("Failed to connect to the database: ", err)
 	}
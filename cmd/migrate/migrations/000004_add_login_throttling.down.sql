@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS login_attempts;
+ALTER TABLE users DROP COLUMN locked_until;
+ALTER TABLE users DROP COLUMN last_failed_login_at;
+ALTER TABLE users DROP COLUMN failed_logins;
+ALTER TABLE users DROP COLUMN role;
\ No newline at end of file
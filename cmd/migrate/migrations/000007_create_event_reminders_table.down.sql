@@ -0,0 +1 @@
+DROP TABLE IF EXISTS event_reminders;
\ No newline at end of file
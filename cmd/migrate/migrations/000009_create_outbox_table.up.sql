@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    topic TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    completed_handlers TEXT NOT NULL DEFAULT '',
+    last_error TEXT,
+    next_attempt_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL,
+    processed_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox (processed_at, next_attempt_at);
\ No newline at end of file
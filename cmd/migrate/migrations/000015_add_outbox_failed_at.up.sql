@@ -0,0 +1 @@
+ALTER TABLE outbox ADD COLUMN failed_at DATETIME;
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS password_reset_requests (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email TEXT NOT NULL,
+    ip_address TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_reset_requests_ip_address ON password_reset_requests (ip_address, created_at);
+CREATE INDEX IF NOT EXISTS idx_password_reset_requests_email ON password_reset_requests (email, created_at);
\ No newline at end of file
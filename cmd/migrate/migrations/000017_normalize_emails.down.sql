@@ -0,0 +1 @@
+-- The original case of the emails isn't kept.
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- Fails when two accounts have emails differing only in case: one of them
+-- has to be changed or deleted first.
+UPDATE users SET email = lower(email);
+UPDATE login_attempts SET email = lower(email);
+UPDATE password_reset_requests SET email = lower(email);
\ No newline at end of file
@@ -0,0 +1,331 @@
+// Command mockoidc is a minimal OpenID Connect provider for trying out and
+// testing the OIDC login of the API locally. It signs in whoever asks: the
+// login page takes any email, and authorization requests carrying a
+// login_hint are approved right away, which lets scripts go through the flow
+// without a browser.
+//
+//	go run ./cmd/mockoidc -addr :9998 -client-id events
+//	OIDC_ISSUER=http://localhost:9998 OIDC_CLIENT_ID=events go run ./cmd/api
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/gumeeee/rest-api-in-gin/internal/jwtkeys"
+	"github.com/gumeeee/rest-api-in-gin/internal/oidc"
+)
+
+const usage = `usage: mockoidc [flags]
+
+Serves a mock OpenID Connect provider. Flags:
+`
+
+const codeTTL = time.Minute
+
+// grant is what an authorization code stands for until the client redeems
+// it at the token endpoint.
+type grant struct {
+	clientId    string
+	redirectURI string
+	challenge   string
+	nonce       string
+	email       string
+	name        string
+	verified    bool
+	expiresAt   time.Time
+}
+
+type provider struct {
+	issuer       string
+	clientId     string
+	clientSecret string
+	keys         *jwtkeys.Set
+
+	mu     sync.Mutex
+	grants map[string]*grant
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "mockoidc:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("mockoidc", flag.ContinueOnError)
+	flags.SetOutput(out)
+	flags.Usage = func() {
+		fmt.Fprint(out, usage)
+		flags.PrintDefaults()
+	}
+
+	addr := flags.String("addr", "localhost:9998", "address to listen on")
+	issuer := flags.String("issuer", "", "issuer URL (default http://<addr>)")
+	clientId := flags.String("client-id", "events", "client id accepted")
+	clientSecret := flags.String("client-secret", "", "client secret required from the client, empty for a public client")
+	alg := flags.String("alg", jwtkeys.RS256, "algorithm signing the ID tokens, "+jwtkeys.RS256+" or "+jwtkeys.EdDSA)
+
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+
+		return err
+	}
+
+	if *issuer == "" {
+		*issuer = "http://" + *addr
+	}
+
+	pemKey, err := jwtkeys.Generate(*alg)
+	if err != nil {
+		return err
+	}
+
+	key, err := jwtkeys.ParseKey(time.Now().UTC().Format("20060102T150405Z"), pemKey)
+	if err != nil {
+		return err
+	}
+
+	keys, err := jwtkeys.NewSet(key)
+	if err != nil {
+		return err
+	}
+
+	p := &provider{
+		issuer:       strings.TrimSuffix(*issuer, "/"),
+		clientId:     *clientId,
+		clientSecret: *clientSecret,
+		keys:         keys,
+		grants:       map[string]*grant{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /jwks", p.jwks)
+	mux.HandleFunc("GET /authorize", p.authorize)
+	mux.HandleFunc("POST /authorize", p.authorize)
+	mux.HandleFunc("POST /token", p.token)
+
+	log.Printf("Mock OpenID Connect provider %s for client %q listening on %s", p.issuer, p.clientId, *addr)
+
+	return http.ListenAndServe(*addr, mux)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// tokenError writes an error response of the token endpoint (RFC 6749
+// section 5.2).
+func tokenError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, map[string]string{"error": code, "error_description": description})
+}
+
+func (p *provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                p.issuer,
+		"authorization_endpoint":                p.issuer + "/authorize",
+		"token_endpoint":                        p.issuer + "/token",
+		"jwks_uri":                              p.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{p.keys.Active().Method.Alg()},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"scopes_supported":                      []string{"openid", "email", "profile"},
+	})
+}
+
+func (p *provider) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, p.keys.JWKS())
+}
+
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<title>Mock OpenID Connect login</title>
+<h1>Mock OpenID Connect login</h1>
+<form method="post">
+{{range $name, $values := .}}{{range $values}}<input type="hidden" name="{{$name}}" value="{{.}}">
+{{end}}{{end}}<p><label>Email <input name="email" type="email" required></label></p>
+<p><label>Name <input name="name"></label></p>
+<p><label><input name="email_verified" type="checkbox" value="true" checked> Email verified</label></p>
+<p><button>Sign in</button></p>
+</form>
+`))
+
+// authorize shows the login page, then redirects back to the client with an
+// authorization code. Requests with a login_hint skip the page.
+func (p *provider) authorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Form.Get("client_id") != p.clientId {
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+		return
+	}
+
+	redirectURI, err := url.Parse(r.Form.Get("redirect_uri"))
+	if err != nil || !redirectURI.IsAbs() {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	redirect := func(params url.Values) {
+		params.Set("state", r.Form.Get("state"))
+		query := redirectURI.Query()
+		for name, values := range params {
+			query[name] = values
+		}
+		redirectURI.RawQuery = query.Encode()
+
+		http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+	}
+
+	if r.Form.Get("response_type") != "code" {
+		redirect(url.Values{"error": {"unsupported_response_type"}})
+		return
+	}
+
+	if r.Form.Get("code_challenge") == "" || r.Form.Get("code_challenge_method") != "S256" {
+		redirect(url.Values{"error": {"invalid_request"}, "error_description": {"PKCE with S256 is required"}})
+		return
+	}
+
+	email := r.Form.Get("login_hint")
+	verified := true
+	if r.Method == http.MethodPost {
+		email = r.PostForm.Get("email")
+		verified = r.PostForm.Get("email_verified") == "true"
+	}
+
+	if email == "" {
+		query := r.URL.Query()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		loginPage.Execute(w, query)
+		return
+	}
+
+	code, err := oidc.RandomString()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	p.mu.Lock()
+	p.grants[code] = &grant{
+		clientId:    p.clientId,
+		redirectURI: r.Form.Get("redirect_uri"),
+		challenge:   r.Form.Get("code_challenge"),
+		nonce:       r.Form.Get("nonce"),
+		email:       email,
+		name:        r.Form.Get("name"),
+		verified:    verified,
+		expiresAt:   time.Now().Add(codeTTL),
+	}
+	p.mu.Unlock()
+
+	redirect(url.Values{"code": {code}})
+}
+
+// token redeems an authorization code for an ID token, once.
+func (p *provider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	clientId, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientId, _ = url.QueryUnescape(clientId)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientId = r.PostForm.Get("client_id")
+	}
+
+	if clientId != p.clientId || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(p.clientSecret)) != 1 {
+		tokenError(w, http.StatusUnauthorized, "invalid_client", "unknown client or wrong secret")
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, http.StatusBadRequest, "unsupported_grant_type", "only authorization_code is supported")
+		return
+	}
+
+	p.mu.Lock()
+	g := p.grants[r.PostForm.Get("code")]
+	delete(p.grants, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	if g == nil || time.Now().After(g.expiresAt) || g.redirectURI != r.PostForm.Get("redirect_uri") {
+		tokenError(w, http.StatusBadRequest, "invalid_grant", "unknown or expired code")
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge {
+		tokenError(w, http.StatusBadRequest, "invalid_grant", "code_verifier doesn't match the code_challenge")
+		return
+	}
+
+	// The subject is derived from the email, so logging in again with the
+	// same email gives the same identity.
+	subject := sha256.Sum256([]byte(strings.ToLower(g.email)))
+	now := time.Now()
+
+	claims := jwt.MapClaims{
+		"iss":            p.issuer,
+		"sub":            hex.EncodeToString(subject[:8]),
+		"aud":            g.clientId,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"email":          g.email,
+		"email_verified": g.verified,
+	}
+	if g.nonce != "" {
+		claims["nonce"] = g.nonce
+	}
+	if g.name != "" {
+		claims["name"] = g.name
+	}
+
+	idToken, err := p.keys.Sign(claims)
+	if err != nil {
+		tokenError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	accessToken, err := oidc.RandomString()
+	if err != nil {
+		tokenError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
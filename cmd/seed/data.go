@@ -0,0 +1,35 @@
+package main
+
+var firstNames = []string{
+	"Ana", "Bruno", "Camila", "Daniel", "Eduarda", "Felipe", "Gabriela", "Gustavo", "Helena", "Igor",
+	"Juliana", "Lucas", "Larissa", "Marcos", "Mariana", "Nicolas", "Olivia", "Pedro", "Rafaela", "Rodrigo",
+	"Sofia", "Thiago", "Beatriz", "Vinicius", "Yasmin", "João", "Letícia", "Mateus", "Carolina", "André",
+}
+
+var lastNames = []string{
+	"Silva", "Santos", "Oliveira", "Souza", "Rodrigues", "Ferreira", "Alves", "Pereira", "Lima", "Gomes",
+	"Costa", "Ribeiro", "Martins", "Carvalho", "Almeida", "Lopes", "Soares", "Fernandes", "Vieira", "Barbosa",
+}
+
+var topics = []string{
+	"Go", "Kubernetes", "Rust", "Machine Learning", "Observability", "Security", "Frontend", "Databases",
+	"Cloud Native", "Open Source", "DevOps", "Product Design", "Data Engineering", "Mobile", "Photography",
+}
+
+// eventKinds pair a kind of event with the template of its description.
+var eventKinds = []struct {
+	name        string
+	description string
+}{
+	{"Meetup", "An evening of talks and networking about %s with the local community."},
+	{"Workshop", "A hands-on workshop: bring your laptop and learn %s by building something."},
+	{"Conference", "A full day of talks by practitioners sharing how they use %s in production."},
+	{"Hackathon", "Form a team and ship a project around %s in a single weekend."},
+	{"Study Group", "We go through a chapter together and discuss what we learned about %s."},
+	{"Lightning Talks", "Five-minute talks about %s. Anyone can sign up to speak."},
+}
+
+var locations = []string{
+	"São Paulo, SP", "Rio de Janeiro, RJ", "Belo Horizonte, MG", "Porto Alegre, RS", "Curitiba, PR",
+	"Recife, PE", "Salvador, BA", "Florianópolis, SC", "Brasília, DF", "Fortaleza, CE", "Online",
+}
@@ -0,0 +1,335 @@
+// Command seed fills the database with generated users, events and
+// attendees for demos and load testing. The same seed and base date always
+// produce the same data.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+	"golang.org/x/crypto/bcrypt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type options struct {
+	seed         uint64
+	base         time.Time
+	users        int
+	events       int
+	pastRatio    float64
+	series       int
+	occurrences  int
+	maxAttendees int
+	password     string
+	reset        bool
+}
+
+type seeder struct {
+	options
+	rand *rand.Rand
+	tx   *sql.Tx
+	ctx  context.Context
+}
+
+type stats struct {
+	users, events, attendees int
+}
+
+func main() {
+	var opts options
+	var base string
+
+	dbPath := flag.String("db", "./data.db", "path of the SQLite database")
+	flag.Uint64Var(&opts.seed, "seed", 1, "random seed; the same seed and base date produce the same data")
+	flag.StringVar(&base, "base", time.Now().UTC().Format(time.DateOnly), "date past and upcoming events are relative to (YYYY-MM-DD)")
+	flag.IntVar(&opts.users, "users", 50, "number of users")
+	flag.IntVar(&opts.events, "events", 200, "number of one-off events")
+	flag.Float64Var(&opts.pastRatio, "past-ratio", 0.4, "share of the one-off events that already took place")
+	flag.IntVar(&opts.series, "series", 10, "number of recurring event series")
+	flag.IntVar(&opts.occurrences, "occurrences", 8, "number of events in each recurring series")
+	flag.IntVar(&opts.maxAttendees, "max-attendees", 20, "maximum number of attendees of an event")
+	flag.StringVar(&opts.password, "password", "password123", "password of every generated user")
+	flag.BoolVar(&opts.reset, "reset", false, "delete the existing users, events and their data first")
+	flag.Parse()
+
+	baseDate, err := time.Parse(time.DateOnly, base)
+	if err != nil {
+		log.Fatal("Invalid base date: ", err)
+	}
+	opts.base = baseDate
+
+	if err := opts.validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := os.Stat(*dbPath); err != nil {
+		log.Fatal("Database not found, run the migrations first: ", err)
+	}
+
+	db, err := database.Open(*dbPath, database.Options{})
+	if err != nil {
+		log.Fatal("Failed to connect to the database: ", err)
+	}
+	defer db.Close()
+
+	started := time.Now()
+
+	result, err := seed(db, opts)
+	if err != nil {
+		log.Fatal("Failed to seed the database: ", err)
+	}
+
+	log.Printf("Seeded %d users, %d events and %d attendees in %s", result.users, result.events,
+		result.attendees, time.Since(started).Round(time.Millisecond))
+	log.Printf("Every user logs in with password %q; admin@example.com is an administrator", opts.password)
+}
+
+func (o options) validate() error {
+	switch {
+	case o.users < 1:
+		return errors.New("-users must be at least 1")
+	case o.events < 0 || o.series < 0 || o.occurrences < 0 || o.maxAttendees < 0:
+		return errors.New("-events, -series, -occurrences and -max-attendees can't be negative")
+	case o.pastRatio < 0 || o.pastRatio > 1:
+		return errors.New("-past-ratio must be between 0 and 1")
+	case len(o.password) < 8:
+		return errors.New("-password must be at least 8 characters")
+	}
+
+	return nil
+}
+
+// seed writes everything in a single transaction, bypassing the models: going
+// through them would queue notifications and webhooks for every generated
+// event and attendee.
+func seed(db *sql.DB, opts options) (stats, error) {
+	var result stats
+
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	var existing int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&existing); err != nil {
+		return result, err
+	}
+
+	if existing > 0 {
+		if !opts.reset {
+			return result, fmt.Errorf("the database already has %d users, pass -reset to replace them", existing)
+		}
+
+		if err := reset(ctx, tx); err != nil {
+			return result, err
+		}
+	}
+
+	s := &seeder{
+		options: opts,
+		rand:    rand.New(rand.NewPCG(opts.seed, opts.seed)),
+		tx:      tx,
+		ctx:     ctx,
+	}
+
+	userIds, err := s.insertUsers()
+	if err != nil {
+		return result, err
+	}
+
+	eventIds, err := s.insertEvents(userIds)
+	if err != nil {
+		return result, err
+	}
+
+	result.attendees, err = s.insertAttendees(userIds, eventIds)
+	if err != nil {
+		return result, err
+	}
+
+	result.users, result.events = len(userIds), len(eventIds)
+
+	return result, tx.Commit()
+}
+
+func reset(ctx context.Context, tx *sql.Tx) error {
+	tables := []string{
+		"event_messages", "event_reminders", "attendees", "webhook_deliveries", "webhooks",
+		"password_resets", "password_reset_requests", "login_attempts", "outbox", "events", "users",
+	}
+
+	for _, table := range tables {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+			return err
+		}
+	}
+
+	// Restart the ids so a given seed always yields the same ones.
+	_, err := tx.ExecContext(ctx, "DELETE FROM sqlite_sequence WHERE name IN ('"+strings.Join(tables, "', '")+"')")
+
+	return err
+}
+
+func (s *seeder) insertUsers() ([]int, error) {
+	// Hashing is slow on purpose, so every user shares the same hash.
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(s.password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := s.tx.PrepareContext(s.ctx,
+		"INSERT INTO users (email, name, password, role, email_verified) VALUES ($1, $2, $3, $4, $5) RETURNING id")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	ids := make([]int, 0, s.users)
+
+	for i := 0; i < s.users; i++ {
+		name, email := "Admin", "admin@example.com"
+		role, verified := database.RoleAdmin, true
+
+		if i > 0 {
+			first := firstNames[s.rand.IntN(len(firstNames))]
+			last := lastNames[s.rand.IntN(len(lastNames))]
+
+			name = first + " " + last
+			// The index keeps the addresses unique however many users
+			// share a name.
+			email = fmt.Sprintf("%s.%s.%d@example.com", asciiLower(first), asciiLower(last), i)
+			role, verified = database.RoleUser, s.rand.Float64() < 0.8
+		}
+
+		var id int
+		err := stmt.QueryRowContext(s.ctx, email, name, string(hashedPassword), role, verified).Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func (s *seeder) insertEvents(userIds []int) ([]int, error) {
+	stmt, err := s.tx.PrepareContext(s.ctx,
+		"INSERT INTO events (owner_id, name, description, date, location) VALUES ($1, $2, $3, $4, $5) RETURNING id")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	ids := make([]int, 0, s.events+s.series*s.occurrences)
+
+	insert := func(event *database.Event) error {
+		var id int
+		err := stmt.QueryRowContext(s.ctx, event.OwnerId, event.Name, event.Description, event.Date,
+			event.Location).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		ids = append(ids, id)
+
+		return nil
+	}
+
+	for i := 0; i < s.events; i++ {
+		// One-off events happen within six months of the base date.
+		days := 1 + s.rand.IntN(180)
+		if s.rand.Float64() < s.pastRatio {
+			days = -days
+		}
+
+		event := s.randomEvent(userIds)
+		event.Date = s.at(s.base.AddDate(0, 0, days))
+
+		if err := insert(event); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := 0; i < s.series; i++ {
+		// A series happens every week, two weeks or month, with half of its
+		// occurrences before the base date.
+		template := s.randomEvent(userIds)
+		interval := []int{7, 14, 28}[s.rand.IntN(3)]
+		start := s.at(s.base.AddDate(0, 0, -interval*(s.occurrences/2)))
+
+		for n := 0; n < s.occurrences; n++ {
+			event := *template
+			event.Name = fmt.Sprintf("%s #%d", template.Name, n+1)
+			event.Date = start.AddDate(0, 0, interval*n)
+
+			if err := insert(&event); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+func (s *seeder) randomEvent(userIds []int) *database.Event {
+	kind := eventKinds[s.rand.IntN(len(eventKinds))]
+	topic := topics[s.rand.IntN(len(topics))]
+
+	return &database.Event{
+		OwnerId:     userIds[s.rand.IntN(len(userIds))],
+		Name:        topic + " " + kind.name,
+		Description: fmt.Sprintf(kind.description, topic),
+		Location:    locations[s.rand.IntN(len(locations))],
+	}
+}
+
+// at sets a random starting time between 9:00 and 21:30 on the given day.
+func (s *seeder) at(day time.Time) time.Time {
+	return day.Add(time.Duration(9*60+30*s.rand.IntN(26)) * time.Minute)
+}
+
+func (s *seeder) insertAttendees(userIds, eventIds []int) (int, error) {
+	stmt, err := s.tx.PrepareContext(s.ctx, "INSERT INTO attendees (event_id, user_id) VALUES ($1, $2)")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	count := 0
+
+	for _, eventId := range eventIds {
+		attendees := min(s.rand.IntN(s.maxAttendees+1), len(userIds))
+
+		for _, i := range s.rand.Perm(len(userIds))[:attendees] {
+			if _, err := stmt.ExecContext(s.ctx, eventId, userIds[i]); err != nil {
+				return 0, err
+			}
+
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+var unaccent = strings.NewReplacer("á", "a", "â", "a", "ã", "a", "é", "e", "ê", "e", "í", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ú", "u", "ç", "c")
+
+// asciiLower turns a name into the local part of an email address.
+func asciiLower(name string) string {
+	return unaccent.Replace(strings.ToLower(name))
+}
@@ -0,0 +1,244 @@
+// Package backup takes consistent copies of the SQLite database while the
+// application keeps using it, checks them and restores them.
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// ErrIntegrity is returned when a database file fails SQLite's integrity
+// check.
+var ErrIntegrity = errors.New("integrity check failed")
+
+// Info describes a database file.
+type Info struct {
+	Path string
+	Size int64
+	// Version is the last migration applied to the database, and Dirty tells
+	// whether that migration failed halfway.
+	Version uint
+	Dirty   bool
+}
+
+// fileTimeLayout names backups so they sort chronologically.
+const fileTimeLayout = "20060102T150405Z"
+
+var backupName = regexp.MustCompile(`^data-\d{8}T\d{6}Z\.db$`)
+
+// Create writes a consistent copy of db to path with VACUUM INTO. It runs in
+// a read transaction, so it doesn't block the writers of a live database. The
+// copy is written to a temporary file first and only renamed to path once
+// complete and checked.
+//
+// Backups hold password hashes and other secrets: they are only readable by
+// their owner.
+func Create(ctx context.Context, db *sql.DB, path string) (*Info, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("%s already exists", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+
+	// VACUUM INTO accepts an empty file, whose mode it keeps, where it would
+	// otherwise create one following the umask.
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+
+	if _, err := db.ExecContext(ctx, "VACUUM INTO $1", tmp); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+
+	if err := os.Chmod(tmp, 0o600); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+
+	info, err := Check(ctx, tmp)
+	if err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	info.Path = path
+
+	return info, nil
+}
+
+// CreateInDir writes a backup named after the current time in dir.
+func CreateInDir(ctx context.Context, db *sql.DB, dir string, now time.Time) (*Info, error) {
+	name := "data-" + now.UTC().Format(fileTimeLayout) + ".db"
+
+	return Create(ctx, db, filepath.Join(dir, name))
+}
+
+// Check runs SQLite's integrity check on a database file, opened read-only,
+// and reads its schema version.
+func Check(ctx context.Context, path string) (*Info, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s: %s", ErrIntegrity, path, strings.Join(problems, "; "))
+	}
+
+	info := &Info{Path: path, Size: stat.Size()}
+
+	info.Version, info.Dirty, err = SchemaVersion(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return info, nil
+}
+
+// SchemaVersion returns the last migration recorded by golang-migrate in db.
+func SchemaVersion(ctx context.Context, db *sql.DB) (uint, bool, error) {
+	var version uint
+	var dirty bool
+
+	err := db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+
+		return 0, false, fmt.Errorf("reading schema version: %w", err)
+	}
+
+	return version, dirty, nil
+}
+
+var migrationName = regexp.MustCompile(`^(\d+)_.*\.up\.sql$`)
+
+// LatestMigration returns the version of the newest migration in dir, the
+// schema version the application expects.
+func LatestMigration(dir string) (uint, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		match := migrationName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+
+		latest = max(latest, uint(version))
+	}
+
+	if latest == 0 {
+		return 0, fmt.Errorf("no migrations found in %s", dir)
+	}
+
+	return latest, nil
+}
+
+// List returns the backups written by CreateInDir in dir, oldest first.
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && backupName.MatchString(entry.Name()) {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+
+	// The names embed the time they were taken at.
+	slices.Sort(paths)
+
+	return paths, nil
+}
+
+// Prune deletes all but the keep most recent backups in dir and returns the
+// paths it removed.
+func Prune(dir string, keep int) ([]string, error) {
+	paths, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(paths) <= keep {
+		return nil, nil
+	}
+
+	var removed []string
+	for _, path := range paths[:len(paths)-keep] {
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+
+		removed = append(removed, path)
+	}
+
+	return removed, nil
+}
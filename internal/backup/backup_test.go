@@ -0,0 +1,188 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestDB creates a WAL database at path with an items table and the
+// schema_migrations table of golang-migrate at version.
+func newTestDB(t *testing.T, path string, version uint, dirty bool) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", path+"?_journal_mode=WAL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// A single connection, so that closing it is the last one.
+	db.SetMaxOpenConns(1)
+
+	statements := []string{
+		"CREATE TABLE schema_migrations (version uint64, dirty bool)",
+		"CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)",
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := db.Exec("INSERT INTO schema_migrations VALUES ($1, $2)", version, dirty); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func countItems(t *testing.T, path string) int {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM items").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+
+	return count
+}
+
+func TestCreateIsOnlyReadableByOwner(t *testing.T) {
+	dir := t.TempDir()
+	db := newTestDB(t, filepath.Join(dir, "data.db"), 1, false)
+
+	info, err := Create(context.Background(), db, filepath.Join(dir, "backups", "backup.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stat, err := os.Stat(info.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := stat.Mode().Perm(); mode != 0o600 {
+		t.Errorf("backup mode = %o, want 600", mode)
+	}
+}
+
+func TestRestoreRefusesSchemaMismatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		version uint
+		dirty   bool
+	}{
+		{"older", 2, false},
+		{"newer", 4, false},
+		{"dirty", 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "backup.db")
+			newTestDB(t, src, tt.version, tt.dirty).Close()
+
+			dbPath := filepath.Join(dir, "data.db")
+			newTestDB(t, dbPath, 3, false).Close()
+			before := readFile(t, dbPath)
+
+			previous, err := Restore(context.Background(), src, dbPath, 3)
+			if !errors.Is(err, ErrSchemaMismatch) {
+				t.Fatalf("got %v, want ErrSchemaMismatch", err)
+			}
+			if previous != "" {
+				t.Errorf("the database was set aside as %s", previous)
+			}
+			if string(readFile(t, dbPath)) != string(before) {
+				t.Error("the database was changed")
+			}
+		})
+	}
+}
+
+// TestRestoreKeepsUncheckpointedTransactions restores over a database whose
+// last transactions are still in its write-ahead log, as left by a crash,
+// and checks that the copy set aside holds them without the log.
+func TestRestoreKeepsUncheckpointedTransactions(t *testing.T) {
+	dir := t.TempDir()
+
+	src := filepath.Join(dir, "backup.db")
+	newTestDB(t, src, 3, false).Close()
+
+	live := filepath.Join(dir, "live.db")
+	db := newTestDB(t, live, 3, false)
+	if _, err := db.Exec("PRAGMA wal_autocheckpoint = 0"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		if _, err := db.Exec("INSERT INTO items (name) VALUES ($1)", name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Copy the files while the database is open, before closing it
+	// checkpoints the log.
+	dbPath := filepath.Join(dir, "data.db")
+	copyTestFile(t, live, dbPath)
+	copyTestFile(t, live+"-wal", dbPath+"-wal")
+	db.Close()
+
+	previous, err := Restore(context.Background(), src, dbPath, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Remove(previous + "-wal"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		t.Fatal(err)
+	}
+	os.Remove(previous + "-shm")
+
+	if count := countItems(t, previous); count != 3 {
+		t.Errorf("the previous database has %d items without its log, want 3", count)
+	}
+	if count := countItems(t, dbPath); count != 0 {
+		t.Errorf("the restored database has %d items, want 0", count)
+	}
+}
+
+func readFile(t *testing.T, path string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func copyTestFile(t *testing.T, src, dst string) {
+	t.Helper()
+
+	in, err := os.Open(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,161 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrSchemaMismatch is returned when a backup doesn't have the schema
+// version the application expects.
+var ErrSchemaMismatch = errors.New("schema version mismatch")
+
+// Restore replaces the database at dbPath with the backup at src, after
+// checking its integrity and that its schema is at version want. The current
+// database, with the transactions of its write-ahead log checkpointed into
+// it, is kept next to it with a ".pre-restore-<time>" suffix and the path of
+// that copy is returned; it is empty when there was no database.
+//
+// Nothing may have the database open while it is restored.
+func Restore(ctx context.Context, src, dbPath string, want uint) (string, error) {
+	info, err := Check(ctx, src)
+	if err != nil {
+		return "", err
+	}
+
+	if info.Dirty || info.Version != want {
+		return "", fmt.Errorf("%w: %s is at version %d (dirty: %t), expected %d", ErrSchemaMismatch,
+			src, info.Version, info.Dirty, want)
+	}
+
+	// Copy next to the database first, so the swap itself is a rename
+	// within the same filesystem.
+	tmp := dbPath + ".restore.tmp"
+	if err := copyFile(src, tmp); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+
+	var previous string
+	if _, err := os.Stat(dbPath); err == nil {
+		if err := checkpoint(ctx, dbPath); err != nil {
+			os.Remove(tmp)
+			return "", err
+		}
+
+		previous, err = unusedPath(dbPath + ".pre-restore-" + time.Now().UTC().Format(fileTimeLayout))
+		if err != nil {
+			os.Remove(tmp)
+			return "", err
+		}
+
+		if err := os.Rename(dbPath, previous); err != nil {
+			os.Remove(tmp)
+			return "", err
+		}
+	}
+
+	// Journal files belong to the database being replaced: they go with it,
+	// as they would corrupt the restored one.
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		if _, err := os.Stat(dbPath + suffix); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		if previous == "" {
+			if err := os.Remove(dbPath + suffix); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		if err := os.Rename(dbPath+suffix, previous+suffix); err != nil {
+			return previous, err
+		}
+	}
+
+	if err := os.Rename(tmp, dbPath); err != nil {
+		return previous, err
+	}
+
+	return previous, syncDir(filepath.Dir(dbPath))
+}
+
+// checkpoint copies the transactions still in the write-ahead log of the
+// database at path into the database file, so that the file alone holds all
+// the data when it is set aside.
+func checkpoint(ctx context.Context, path string) error {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var busy, logFrames, checkpointed int
+	if err := db.QueryRowContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)").Scan(&busy, &logFrames, &checkpointed); err != nil {
+		return fmt.Errorf("checkpointing %s: %w", path, err)
+	}
+
+	if busy != 0 {
+		return fmt.Errorf("checkpointing %s: the database is in use", path)
+	}
+
+	return nil
+}
+
+// unusedPath returns path, or path with a numbered suffix if it already
+// exists.
+func unusedPath(path string) (string, error) {
+	candidate := path
+
+	for i := 1; ; i++ {
+		if _, err := os.Stat(candidate); errors.Is(err, os.ErrNotExist) {
+			return candidate, nil
+		} else if err != nil {
+			return "", err
+		}
+
+		candidate = fmt.Sprintf("%s.%d", path, i)
+	}
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// syncDir makes the renames in dir durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
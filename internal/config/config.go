@@ -0,0 +1,301 @@
+// Package config loads typed settings into a struct from command-line flags,
+// the environment, a .env file and a YAML or TOML file.
+//
+// Every setting is a struct field tagged with the name of its environment
+// variable:
+//
+//	type settings struct {
+//		Port    int           `config:"PORT" default:"8080" usage:"port of the HTTP server"`
+//		Timeout time.Duration `config:"TIMEOUT" default:"10s"`
+//		Secret  string        `config:"SECRET" required:"true"`
+//	}
+//
+// The value of a setting comes from the first of these sources defining it:
+//
+//  1. the command-line flag named after the variable, -port or -timeout;
+//  2. the environment variable, PORT or TIMEOUT;
+//  3. the .env file of the working directory;
+//  4. the configuration file given by -config or CONFIG_FILE, with keys such
+//     as port or timeout, in YAML (.yaml, .yml) or TOML (.toml);
+//  5. the default tag.
+//
+// The keys of the configuration file matching no setting are ignored, so
+// that several commands can share it.
+//
+// Supported field types are string, int, bool, time.Duration and slices of
+// strings or durations, written as comma separated lists outside of the
+// configuration file.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// EnvFile is the dotenv file read by Load.
+const EnvFile = ".env"
+
+// ConfigFileVar names the variable, and its flag, giving the configuration
+// file.
+const ConfigFileVar = "CONFIG_FILE"
+
+type field struct {
+	name     string
+	usage    string
+	def      string
+	required bool
+	value    reflect.Value
+}
+
+// source is where the value of a setting was found, reported in errors.
+type source struct {
+	name   string
+	lookup func(name string) (string, bool)
+}
+
+// Load fills dst, a pointer to a struct, and returns the positional
+// arguments left after the flags. A -h or -help flag makes it print the
+// usage and return flag.ErrHelp. Every invalid or missing value is reported
+// in the returned error.
+func Load(dst any, name string, args []string) ([]string, error) {
+	fields, err := fieldsOf(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flagValues := map[string]*flagValue{}
+
+	for _, f := range fields {
+		value := &flagValue{isBool: f.value.Kind() == reflect.Bool}
+		flagValues[f.name] = value
+
+		usage := f.usage
+		if usage == "" {
+			usage = f.name
+		}
+		usage += " ($" + f.name + ")"
+		if f.def != "" {
+			usage += fmt.Sprintf(" (default %q)", f.def)
+		}
+
+		flags.Var(value, flagName(f.name), usage)
+	}
+
+	configFile := &flagValue{}
+	flags.Var(configFile, "config", "YAML or TOML configuration file ($"+ConfigFileVar+")")
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	dotenv, err := godotenv.Read(EnvFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("config: reading %s: %w", EnvFile, err)
+	}
+
+	sources := []source{
+		{"flag", func(name string) (string, bool) {
+			value := flagValues[name]
+			return value.value, value.set
+		}},
+		{"environment", os.LookupEnv},
+		{EnvFile, func(name string) (string, bool) {
+			value, ok := dotenv[name]
+			return value, ok
+		}},
+	}
+
+	path, ok := configFile.value, configFile.set
+	if !ok {
+		path, ok = os.LookupEnv(ConfigFileVar)
+	}
+	if !ok {
+		path = dotenv[ConfigFileVar]
+	}
+
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("config: reading %s: %w", path, err)
+		}
+
+		sources = append(sources, source{path, func(name string) (string, bool) {
+			value, ok := values[fileKey(name)]
+			return value, ok
+		}})
+	}
+
+	var errs []error
+
+	for _, f := range fields {
+		value, from := f.def, "default"
+		for _, s := range sources {
+			if v, ok := s.lookup(f.name); ok {
+				value, from = v, s.name
+				break
+			}
+		}
+
+		if f.required && strings.TrimSpace(value) == "" {
+			errs = append(errs, fmt.Errorf("%s is required", f.name))
+			continue
+		}
+
+		if err := set(f.value, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid value %q from %s: %w", f.name, value, from, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("config: %w", errors.Join(errs...))
+	}
+
+	return flags.Args(), nil
+}
+
+func fieldsOf(dst any) ([]field, error) {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("config: destination must be a pointer to a struct")
+	}
+	v = v.Elem()
+
+	var fields []field
+	for i := 0; i < v.NumField(); i++ {
+		structField := v.Type().Field(i)
+
+		name, ok := structField.Tag.Lookup("config")
+		if !ok {
+			continue
+		}
+
+		f := field{
+			name:     name,
+			usage:    structField.Tag.Get("usage"),
+			def:      structField.Tag.Get("default"),
+			required: structField.Tag.Get("required") == "true",
+			value:    v.Field(i),
+		}
+
+		// Catch unsupported types when the struct is first loaded rather
+		// than only once a value is set.
+		if err := set(reflect.New(f.value.Type()).Elem(), ""); errors.Is(err, errUnsupported) {
+			return nil, fmt.Errorf("config: %s: %w %s", name, err, f.value.Type())
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields, nil
+}
+
+var errUnsupported = errors.New("unsupported type")
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// set parses value into v. An empty value sets the zero value.
+func set(v reflect.Value, value string) error {
+	value = strings.TrimSpace(value)
+
+	switch {
+	case v.Type() == durationType:
+		if value == "" {
+			v.SetInt(0)
+			return nil
+		}
+
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(value)
+	case v.Kind() == reflect.Int:
+		if value == "" {
+			v.SetInt(0)
+			return nil
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.New("not an integer")
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Bool:
+		if value == "" {
+			v.SetBool(false)
+			return nil
+		}
+
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("not a boolean")
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Slice && (v.Type().Elem().Kind() == reflect.String || v.Type().Elem() == durationType):
+		items := reflect.MakeSlice(v.Type(), 0, 0)
+
+		for _, item := range strings.Split(value, ",") {
+			if strings.TrimSpace(item) == "" {
+				continue
+			}
+
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := set(elem, item); err != nil {
+				return err
+			}
+			items = reflect.Append(items, elem)
+		}
+
+		v.Set(items)
+	default:
+		return errUnsupported
+	}
+
+	return nil
+}
+
+// flagName turns JWT_SECRET into jwt-secret.
+func flagName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+}
+
+// fileKey turns JWT_SECRET into jwt_secret.
+func fileKey(name string) string {
+	return strings.ToLower(name)
+}
+
+// flagValue records whether a flag was given, to tell an empty value from a
+// missing flag.
+type flagValue struct {
+	value  string
+	set    bool
+	isBool bool
+}
+
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+
+	return v.value
+}
+
+func (v *flagValue) Set(value string) error {
+	v.value, v.set = value, true
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
+}
@@ -0,0 +1,377 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testSettings struct {
+	Name     string          `config:"TEST_NAME" default:"from-default"`
+	Port     int             `config:"TEST_PORT" default:"8080"`
+	Debug    bool            `config:"TEST_DEBUG" default:"false"`
+	Timeout  time.Duration   `config:"TEST_TIMEOUT" default:"10s"`
+	Tags     []string        `config:"TEST_TAGS" default:"a,b"`
+	Offsets  []time.Duration `config:"TEST_OFFSETS" default:"24h,1h"`
+	Required string          `config:"TEST_REQUIRED" default:"set" required:"true"`
+	Ignored  string
+}
+
+// inTempDir runs the test in an empty directory, so that a .env file of the
+// package directory can't interfere, and writes the given files there.
+func inTempDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		env    map[string]string
+		dotenv string
+		file   string
+		want   string
+	}{
+		{
+			name: "default",
+			want: "from-default",
+		},
+		{
+			name: "file over default",
+			file: "test_name: from-file\n",
+			want: "from-file",
+		},
+		{
+			name:   ".env over file",
+			dotenv: "TEST_NAME=from-dotenv\n",
+			file:   "test_name: from-file\n",
+			want:   "from-dotenv",
+		},
+		{
+			name:   "environment over .env",
+			env:    map[string]string{"TEST_NAME": "from-env"},
+			dotenv: "TEST_NAME=from-dotenv\n",
+			file:   "test_name: from-file\n",
+			want:   "from-env",
+		},
+		{
+			name:   "flag over environment",
+			args:   []string{"-test-name", "from-flag"},
+			env:    map[string]string{"TEST_NAME": "from-env"},
+			dotenv: "TEST_NAME=from-dotenv\n",
+			file:   "test_name: from-file\n",
+			want:   "from-flag",
+		},
+		{
+			name: "empty environment variable over default",
+			env:  map[string]string{"TEST_NAME": ""},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string]string{}
+			if tt.dotenv != "" {
+				files[EnvFile] = tt.dotenv
+			}
+			if tt.file != "" {
+				files["config.yaml"] = tt.file
+			}
+			dir := inTempDir(t, files)
+
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", filepath.Join(dir, "config.yaml")}, args...)
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			var s testSettings
+			if _, err := Load(&s, "test", args); err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			if s.Name != tt.want {
+				t.Errorf("Name = %q, want %q", s.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFileLocation(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup returns the arguments of Load.
+		setup func(t *testing.T, path string) []string
+	}{
+		{"flag", func(t *testing.T, path string) []string {
+			return []string{"-config", path}
+		}},
+		{"environment", func(t *testing.T, path string) []string {
+			t.Setenv(ConfigFileVar, path)
+			return nil
+		}},
+		{".env", func(t *testing.T, path string) []string {
+			if err := os.WriteFile(EnvFile, []byte(ConfigFileVar+"="+path+"\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			return nil
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := inTempDir(t, map[string]string{"settings.toml": "test_port = 9000\n"})
+
+			var s testSettings
+			if _, err := Load(&s, "test", tt.setup(t, filepath.Join(dir, "settings.toml"))); err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			if s.Port != 9000 {
+				t.Errorf("Port = %d, want 9000 from the configuration file", s.Port)
+			}
+		})
+	}
+}
+
+func TestLoadParsing(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		yaml  string
+		toml  string
+		check func(t *testing.T, s testSettings)
+	}{
+		{
+			name: "defaults",
+			check: func(t *testing.T, s testSettings) {
+				want := testSettings{
+					Name:     "from-default",
+					Port:     8080,
+					Timeout:  10 * time.Second,
+					Tags:     []string{"a", "b"},
+					Offsets:  []time.Duration{24 * time.Hour, time.Hour},
+					Required: "set",
+				}
+				if !reflect.DeepEqual(s, want) {
+					t.Errorf("got %+v, want %+v", s, want)
+				}
+			},
+		},
+		{
+			name: "environment",
+			env: map[string]string{
+				"TEST_PORT":    " 9000 ",
+				"TEST_DEBUG":   "true",
+				"TEST_TIMEOUT": "1m30s",
+				"TEST_TAGS":    "x, y ,,z",
+				"TEST_OFFSETS": "48h, 30m",
+			},
+			check: func(t *testing.T, s testSettings) {
+				if s.Port != 9000 || !s.Debug || s.Timeout != 90*time.Second {
+					t.Errorf("got port %d, debug %t, timeout %s", s.Port, s.Debug, s.Timeout)
+				}
+				if want := []string{"x", "y", "z"}; !reflect.DeepEqual(s.Tags, want) {
+					t.Errorf("Tags = %q, want %q", s.Tags, want)
+				}
+				if want := []time.Duration{48 * time.Hour, 30 * time.Minute}; !reflect.DeepEqual(s.Offsets, want) {
+					t.Errorf("Offsets = %v, want %v", s.Offsets, want)
+				}
+			},
+		},
+		{
+			name: "empty list",
+			env:  map[string]string{"TEST_TAGS": "", "TEST_OFFSETS": ""},
+			check: func(t *testing.T, s testSettings) {
+				if len(s.Tags) != 0 || len(s.Offsets) != 0 {
+					t.Errorf("Tags = %q, Offsets = %v, want empty lists", s.Tags, s.Offsets)
+				}
+			},
+		},
+		{
+			name: "boolean spellings",
+			env:  map[string]string{"TEST_DEBUG": "1"},
+			check: func(t *testing.T, s testSettings) {
+				if !s.Debug {
+					t.Error("Debug = false, want true for 1")
+				}
+			},
+		},
+		{
+			name: "YAML types and lists",
+			yaml: "test-port: 9001\ntest_debug: true\ntest_timeout: 2m\ntest_tags: [red, green]\ntest_offsets:\n  - 1h\n  - 15m\n",
+			check: func(t *testing.T, s testSettings) {
+				if s.Port != 9001 || !s.Debug || s.Timeout != 2*time.Minute {
+					t.Errorf("got port %d, debug %t, timeout %s", s.Port, s.Debug, s.Timeout)
+				}
+				if want := []string{"red", "green"}; !reflect.DeepEqual(s.Tags, want) {
+					t.Errorf("Tags = %q, want %q", s.Tags, want)
+				}
+				if want := []time.Duration{time.Hour, 15 * time.Minute}; !reflect.DeepEqual(s.Offsets, want) {
+					t.Errorf("Offsets = %v, want %v", s.Offsets, want)
+				}
+			},
+		},
+		{
+			name: "TOML types and lists",
+			toml: "test_port = 9002\ntest_debug = true\ntest_timeout = \"45s\"\ntest_tags = [\"blue\"]\nunrelated = \"ignored\"\n",
+			check: func(t *testing.T, s testSettings) {
+				if s.Port != 9002 || !s.Debug || s.Timeout != 45*time.Second {
+					t.Errorf("got port %d, debug %t, timeout %s", s.Port, s.Debug, s.Timeout)
+				}
+				if want := []string{"blue"}; !reflect.DeepEqual(s.Tags, want) {
+					t.Errorf("Tags = %q, want %q", s.Tags, want)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string]string{}
+			var args []string
+			if tt.yaml != "" {
+				files["config.yml"] = tt.yaml
+				args = []string{"-config", "config.yml"}
+			}
+			if tt.toml != "" {
+				files["config.toml"] = tt.toml
+				args = []string{"-config", "config.toml"}
+			}
+			inTempDir(t, files)
+
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			var s testSettings
+			if _, err := Load(&s, "test", args); err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			tt.check(t, s)
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		// want are parts of the error message.
+		want []string
+	}{
+		{
+			name: "every invalid value is reported",
+			env: map[string]string{
+				"TEST_PORT":    "eighty",
+				"TEST_DEBUG":   "maybe",
+				"TEST_TIMEOUT": "10",
+				"TEST_OFFSETS": "1h,soon",
+			},
+			want: []string{
+				`TEST_PORT: invalid value "eighty" from environment: not an integer`,
+				`TEST_DEBUG: invalid value "maybe" from environment: not a boolean`,
+				`TEST_TIMEOUT: invalid value "10" from environment`,
+				`TEST_OFFSETS: invalid value "1h,soon" from environment`,
+			},
+		},
+		{
+			name: "flag named in the error",
+			args: []string{"-test-port", "x"},
+			want: []string{`TEST_PORT: invalid value "x" from flag`},
+		},
+		{
+			name: "required",
+			env:  map[string]string{"TEST_REQUIRED": "  "},
+			want: []string{"TEST_REQUIRED is required"},
+		},
+		{
+			name: "unknown configuration file format",
+			args: []string{"-config", "config.json"},
+			want: []string{`unknown format ".json"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inTempDir(t, map[string]string{"config.json": "{}"})
+
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			var s testSettings
+			_, err := Load(&s, "test", tt.args)
+			if err == nil {
+				t.Fatal("Load succeeded")
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q doesn't contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadArgs(t *testing.T) {
+	inTempDir(t, nil)
+
+	var s testSettings
+	rest, err := Load(&s, "test", []string{"-test-debug", "-test-port=81", "up", "-x"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if !s.Debug || s.Port != 81 {
+		t.Errorf("got debug %t, port %d, want true and 81", s.Debug, s.Port)
+	}
+	if want := []string{"up", "-x"}; !reflect.DeepEqual(rest, want) {
+		t.Errorf("positional arguments %q, want %q", rest, want)
+	}
+}
+
+func TestLoadHelp(t *testing.T) {
+	inTempDir(t, nil)
+
+	var s testSettings
+	if _, err := Load(&s, "test", []string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Load with -h: got %v, want flag.ErrHelp", err)
+	}
+}
+
+func TestLoadUnsupportedType(t *testing.T) {
+	var s struct {
+		Ratio float64 `config:"TEST_RATIO"`
+	}
+
+	if _, err := Load(&s, "test", nil); !errors.Is(err, errUnsupported) {
+		t.Errorf("got %v, want errUnsupported", err)
+	}
+
+	if _, err := Load(s, "test", nil); err == nil {
+		t.Error("Load accepted a struct that isn't a pointer")
+	}
+}
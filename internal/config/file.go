@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// readFile decodes a YAML or TOML file of top-level settings into their
+// string representation.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[string]any{}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unknown format %q, expected .yaml, .yml or .toml", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(raw))
+
+	for key, value := range raw {
+		key = strings.ReplaceAll(strings.ToLower(key), "-", "_")
+
+		switch value := value.(type) {
+		case nil:
+			values[key] = ""
+		case []any:
+			items := make([]string, len(value))
+			for i, item := range value {
+				if !isScalar(item) {
+					return nil, fmt.Errorf("%s: lists may only hold scalar values", key)
+				}
+
+				items[i] = fmt.Sprint(item)
+			}
+
+			values[key] = strings.Join(items, ",")
+		default:
+			if !isScalar(value) {
+				return nil, fmt.Errorf("%s: expected a scalar value or a list", key)
+			}
+
+			values[key] = fmt.Sprint(value)
+		}
+	}
+
+	return values, nil
+}
+
+func isScalar(value any) bool {
+	switch value.(type) {
+	case string, bool, int, int64, uint64, float64:
+		return true
+	}
+
+	return false
+}
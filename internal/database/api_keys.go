@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+type APIKeyModel struct {
+	DB *sql.DB
+}
+
+// APIKey lets an integration act as a user without their password. Only the
+// hash of the key is stored; Prefix, its first characters, helps users tell
+// their keys apart.
+type APIKey struct {
+	Id         int        `json:"id"`
+	UserId     int        `json:"userId"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// IsExpired reports whether the key can no longer be used at the given time.
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+const apiKeyColumns = "id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, created_at"
+
+func scanAPIKey(scanner interface{ Scan(...any) error }) (*APIKey, error) {
+	var key APIKey
+	var scopes string
+
+	err := scanner.Scan(&key.Id, &key.UserId, &key.Name, &key.Prefix, &key.KeyHash, &scopes,
+		&key.ExpiresAt, &key.LastUsedAt, &key.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	key.Scopes = strings.Split(scopes, ",")
+
+	return &key, nil
+}
+
+func (m *APIKeyModel) Insert(key *APIKey) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now().UTC()
+	}
+
+	query := `
+	  INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at, created_at)
+	  VALUES ($1, $2, $3, $4, $5, $6, $7)
+	  RETURNING id
+	`
+
+	return m.DB.QueryRowContext(ctx, query, key.UserId, key.Name, key.Prefix, key.KeyHash,
+		strings.Join(key.Scopes, ","), key.ExpiresAt, key.CreatedAt).Scan(&key.Id)
+}
+
+func (m *APIKeyModel) Get(id int) (*APIKey, error) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE id = $1"
+
+	return m.getAPIKey(query, id)
+}
+
+func (m *APIKeyModel) GetByHash(keyHash string) (*APIKey, error) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE key_hash = $1"
+
+	return m.getAPIKey(query, keyHash)
+}
+
+func (m *APIKeyModel) getAPIKey(query string, args ...any) (*APIKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	key, err := scanAPIKey(m.DB.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// GetByUser returns the keys of a user, most recent first.
+func (m *APIKeyModel) GetByUser(userId int) ([]*APIKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE user_id = $1 ORDER BY id DESC"
+
+	rows, err := m.DB.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*APIKey{}
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// TouchLastUsed records that a key was just used.
+func (m *APIKeyModel) TouchLastUsed(id int, at time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, "UPDATE api_keys SET last_used_at = $1 WHERE id = $2", at.UTC(), id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *APIKeyModel) Delete(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, "DELETE FROM api_keys WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -19,12 +19,26 @@ func (m *AttendeeModel) Insert(attendee *Attendee) (*Attendee, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	query := "INSERT INTO attendees (event_id, user_id) VALUES ($1, $2) RETURNING id"
-	err := m.DB.QueryRowContext(ctx, query, attendee.EventId, attendee.UserId).Scan(&attendee.Id)
+	err = tx.QueryRowContext(ctx, query, attendee.EventId, attendee.UserId).Scan(&attendee.Id)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := insertOutboxMessage(ctx, tx, TopicAttendeeAdded, attendee); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	return attendee, nil
 }
 
@@ -84,13 +98,40 @@ func (m *AttendeeModel) Delete(eventId, userId int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := "DELETE FROM attendees WHERE user_id = $1 AND event_id = $2"
-	_, err := m.DB.ExecContext(ctx, query, userId, eventId)
+	tx, err := m.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	query := "DELETE FROM attendees WHERE user_id = $1 AND event_id = $2 RETURNING id"
+	rows, err := tx.QueryContext(ctx, query, userId, eventId)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var removed []Attendee
+	for rows.Next() {
+		attendee := Attendee{UserId: userId, EventId: eventId}
+		if err := rows.Scan(&attendee.Id); err != nil {
+			return err
+		}
+
+		removed = append(removed, attendee)
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, attendee := range removed {
+		if err := insertOutboxMessage(ctx, tx, TopicAttendeeRemoved, attendee); err != nil {
+			return err
+		}
+	}
 
-	return nil
+	return tx.Commit()
 }
 
 func (m *AttendeeModel) GetEventsByAttendee(attendeeId int) ([]*Event, error) {
@@ -124,3 +165,88 @@ func (m *AttendeeModel) GetEventsByAttendee(attendeeId int) ([]*Event, error) {
 
 	return events, nil
 }
+
+// GetAttendeesByEventIds returns the attendees of each of the given events,
+// keyed by event id.
+func (m *AttendeeModel) GetAttendeesByEventIds(eventIds []int) (map[int][]*User, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	placeholders, args := inList(eventIds)
+	query := `
+	  SELECT a.event_id, u.id, u.name, u.email
+	  FROM users u
+	  JOIN attendees a ON u.id = a.user_id
+	  WHERE a.event_id IN (` + placeholders + `)
+	  ORDER BY a.id
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attendees := make(map[int][]*User)
+
+	for rows.Next() {
+		var eventId int
+		var user User
+
+		err := rows.Scan(&eventId, &user.Id, &user.Name, &user.Email)
+		if err != nil {
+			return nil, err
+		}
+
+		attendees[eventId] = append(attendees[eventId], &user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return attendees, nil
+}
+
+// GetEventsByAttendeeIds returns the events each of the given users attends,
+// keyed by user id.
+func (m *AttendeeModel) GetEventsByAttendeeIds(attendeeIds []int) (map[int][]*Event, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	placeholders, args := inList(attendeeIds)
+	query := `
+	  SELECT a.user_id, e.id, e.owner_id, e.name, e.description, e.date, e.location
+	  FROM events e
+	  JOIN attendees a ON e.id = a.event_id
+	  WHERE a.user_id IN (` + placeholders + `)
+	  ORDER BY a.id
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make(map[int][]*Event)
+
+	for rows.Next() {
+		var attendeeId int
+		var event Event
+
+		err := rows.Scan(&attendeeId, &event.Id, &event.OwnerId, &event.Name,
+			&event.Description, &event.Date, &event.Location)
+		if err != nil {
+			return nil, err
+		}
+
+		events[attendeeId] = append(events[attendeeId], &event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate"
+	"github.com/golang-migrate/migrate/database/sqlite3"
+	"github.com/golang-migrate/migrate/source/file"
+)
+
+// newTestModels opens a database in a temporary directory with Open, runs
+// the migrations of cmd/migrate on it and returns its models.
+func newTestModels(t *testing.T) Models {
+	t.Helper()
+
+	db, err := Open(filepath.Join(t.TempDir(), "data.db"), Options{})
+	if err != nil {
+		t.Fatalf("opening the database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	instance, err := sqlite3.WithInstance(db, &sqlite3.Config{})
+	if err != nil {
+		t.Fatalf("creating the migration instance: %v", err)
+	}
+
+	source, err := (&file.File{}).Open("../../cmd/migrate/migrations")
+	if err != nil {
+		t.Fatalf("opening the migrations: %v", err)
+	}
+
+	m, err := migrate.NewWithInstance("file", source, "sqlite3", instance)
+	if err != nil {
+		t.Fatalf("creating the migration: %v", err)
+	}
+
+	if err := m.Up(); err != nil {
+		t.Fatalf("running the migrations: %v", err)
+	}
+
+	return NewModels(db)
+}
+
+func insertTestUser(t *testing.T, models Models, email string) *User {
+	t.Helper()
+
+	user := &User{Email: email, Name: "Test", Password: "hash"}
+	if err := models.Users.Insert(user); err != nil {
+		t.Fatalf("inserting user %s: %v", email, err)
+	}
+
+	return user
+}
+
+func insertTestEvent(t *testing.T, models Models, ownerId int) *Event {
+	t.Helper()
+
+	event := &Event{
+		OwnerId:     ownerId,
+		Name:        "Meetup",
+		Description: "A meetup to test the models",
+		Date:        time.Now().UTC().Add(24 * time.Hour),
+		Location:    "Online",
+	}
+	if err := models.Events.Insert(event); err != nil {
+		t.Fatalf("inserting event: %v", err)
+	}
+
+	return event
+}
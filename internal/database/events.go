@@ -13,19 +13,34 @@ type EventModel struct {
 type Event struct {
 	Id          int       `json:"id"`
 	OwnerId     int       `json:"ownerId"`
-	Name        string    `json:"name" binding:"required, min=3"`
-	Description string    `json:"description" binding:"required, min=10"`
-	Date        time.Time `json:"date" binding:"required, datetime=2006-01-02"`
-	Location    string    `json:"location" binding:"required, min=3"`
+	Name        string    `json:"name" binding:"required,min=3"`
+	Description string    `json:"description" binding:"required,min=10"`
+	Date        time.Time `json:"date" binding:"required"`
+	Location    string    `json:"location" binding:"required,min=3"`
 }
 
 func (m *EventModel) Insert(event *Event) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := "INSERT INTO events (owner_id, name, description, date, location) VALUES ($1, $2, $3, $4, $5) RETURNING id"
 
-	return m.DB.QueryRowContext(ctx, query, event.OwnerId, event.Name, event.Description, event.Date, event.Location).Scan(&event.Id)
+	err = tx.QueryRowContext(ctx, query, event.OwnerId, event.Name, event.Description, event.Date, event.Location).Scan(&event.Id)
+	if err != nil {
+		return err
+	}
+
+	if err := insertOutboxMessage(ctx, tx, TopicEventCreated, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (m *EventModel) GetAll() ([]*Event, error) {
@@ -82,31 +97,253 @@ func (m *EventModel) Get(id int) (*Event, error) {
 	return &event, nil
 }
 
+// GetByIds returns the events with the given ids, in no particular order.
+func (m *EventModel) GetByIds(ids []int) ([]*Event, error) {
+	placeholders, args := inList(ids)
+	query := "SELECT id, owner_id, name, description, date, location FROM events WHERE id IN (" + placeholders + ")"
+
+	return m.getEvents(query, args...)
+}
+
+// GetByOwnerIds returns the events owned by any of the given users.
+func (m *EventModel) GetByOwnerIds(ownerIds []int) ([]*Event, error) {
+	placeholders, args := inList(ownerIds)
+	query := "SELECT id, owner_id, name, description, date, location FROM events WHERE owner_id IN (" + placeholders + ") ORDER BY id"
+
+	return m.getEvents(query, args...)
+}
+
+func (m *EventModel) getEvents(query string, args ...any) ([]*Event, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*Event{}
+
+	for rows.Next() {
+		var event Event
+
+		err := rows.Scan(&event.Id, &event.OwnerId, &event.Name,
+			&event.Description, &event.Date, &event.Location)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, &event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
 func (m *EventModel) Update(event *Event) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := "UPDATE events SET name = $1, description = $2, date = $3, location = $4 WHERE id = $5"
 
-	_, err := m.DB.ExecContext(ctx, query, event.Name, event.Description,
+	_, err = tx.ExecContext(ctx, query, event.Name, event.Description,
 		event.Date, event.Location, event.Id)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if err := insertOutboxMessage(ctx, tx, TopicEventUpdated, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (m *EventModel) Delete(id int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := "DELETE FROM events WHERE id = $1"
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var event Event
+	query := "SELECT id, owner_id, name, description, date, location FROM events WHERE id = $1"
+	err = tx.QueryRowContext(ctx, query, id).Scan(&event.Id, &event.OwnerId, &event.Name,
+		&event.Description, &event.Date, &event.Location)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT user_id FROM attendees WHERE event_id = $1", id)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	attendeeIds := []int{}
+	for rows.Next() {
+		var userId int
+		if err := rows.Scan(&userId); err != nil {
+			return err
+		}
+
+		attendeeIds = append(attendeeIds, userId)
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM events WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	err = insertOutboxMessage(ctx, tx, TopicEventDeleted, DeletedEvent{Event: &event, AttendeeIds: attendeeIds})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetUpcoming returns the events taking place after from and no later than
+// to, soonest first.
+func (m *EventModel) GetUpcoming(from, to time.Time) ([]*Event, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+	  SELECT id, owner_id, name, description, date, location
+	  FROM events
+	  WHERE datetime(date) > datetime($1) AND datetime(date) <= datetime($2)
+	  ORDER BY datetime(date)
+	`
 
-	_, err := m.DB.ExecContext(ctx, query, id)
+	rows, err := m.DB.QueryContext(ctx, query, from.UTC(), to.UTC())
 	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*Event{}
+
+	for rows.Next() {
+		var event Event
+
+		err := rows.Scan(&event.Id, &event.OwnerId, &event.Name,
+			&event.Description, &event.Date, &event.Location)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, &event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Transfer gives an event to another owner.
+func (m *EventModel) Transfer(id, ownerId int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := transferEvents(ctx, tx, ownerId, "id = $2", id); err != nil {
 		return err
 	}
 
-	return nil
+	return tx.Commit()
+}
+
+// TransferAll gives every event of a user to another owner and returns how
+// many events changed hands.
+func (m *EventModel) TransferAll(fromOwnerId, toOwnerId int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	transferred, err := transferEvents(ctx, tx, toOwnerId, "owner_id = $2", fromOwnerId)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return transferred, nil
+}
+
+// transferEvents gives the events matching condition, whose parameters
+// start at $2, to ownerId and records an event.updated message for each of
+// them, as any other change of an event. It returns how many events changed
+// hands.
+func transferEvents(ctx context.Context, tx *sql.Tx, ownerId int, condition string, args ...any) (int, error) {
+	query := "UPDATE events SET owner_id = $1 WHERE " + condition +
+		" RETURNING id, owner_id, name, description, date, location"
+
+	rows, err := tx.QueryContext(ctx, query, append([]any{ownerId}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	events := []*Event{}
+
+	for rows.Next() {
+		var event Event
+
+		err := rows.Scan(&event.Id, &event.OwnerId, &event.Name,
+			&event.Description, &event.Date, &event.Location)
+		if err != nil {
+			return 0, err
+		}
+
+		events = append(events, &event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+	rows.Close()
+
+	for _, event := range events {
+		if err := insertOutboxMessage(ctx, tx, TopicEventUpdated, event); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(events), nil
 }
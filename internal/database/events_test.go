@@ -0,0 +1,110 @@
+package database
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// updatedEvents returns the events of the pending event.updated messages of
+// the outbox.
+func updatedEvents(t *testing.T, models Models) []Event {
+	t.Helper()
+
+	messages, err := models.Outbox.GetPending(time.Now().Add(time.Minute), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := []Event{}
+	for _, message := range messages {
+		if message.Topic != TopicEventUpdated {
+			continue
+		}
+
+		var event Event
+		if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+			t.Fatalf("decoding %s: %v", message.Payload, err)
+		}
+		events = append(events, event)
+	}
+
+	return events
+}
+
+func TestTransferWritesOutboxMessage(t *testing.T) {
+	models := newTestModels(t)
+
+	owner := insertTestUser(t, models, "owner@example.com")
+	newOwner := insertTestUser(t, models, "new-owner@example.com")
+	event := insertTestEvent(t, models, owner.Id)
+
+	if err := models.Events.Transfer(event.Id, newOwner.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	events := updatedEvents(t, models)
+	if len(events) != 1 || events[0].Id != event.Id || events[0].OwnerId != newOwner.Id {
+		t.Fatalf("event.updated messages = %+v, want event %d owned by %d", events, event.Id, newOwner.Id)
+	}
+	if events[0].Name != event.Name {
+		t.Errorf("message name = %q, want %q", events[0].Name, event.Name)
+	}
+
+	got, err := models.Events.Get(event.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.OwnerId != newOwner.Id {
+		t.Errorf("owner = %d, want %d", got.OwnerId, newOwner.Id)
+	}
+}
+
+func TestTransferAllWritesOutboxMessages(t *testing.T) {
+	models := newTestModels(t)
+
+	owner := insertTestUser(t, models, "owner@example.com")
+	newOwner := insertTestUser(t, models, "new-owner@example.com")
+	first := insertTestEvent(t, models, owner.Id)
+	second := insertTestEvent(t, models, owner.Id)
+	insertTestEvent(t, models, newOwner.Id)
+
+	transferred, err := models.Events.TransferAll(owner.Id, newOwner.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transferred != 2 {
+		t.Errorf("transferred %d events, want 2", transferred)
+	}
+
+	events := updatedEvents(t, models)
+	if len(events) != 2 {
+		t.Fatalf("event.updated messages = %+v, want 2", events)
+	}
+	for i, want := range []int{first.Id, second.Id} {
+		if events[i].Id != want || events[i].OwnerId != newOwner.Id {
+			t.Errorf("message %d = %+v, want event %d owned by %d", i, events[i], want, newOwner.Id)
+		}
+	}
+}
+
+func TestPurgeTransferringEventsWritesOutboxMessages(t *testing.T) {
+	models := newTestModels(t)
+
+	owner := insertTestUser(t, models, "owner@example.com")
+	newOwner := insertTestUser(t, models, "new-owner@example.com")
+	event := insertTestEvent(t, models, owner.Id)
+
+	transferred, err := models.Users.PurgeTransferringEvents(owner.Id, newOwner.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transferred != 1 {
+		t.Errorf("transferred %d events, want 1", transferred)
+	}
+
+	events := updatedEvents(t, models)
+	if len(events) != 1 || events[0].Id != event.Id || events[0].OwnerId != newOwner.Id {
+		t.Errorf("event.updated messages = %+v, want event %d owned by %d", events, event.Id, newOwner.Id)
+	}
+}
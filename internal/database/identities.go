@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type IdentityModel struct {
+	DB *sql.DB
+}
+
+// Identity links a user to their account at an external OpenID Connect
+// provider, identified by the issuer and the subject it assigns.
+type Identity struct {
+	Id          int       `json:"id"`
+	UserId      int       `json:"userId"`
+	Issuer      string    `json:"issuer"`
+	Subject     string    `json:"subject"`
+	Email       string    `json:"email"`
+	CreatedAt   time.Time `json:"createdAt"`
+	LastLoginAt time.Time `json:"lastLoginAt"`
+}
+
+func (m *IdentityModel) Insert(identity *Identity) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if identity.CreatedAt.IsZero() {
+		identity.CreatedAt = time.Now().UTC()
+	}
+	identity.LastLoginAt = identity.CreatedAt
+
+	query := `
+	  INSERT INTO user_identities (user_id, issuer, subject, email, created_at, last_login_at)
+	  VALUES ($1, $2, $3, $4, $5, $6)
+	  RETURNING id
+	`
+
+	return m.DB.QueryRowContext(ctx, query, identity.UserId, identity.Issuer, identity.Subject,
+		identity.Email, identity.CreatedAt, identity.LastLoginAt).Scan(&identity.Id)
+}
+
+func (m *IdentityModel) GetBySubject(issuer, subject string) (*Identity, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+	  SELECT id, user_id, issuer, subject, email, created_at, last_login_at
+	  FROM user_identities
+	  WHERE issuer = $1 AND subject = $2
+	`
+
+	var identity Identity
+
+	err := m.DB.QueryRowContext(ctx, query, issuer, subject).Scan(&identity.Id, &identity.UserId,
+		&identity.Issuer, &identity.Subject, &identity.Email, &identity.CreatedAt, &identity.LastLoginAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+func (m *IdentityModel) GetByUser(userId int) ([]*Identity, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+	  SELECT id, user_id, issuer, subject, email, created_at, last_login_at
+	  FROM user_identities
+	  WHERE user_id = $1
+	  ORDER BY id
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []*Identity{}
+
+	for rows.Next() {
+		var identity Identity
+
+		err := rows.Scan(&identity.Id, &identity.UserId, &identity.Issuer, &identity.Subject,
+			&identity.Email, &identity.CreatedAt, &identity.LastLoginAt)
+		if err != nil {
+			return nil, err
+		}
+
+		identities = append(identities, &identity)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+// RecordLogin updates the last login of an identity and the email the
+// provider currently has for it.
+func (m *IdentityModel) RecordLogin(id int, email string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, "UPDATE user_identities SET email = $1, last_login_at = $2 WHERE id = $3",
+		email, at.UTC(), id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type LoginAttemptModel struct {
+	DB *sql.DB
+}
+
+type LoginAttempt struct {
+	Id        int       `json:"id"`
+	Email     string    `json:"email"`
+	IPAddress string    `json:"ipAddress"`
+	Success   bool      `json:"success"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (m *LoginAttemptModel) Insert(attempt *LoginAttempt) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if attempt.CreatedAt.IsZero() {
+		attempt.CreatedAt = time.Now().UTC()
+	}
+
+	query := "INSERT INTO login_attempts (email, ip_address, success, created_at) VALUES ($1, $2, $3, $4) RETURNING id"
+
+	attempt.Email = NormalizeEmail(attempt.Email)
+
+	return m.DB.QueryRowContext(ctx, query, attempt.Email, attempt.IPAddress,
+		attempt.Success, attempt.CreatedAt).Scan(&attempt.Id)
+}
+
+// CountFailuresByIP returns the number of failed login attempts made from the
+// given IP address since the given time.
+func (m *LoginAttemptModel) CountFailuresByIP(ip string, since time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := "SELECT COUNT(*) FROM login_attempts WHERE ip_address = $1 AND success = 0 AND created_at > $2"
+
+	var count int
+	err := m.DB.QueryRowContext(ctx, query, ip, since.UTC()).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetByEmail returns the login attempts made with an email, oldest first.
+func (m *LoginAttemptModel) GetByEmail(email string) ([]*LoginAttempt, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := "SELECT id, email, ip_address, success, created_at FROM login_attempts WHERE email = $1 ORDER BY id"
+
+	rows, err := m.DB.QueryContext(ctx, query, NormalizeEmail(email))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := []*LoginAttempt{}
+
+	for rows.Next() {
+		var attempt LoginAttempt
+
+		err := rows.Scan(&attempt.Id, &attempt.Email, &attempt.IPAddress, &attempt.Success, &attempt.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		attempts = append(attempts, &attempt)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+// GetFailureStreak returns the number of failed login attempts made with an
+// email since its last successful one, and when the latest of them was made.
+// It stands in for the counters of UserModel for emails without an account.
+func (m *LoginAttemptModel) GetFailureStreak(email string) (int, *time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	streak := `
+	  FROM login_attempts
+	  WHERE email = $1 AND success = 0
+	    AND id > COALESCE((SELECT MAX(id) FROM login_attempts WHERE email = $1 AND success = 1), 0)
+	`
+
+	email = NormalizeEmail(email)
+
+	var count int
+	if err := m.DB.QueryRowContext(ctx, "SELECT COUNT(*) "+streak, email).Scan(&count); err != nil {
+		return 0, nil, err
+	}
+	if count == 0 {
+		return 0, nil, nil
+	}
+
+	var last time.Time
+	if err := m.DB.QueryRowContext(ctx, "SELECT created_at "+streak+" ORDER BY id DESC LIMIT 1", email).Scan(&last); err != nil {
+		return 0, nil, err
+	}
+
+	return count, &last, nil
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type MessageModel struct {
+	DB *sql.DB
+}
+
+// Message is a chat message posted by a user in an event's chat.
+type Message struct {
+	Id        int       `json:"id"`
+	EventId   int       `json:"eventId"`
+	UserId    int       `json:"userId"`
+	UserName  string    `json:"userName"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (m *MessageModel) Insert(message *Message) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if message.CreatedAt.IsZero() {
+		message.CreatedAt = time.Now().UTC()
+	}
+
+	query := "INSERT INTO event_messages (event_id, user_id, body, created_at) VALUES ($1, $2, $3, $4) RETURNING id"
+
+	return m.DB.QueryRowContext(ctx, query, message.EventId, message.UserId,
+		message.Body, message.CreatedAt).Scan(&message.Id)
+}
+
+// GetBefore returns up to limit messages of an event older than the message
+// with id beforeId (or the latest ones if beforeId is zero), newest first.
+func (m *MessageModel) GetBefore(eventId, beforeId, limit int) ([]*Message, error) {
+	query := `
+	  SELECT m.id, m.event_id, m.user_id, u.name, m.body, m.created_at
+	  FROM event_messages m
+	  JOIN users u ON u.id = m.user_id
+	  WHERE m.event_id = $1 AND ($2 = 0 OR m.id < $2)
+	  ORDER BY m.id DESC
+	  LIMIT $3
+	`
+
+	return m.getMessages(query, eventId, beforeId, limit)
+}
+
+// GetAfter returns the latest messages of an event newer than the message
+// with id afterId, up to limit of them, oldest first. Clients use it to catch
+// up after reconnecting.
+func (m *MessageModel) GetAfter(eventId, afterId, limit int) ([]*Message, error) {
+	query := `
+	  SELECT * FROM (
+	    SELECT m.id, m.event_id, m.user_id, u.name, m.body, m.created_at
+	    FROM event_messages m
+	    JOIN users u ON u.id = m.user_id
+	    WHERE m.event_id = $1 AND m.id > $2
+	    ORDER BY m.id DESC
+	    LIMIT $3
+	  ) ORDER BY id
+	`
+
+	return m.getMessages(query, eventId, afterId, limit)
+}
+
+// GetByUser returns every message a user posted, oldest first.
+func (m *MessageModel) GetByUser(userId int) ([]*Message, error) {
+	query := `
+	  SELECT m.id, m.event_id, m.user_id, u.name, m.body, m.created_at
+	  FROM event_messages m
+	  JOIN users u ON u.id = m.user_id
+	  WHERE m.user_id = $1
+	  ORDER BY m.id
+	`
+
+	return m.getMessages(query, userId)
+}
+
+func (m *MessageModel) getMessages(query string, args ...any) ([]*Message, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []*Message{}
+
+	for rows.Next() {
+		var message Message
+
+		err := rows.Scan(&message.Id, &message.EventId, &message.UserId,
+			&message.UserName, &message.Body, &message.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, &message)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
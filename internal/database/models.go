@@ -1,17 +1,54 @@
 package database
 
-import "database/sql"
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+)
 
 type Models struct {
-	Users     UserModel
-	Events    EventModel
-	Attendees AttendeeModel
+	Users             UserModel
+	Events            EventModel
+	Attendees         AttendeeModel
+	LoginAttempts     LoginAttemptModel
+	PasswordResets    PasswordResetModel
+	Reminders         ReminderModel
+	Webhooks          WebhookModel
+	WebhookDeliveries WebhookDeliveryModel
+	Outbox            OutboxModel
+	Messages          MessageModel
+	APIKeys           APIKeyModel
+	Identities        IdentityModel
+	TwoFactor         TwoFactorModel
 }
 
 func NewModels(db *sql.DB) Models {
 	return Models{
-		Users:     UserModel{DB: db},
-		Events:    EventModel{DB: db},
-		Attendees: AttendeeModel{DB: db},
+		Users:             UserModel{DB: db},
+		Events:            EventModel{DB: db},
+		Attendees:         AttendeeModel{DB: db},
+		LoginAttempts:     LoginAttemptModel{DB: db},
+		PasswordResets:    PasswordResetModel{DB: db},
+		Reminders:         ReminderModel{DB: db},
+		Webhooks:          WebhookModel{DB: db},
+		WebhookDeliveries: WebhookDeliveryModel{DB: db},
+		Outbox:            OutboxModel{DB: db},
+		Messages:          MessageModel{DB: db},
+		APIKeys:           APIKeyModel{DB: db},
+		Identities:        IdentityModel{DB: db},
+		TwoFactor:         TwoFactorModel{DB: db},
 	}
 }
+
+// inList returns the placeholders and arguments of an "IN (...)" list for ids.
+func inList(ids []int) (string, []any) {
+	placeholders := make([]string, len(ids))
+	args := make([]any, len(ids))
+
+	for i, id := range ids {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+		args[i] = id
+	}
+
+	return strings.Join(placeholders, ", "), args
+}
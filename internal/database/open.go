@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options tunes the connections opened by Open. Zero values use the
+// defaults.
+type Options struct {
+	// BusyTimeout is how long a connection waits for another one to release
+	// the write lock before failing with "database is locked".
+	BusyTimeout time.Duration
+	// MaxOpenConns limits the connections of the pool. WAL lets readers work
+	// alongside the single writer, so a few connections are enough, and more
+	// would only wait on the write lock.
+	MaxOpenConns int
+}
+
+const (
+	defaultBusyTimeout  = 5 * time.Second
+	defaultMaxOpenConns = 8
+)
+
+// Open opens the SQLite database at path with the settings the models rely
+// on, applied by the driver to every connection of the pool:
+//
+//   - foreign keys are enforced, so ON DELETE CASCADE removes dependent rows;
+//   - the journal is a write-ahead log, so reads don't block on writes;
+//   - writers wait up to the busy timeout for the lock instead of failing;
+//   - synchronous is NORMAL, which is safe with WAL and avoids an fsync per
+//     transaction;
+//   - transactions take the write lock when they begin, so that two
+//     transactions reading before writing can't deadlock.
+//
+// The connection is checked before Open returns.
+func Open(path string, options Options) (*sql.DB, error) {
+	if options.BusyTimeout <= 0 {
+		options.BusyTimeout = defaultBusyTimeout
+	}
+	if options.MaxOpenConns <= 0 {
+		options.MaxOpenConns = defaultMaxOpenConns
+	}
+
+	params := url.Values{
+		"_foreign_keys": {"on"},
+		"_journal_mode": {"WAL"},
+		"_busy_timeout": {strconv.FormatInt(options.BusyTimeout.Milliseconds(), 10)},
+		"_synchronous":  {"NORMAL"},
+		"_txlock":       {"immediate"},
+	}
+
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+
+	db, err := sql.Open("sqlite3", path+separator+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(options.MaxOpenConns)
+	// Idle connections are kept: closing the last one checkpoints the WAL,
+	// and reopening one runs the pragmas again.
+	db.SetMaxIdleConns(options.MaxOpenConns)
+	db.SetConnMaxIdleTime(0)
+	db.SetConnMaxLifetime(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var foreignKeys bool
+	if err := db.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+
+	if !foreignKeys {
+		db.Close()
+		return nil, errors.New("opening " + path + ": foreign keys can't be enabled")
+	}
+
+	return db, nil
+}
@@ -0,0 +1,200 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenPragmas(t *testing.T) {
+	models := newTestModels(t)
+	db := models.Users.DB
+
+	var foreignKeys int
+	if err := db.QueryRow("PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+		t.Fatal(err)
+	}
+	if foreignKeys != 1 {
+		t.Errorf("foreign_keys = %d, want 1", foreignKeys)
+	}
+
+	var journalMode string
+	if err := db.QueryRow("PRAGMA journal_mode").Scan(&journalMode); err != nil {
+		t.Fatal(err)
+	}
+	if journalMode != "wal" {
+		t.Errorf("journal_mode = %q, want wal", journalMode)
+	}
+
+	var busyTimeout int
+	if err := db.QueryRow("PRAGMA busy_timeout").Scan(&busyTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if want := int(defaultBusyTimeout.Milliseconds()); busyTimeout != want {
+		t.Errorf("busy_timeout = %d, want %d", busyTimeout, want)
+	}
+}
+
+// TestOpenPragmasOnEveryConnection holds several connections at once, so
+// that the pool has to open new ones, and checks the pragmas on each.
+func TestOpenPragmasOnEveryConnection(t *testing.T) {
+	db := newTestModels(t).Users.DB
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	for i := 0; i < defaultMaxOpenConns; i++ {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		var foreignKeys int
+		if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+			t.Fatal(err)
+		}
+		if foreignKeys != 1 {
+			t.Errorf("connection %d: foreign_keys = %d, want 1", i, foreignKeys)
+		}
+	}
+}
+
+func TestOpenKeepsQueryParameters(t *testing.T) {
+	db, err := Open("file:"+t.TempDir()+"/data.db?cache=private", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var journalMode string
+	if err := db.QueryRow("PRAGMA journal_mode").Scan(&journalMode); err != nil {
+		t.Fatal(err)
+	}
+	if journalMode != "wal" {
+		t.Errorf("journal_mode = %q, want wal", journalMode)
+	}
+}
+
+// countRows returns the number of rows of table matching where.
+func countRows(t *testing.T, models Models, table, where string, args ...any) int {
+	t.Helper()
+
+	var count int
+	query := "SELECT count(*) FROM " + table + " WHERE " + where
+	if err := models.Users.DB.QueryRow(query, args...).Scan(&count); err != nil {
+		t.Fatalf("counting %s: %v", table, err)
+	}
+
+	return count
+}
+
+func TestDeletingUserCascades(t *testing.T) {
+	models := newTestModels(t)
+
+	owner := insertTestUser(t, models, "owner@example.com")
+	attendee := insertTestUser(t, models, "attendee@example.com")
+
+	ownEvent := insertTestEvent(t, models, owner.Id)
+	otherEvent := insertTestEvent(t, models, attendee.Id)
+
+	if _, err := models.Attendees.Insert(&Attendee{EventId: otherEvent.Id, UserId: owner.Id}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := models.Attendees.Insert(&Attendee{EventId: ownEvent.Id, UserId: attendee.Id}); err != nil {
+		t.Fatal(err)
+	}
+	if err := models.Messages.Insert(&Message{EventId: otherEvent.Id, UserId: owner.Id, Body: "Hello"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := models.Messages.Insert(&Message{EventId: ownEvent.Id, UserId: attendee.Id, Body: "Hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := models.APIKeys.Insert(&APIKey{UserId: owner.Id, Name: "ci", Prefix: "abc", KeyHash: "hash", Scopes: []string{"events:read"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := models.Webhooks.Insert(&Webhook{UserId: owner.Id, URL: "https://example.com/hook", Secret: strings.Repeat("s", 16), EventTypes: []string{"event.created"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := models.Identities.Insert(&Identity{UserId: owner.Id, Issuer: "https://issuer.example.com", Subject: "123", Email: owner.Email}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A plain DELETE, so that only the foreign keys remove the rest.
+	if _, err := models.Users.DB.Exec("DELETE FROM users WHERE id = $1", owner.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		table string
+		where string
+		args  []any
+	}{
+		{"events", "owner_id = $1", []any{owner.Id}},
+		{"attendees", "user_id = $1 OR event_id = $2", []any{owner.Id, ownEvent.Id}},
+		{"event_messages", "user_id = $1 OR event_id = $2", []any{owner.Id, ownEvent.Id}},
+		{"api_keys", "user_id = $1", []any{owner.Id}},
+		{"webhooks", "user_id = $1", []any{owner.Id}},
+		{"user_identities", "user_id = $1", []any{owner.Id}},
+	}
+
+	for _, tt := range tests {
+		if count := countRows(t, models, tt.table, tt.where, tt.args...); count != 0 {
+			t.Errorf("%s: %d rows left, want 0", tt.table, count)
+		}
+	}
+
+	if count := countRows(t, models, "events", "id = $1", otherEvent.Id); count != 1 {
+		t.Errorf("event of another user: %d rows, want 1", count)
+	}
+	if count := countRows(t, models, "users", "id = $1", attendee.Id); count != 1 {
+		t.Errorf("other user: %d rows, want 1", count)
+	}
+}
+
+func TestDeletingEventCascades(t *testing.T) {
+	models := newTestModels(t)
+
+	owner := insertTestUser(t, models, "owner@example.com")
+	attendee := insertTestUser(t, models, "attendee@example.com")
+
+	event := insertTestEvent(t, models, owner.Id)
+	other := insertTestEvent(t, models, owner.Id)
+
+	for _, eventId := range []int{event.Id, other.Id} {
+		if _, err := models.Attendees.Insert(&Attendee{EventId: eventId, UserId: attendee.Id}); err != nil {
+			t.Fatal(err)
+		}
+		if err := models.Messages.Insert(&Message{EventId: eventId, UserId: attendee.Id, Body: "Hello"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := models.Events.Delete(event.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range []string{"attendees", "event_messages"} {
+		if count := countRows(t, models, table, "event_id = $1", event.Id); count != 0 {
+			t.Errorf("%s of the deleted event: %d rows left, want 0", table, count)
+		}
+		if count := countRows(t, models, table, "event_id = $1", other.Id); count != 1 {
+			t.Errorf("%s of another event: %d rows, want 1", table, count)
+		}
+	}
+}
+
+func TestForeignKeysRejectOrphans(t *testing.T) {
+	models := newTestModels(t)
+
+	owner := insertTestUser(t, models, "owner@example.com")
+	event := insertTestEvent(t, models, owner.Id)
+
+	if _, err := models.Attendees.Insert(&Attendee{EventId: event.Id, UserId: owner.Id + 100}); err == nil {
+		t.Error("attendee of a missing user was inserted")
+	}
+	if err := models.Events.Insert(&Event{OwnerId: owner.Id + 100, Name: "Orphan", Description: "An event without owner", Date: time.Now(), Location: "Nowhere"}); err == nil {
+		t.Error("event of a missing user was inserted")
+	}
+}
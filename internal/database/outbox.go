@@ -0,0 +1,201 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Topics of the messages written to the outbox. They double as the event types
+// webhooks can subscribe to.
+const (
+	TopicEventCreated    = "event.created"
+	TopicEventUpdated    = "event.updated"
+	TopicEventDeleted    = "event.deleted"
+	TopicAttendeeAdded   = "attendee.added"
+	TopicAttendeeRemoved = "attendee.removed"
+)
+
+// TopicNotification is the topic of the messages sending a single
+// notification, queued by the handlers of the topics above so that a failed
+// delivery is only retried for its recipient. Webhooks can't subscribe to it.
+const TopicNotification = "notification"
+
+// DeletedEvent is the payload of TopicEventDeleted. Attendees are removed
+// together with the event, so their ids are kept to be able to notify them.
+type DeletedEvent struct {
+	*Event
+	AttendeeIds []int `json:"attendeeIds"`
+}
+
+type OutboxModel struct {
+	DB *sql.DB
+}
+
+// OutboxMessage records a change that still has side effects to trigger, such
+// as notifications or webhooks. It is written in the same transaction as the
+// change itself so neither can be lost without the other.
+type OutboxMessage struct {
+	Id            int        `json:"id"`
+	Topic         string     `json:"topic"`
+	Payload       string     `json:"payload"`
+	Attempts      int        `json:"attempts"`
+	Completed     []string   `json:"completed"`
+	LastError     *string    `json:"lastError"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	ProcessedAt   *time.Time `json:"processedAt"`
+	// FailedAt is set when the dispatcher gave up on the message after too
+	// many attempts. Such messages stay in the outbox to be looked into.
+	FailedAt *time.Time `json:"failedAt"`
+}
+
+// insertOutboxMessage adds a message to the outbox as part of tx.
+func insertOutboxMessage(ctx context.Context, tx *sql.Tx, topic string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	query := "INSERT INTO outbox (topic, payload, next_attempt_at, created_at) VALUES ($1, $2, $3, $4)"
+
+	_, err = tx.ExecContext(ctx, query, topic, string(body), now, now)
+
+	return err
+}
+
+// GetPending returns messages that haven't been processed nor given up on yet
+// and are due, oldest first.
+func (m *OutboxModel) GetPending(now time.Time, limit int) ([]*OutboxMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+	  SELECT id, topic, payload, attempts, completed_handlers, last_error, next_attempt_at, created_at, processed_at, failed_at
+	  FROM outbox
+	  WHERE processed_at IS NULL AND failed_at IS NULL AND next_attempt_at <= $1
+	  ORDER BY id
+	  LIMIT $2
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, now.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []*OutboxMessage{}
+
+	for rows.Next() {
+		var msg OutboxMessage
+		var completed string
+
+		err := rows.Scan(&msg.Id, &msg.Topic, &msg.Payload, &msg.Attempts, &completed, &msg.LastError,
+			&msg.NextAttemptAt, &msg.CreatedAt, &msg.ProcessedAt, &msg.FailedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if completed != "" {
+			msg.Completed = strings.Split(completed, ",")
+		}
+
+		messages = append(messages, &msg)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// Insert adds one message per payload to the outbox, in a single
+// transaction.
+func (m *OutboxModel) Insert(topic string, payloads ...any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, payload := range payloads {
+		if err := insertOutboxMessage(ctx, tx, topic, payload); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (m *OutboxModel) MarkProcessed(id int, at time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := "UPDATE outbox SET processed_at = $1, attempts = attempts + 1, last_error = NULL WHERE id = $2"
+
+	_, err := m.DB.ExecContext(ctx, query, at.UTC(), id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed attempt, the handlers that already succeeded and
+// when the message should be retried.
+func (m *OutboxModel) MarkFailed(id int, completed []string, lastError string, nextAttemptAt time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+	  UPDATE outbox SET attempts = attempts + 1, completed_handlers = $1, last_error = $2, next_attempt_at = $3
+	  WHERE id = $4
+	`
+
+	_, err := m.DB.ExecContext(ctx, query, strings.Join(completed, ","), lastError, nextAttemptAt.UTC(), id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// MarkDead records a last failed attempt, after which the message is no
+// longer retried.
+func (m *OutboxModel) MarkDead(id int, completed []string, lastError string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+	  UPDATE outbox SET attempts = attempts + 1, completed_handlers = $1, last_error = $2, failed_at = $3
+	  WHERE id = $4
+	`
+
+	_, err := m.DB.ExecContext(ctx, query, strings.Join(completed, ","), lastError, at.UTC(), id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteProcessedBefore deletes the messages processed before the given time
+// and returns how many there were.
+func (m *OutboxModel) DeleteProcessedBefore(before time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, "DELETE FROM outbox WHERE processed_at < $1", before.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
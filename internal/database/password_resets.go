@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrInvalidToken is returned when a one-time token does not exist, has
+// expired or has already been used.
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+type PasswordResetModel struct {
+	DB *sql.DB
+}
+
+type PasswordReset struct {
+	Id        int        `json:"id"`
+	UserId    int        `json:"userId"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// Insert stores a new reset token for a user, discarding any token the user
+// requested before that was never used.
+func (m *PasswordResetModel) Insert(reset *PasswordReset) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM password_resets WHERE user_id = $1 AND used_at IS NULL", reset.UserId)
+	if err != nil {
+		return err
+	}
+
+	if reset.CreatedAt.IsZero() {
+		reset.CreatedAt = time.Now().UTC()
+	}
+
+	query := "INSERT INTO password_resets (user_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4) RETURNING id"
+	err = tx.QueryRowContext(ctx, query, reset.UserId, reset.TokenHash,
+		reset.ExpiresAt.UTC(), reset.CreatedAt).Scan(&reset.Id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ResetPassword consumes the reset token with the given hash and replaces the
+// password of its user. The user's token version is bumped and their API keys
+// are deleted, so every session and key issued before the reset stops
+// working. It returns the id of the user.
+func (m *PasswordResetModel) ResetPassword(tokenHash, passwordHash string, now time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+	  UPDATE password_resets SET used_at = $1
+	  WHERE token_hash = $2 AND used_at IS NULL AND expires_at > $1
+	  RETURNING user_id
+	`
+
+	var userId int
+	err = tx.QueryRowContext(ctx, query, now.UTC(), tokenHash).Scan(&userId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrInvalidToken
+		}
+
+		return 0, err
+	}
+
+	if err := resetPassword(ctx, tx, userId, passwordHash); err != nil {
+		return 0, err
+	}
+
+	return userId, tx.Commit()
+}
+
+// resetPassword replaces the password of a user as part of tx like
+// UserModel.SetPassword, and also deletes their API keys: a reset is how an
+// account that may be compromised is recovered, and keys would outlive it.
+func resetPassword(ctx context.Context, tx *sql.Tx, id int, passwordHash string) error {
+	query := `
+	  UPDATE users SET password = $1, token_version = token_version + 1,
+	    failed_logins = 0, last_failed_login_at = NULL, locked_until = NULL
+	  WHERE id = $2
+	`
+	if _, err := tx.ExecContext(ctx, query, passwordHash, id); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, "DELETE FROM api_keys WHERE user_id = $1", id)
+
+	return err
+}
+
+// InsertRequest records a request for a password reset, whether or not the
+// email has an account, to throttle them.
+func (m *PasswordResetModel) InsertRequest(email, ip string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := "INSERT INTO password_reset_requests (email, ip_address, created_at) VALUES ($1, $2, $3)"
+
+	_, err := m.DB.ExecContext(ctx, query, NormalizeEmail(email), ip, at.UTC())
+
+	return err
+}
+
+// CountRequestsByIP returns the number of password resets requested from the
+// given IP address since the given time.
+func (m *PasswordResetModel) CountRequestsByIP(ip string, since time.Time) (int, error) {
+	return m.countRequests("ip_address", ip, since)
+}
+
+// CountRequestsByEmail returns the number of password resets requested for
+// the given email since the given time.
+func (m *PasswordResetModel) CountRequestsByEmail(email string, since time.Time) (int, error) {
+	return m.countRequests("email", NormalizeEmail(email), since)
+}
+
+func (m *PasswordResetModel) countRequests(column, value string, since time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := "SELECT COUNT(*) FROM password_reset_requests WHERE " + column + " = $1 AND created_at > $2"
+
+	var count int
+	if err := m.DB.QueryRowContext(ctx, query, value, since.UTC()).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func insertTestAPIKey(t *testing.T, models Models, userId int) *APIKey {
+	t.Helper()
+
+	key := &APIKey{UserId: userId, Name: "ci", Prefix: "evk_test", KeyHash: time.Now().String(), Scopes: []string{"events:read"}}
+	if err := models.APIKeys.Insert(key); err != nil {
+		t.Fatalf("inserting API key: %v", err)
+	}
+
+	return key
+}
+
+func TestPasswordResetsRevokeAPIKeys(t *testing.T) {
+	models := newTestModels(t)
+
+	tests := []struct {
+		name     string
+		set      func(user *User) error
+		keepKeys bool
+	}{
+		{
+			name: "reset with a token",
+			set: func(user *User) error {
+				reset := &PasswordReset{UserId: user.Id, TokenHash: "token-hash", ExpiresAt: time.Now().Add(time.Hour)}
+				if err := models.PasswordResets.Insert(reset); err != nil {
+					return err
+				}
+
+				_, err := models.PasswordResets.ResetPassword("token-hash", "new-hash", time.Now())
+				return err
+			},
+		},
+		{
+			name: "reset by an administrator",
+			set: func(user *User) error {
+				return models.Users.ResetPassword(user.Id, "new-hash")
+			},
+		},
+		{
+			name: "change by the user",
+			set: func(user *User) error {
+				return models.Users.SetPassword(user.Id, "new-hash")
+			},
+			keepKeys: true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := insertTestUser(t, models, fmt.Sprintf("user%d@example.com", i))
+			key := insertTestAPIKey(t, models, user.Id)
+
+			if err := tt.set(user); err != nil {
+				t.Fatal(err)
+			}
+
+			updated, err := models.Users.Get(user.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if updated.Password != "new-hash" || updated.TokenVersion != user.TokenVersion+1 {
+				t.Errorf("password %q, token version %d: want new-hash, %d", updated.Password, updated.TokenVersion, user.TokenVersion+1)
+			}
+
+			got, err := models.APIKeys.Get(key.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kept := got != nil; kept != tt.keepKeys {
+				t.Errorf("API key kept = %v, want %v", kept, tt.keepKeys)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type ReminderModel struct {
+	DB *sql.DB
+}
+
+type Reminder struct {
+	Id            int       `json:"id"`
+	EventId       int       `json:"eventId"`
+	UserId        int       `json:"userId"`
+	OffsetMinutes int       `json:"offsetMinutes"`
+	SentAt        time.Time `json:"sentAt"`
+}
+
+// Claim records that the reminder sent the given offset before an event has
+// been handled for an attendee. It returns false if it was already claimed,
+// which guarantees each reminder goes out at most once, even across restarts.
+func (m *ReminderModel) Claim(eventId, userId int, offset time.Duration, at time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+	  INSERT INTO event_reminders (event_id, user_id, offset_minutes, sent_at)
+	  VALUES ($1, $2, $3, $4)
+	  ON CONFLICT (event_id, user_id, offset_minutes) DO NOTHING
+	`
+
+	result, err := m.DB.ExecContext(ctx, query, eventId, userId, int(offset.Minutes()), at.UTC())
+	if err != nil {
+		return false, err
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return inserted == 1, nil
+}
+
+// Release removes a claim so the reminder is retried, e.g. after it could not
+// be delivered.
+func (m *ReminderModel) Release(eventId, userId int, offset time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := "DELETE FROM event_reminders WHERE event_id = $1 AND user_id = $2 AND offset_minutes = $3"
+
+	_, err := m.DB.ExecContext(ctx, query, eventId, userId, int(offset.Minutes()))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrNoEnrollment is returned when confirming a two-factor enrollment that
+// was never started or is already confirmed.
+var ErrNoEnrollment = errors.New("no pending two-factor enrollment")
+
+type TwoFactorModel struct {
+	DB *sql.DB
+}
+
+// TwoFactor is the TOTP secret of a user. It only protects logins once
+// enrollment is confirmed with a first code, which sets EnabledAt.
+type TwoFactor struct {
+	UserId int
+	Secret string
+	// LastCounter is the time step of the last accepted code, so that a
+	// code can't be used twice.
+	LastCounter int64
+	EnabledAt   *time.Time
+	CreatedAt   time.Time
+}
+
+func (t *TwoFactor) IsEnabled() bool {
+	return t != nil && t.EnabledAt != nil
+}
+
+func (m *TwoFactorModel) Get(userId int) (*TwoFactor, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := "SELECT user_id, secret, last_counter, enabled_at, created_at FROM two_factor WHERE user_id = $1"
+
+	var twoFactor TwoFactor
+
+	err := m.DB.QueryRowContext(ctx, query, userId).Scan(&twoFactor.UserId, &twoFactor.Secret,
+		&twoFactor.LastCounter, &twoFactor.EnabledAt, &twoFactor.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &twoFactor, nil
+}
+
+// Begin stores the secret of an enrollment, replacing any enrollment that
+// wasn't confirmed. It doesn't touch an enabled secret.
+func (m *TwoFactorModel) Begin(userId int, secret string, now time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+	  INSERT INTO two_factor (user_id, secret, created_at) VALUES ($1, $2, $3)
+	  ON CONFLICT (user_id) DO UPDATE SET secret = excluded.secret, last_counter = 0,
+	    created_at = excluded.created_at
+	  WHERE two_factor.enabled_at IS NULL
+	`
+
+	_, err := m.DB.ExecContext(ctx, query, userId, secret, now.UTC())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Enable confirms an enrollment with the counter of its first code and
+// replaces the recovery codes of the user.
+func (m *TwoFactorModel) Enable(userId int, counter int64, recoveryCodeHashes []string, now time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE two_factor SET enabled_at = $1, last_counter = $2 WHERE user_id = $3 AND enabled_at IS NULL"
+
+	result, err := tx.ExecContext(ctx, query, now.UTC(), counter, userId)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return ErrNoEnrollment
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userId, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UseCounter records that the code of a time step was accepted. It returns
+// false when that code, or a later one, was already used.
+func (m *TwoFactorModel) UseCounter(userId int, counter int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := "UPDATE two_factor SET last_counter = $1 WHERE user_id = $2 AND last_counter < $1"
+
+	result, err := m.DB.ExecContext(ctx, query, counter, userId)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+
+	return n == 1, err
+}
+
+// UseRecoveryCode spends one of the user's recovery codes. It returns false
+// when no unused code has that hash.
+func (m *TwoFactorModel) UseRecoveryCode(userId int, codeHash string, now time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+	  UPDATE recovery_codes SET used_at = $1
+	  WHERE id = (
+	    SELECT id FROM recovery_codes WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL LIMIT 1
+	  )
+	`
+
+	result, err := m.DB.ExecContext(ctx, query, now.UTC(), userId, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+
+	return n == 1, err
+}
+
+// CountRecoveryCodes returns how many recovery codes the user has left.
+func (m *TwoFactorModel) CountRecoveryCodes(userId int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var count int
+
+	query := "SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL"
+
+	err := m.DB.QueryRowContext(ctx, query, userId).Scan(&count)
+
+	return count, err
+}
+
+// ReplaceRecoveryCodes invalidates the recovery codes of the user in favor of
+// new ones.
+func (m *TwoFactorModel) ReplaceRecoveryCodes(userId int, codeHashes []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userId, codeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userId int, codeHashes []string) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = $1", userId)
+	if err != nil {
+		return err
+	}
+
+	for _, codeHash := range codeHashes {
+		_, err := tx.ExecContext(ctx, "INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)",
+			userId, codeHash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Disable removes the secret and recovery codes of the user.
+func (m *TwoFactorModel) Disable(userId int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"DELETE FROM recovery_codes WHERE user_id = $1",
+		"DELETE FROM two_factor WHERE user_id = $1",
+	} {
+		if _, err := tx.ExecContext(ctx, query, userId); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
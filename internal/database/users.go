@@ -3,7 +3,20 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"strings"
 	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// ErrDuplicateEmail is returned when saving a user with the email of another
+// user.
+var ErrDuplicateEmail = errors.New("email is already in use")
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
 )
 
 type UserModel struct {
@@ -11,19 +24,60 @@ type UserModel struct {
 }
 
 type User struct {
-	Id       int    `json:"id"`
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"-"`
+	Id                int        `json:"id"`
+	Name              string     `json:"name"`
+	Email             string     `json:"email"`
+	Password          string     `json:"-"`
+	Role              string     `json:"role"`
+	EmailVerified     bool       `json:"emailVerified"`
+	FailedLogins      int        `json:"-"`
+	LastFailedLoginAt *time.Time `json:"-"`
+	LockedUntil       *time.Time `json:"-"`
+	TokenVersion      int        `json:"-"`
+	DisabledAt        *time.Time `json:"-"`
 }
 
+// NormalizeEmail lower-cases the ASCII letters of an email, like SQLite's
+// lower(), so that addresses differing only in case belong to one account.
+// The models apply it to every email they store or look up.
+func NormalizeEmail(email string) string {
+	return strings.Map(func(r rune) rune {
+		if 'A' <= r && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+
+		return r
+	}, email)
+}
+
+const userColumns = "id, name, email, password, role, email_verified, failed_logins, last_failed_login_at, locked_until, token_version, disabled_at"
+
 func (m *UserModel) Insert(user *User) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := "INSERT INTO users (email, name, password) VALUES ($1, $2, $3) RETURNING id"
+	if user.Role == "" {
+		user.Role = RoleUser
+	}
+
+	user.Email = NormalizeEmail(user.Email)
+
+	query := "INSERT INTO users (email, name, password, role) VALUES ($1, $2, $3, $4) RETURNING id"
 
-	return m.DB.QueryRowContext(ctx, query, user.Email, user.Name, user.Password).Scan(&user.Id)
+	err := m.DB.QueryRowContext(ctx, query, user.Email, user.Name, user.Password, user.Role).Scan(&user.Id)
+	if isUniqueViolation(err) {
+		return ErrDuplicateEmail
+	}
+
+	return err
+}
+
+// isUniqueViolation reports whether err comes from a UNIQUE constraint, the
+// only one on users being the email.
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
 }
 
 func (m *UserModel) getUser(query string, args ...interface{}) (*User, error) {
@@ -31,7 +85,8 @@ func (m *UserModel) getUser(query string, args ...interface{}) (*User, error) {
 	defer cancel()
 
 	var user User
-	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&user.Id, &user.Name, &user.Email, &user.Password)
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&user.Id, &user.Name, &user.Email, &user.Password,
+		&user.Role, &user.EmailVerified, &user.FailedLogins, &user.LastFailedLoginAt, &user.LockedUntil, &user.TokenVersion, &user.DisabledAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -44,13 +99,391 @@ func (m *UserModel) getUser(query string, args ...interface{}) (*User, error) {
 }
 
 func (m *UserModel) Get(id int) (*User, error) {
-	query := "SELECT * FROM users WHERE id = $1"
+	query := "SELECT " + userColumns + " FROM users WHERE id = $1"
 
 	return m.getUser(query, id)
 }
 
 func (m *UserModel) GetByEmail(email string) (*User, error) {
-	query := "SELECT * FROM users WHERE email = $1"
+	query := "SELECT " + userColumns + " FROM users WHERE email = $1"
+
+	return m.getUser(query, NormalizeEmail(email))
+}
+
+// GetAll returns every user, ordered by id.
+func (m *UserModel) GetAll() ([]*User, error) {
+	query := "SELECT " + userColumns + " FROM users ORDER BY id"
+
+	return m.getUsers(query)
+}
+
+// GetByIds returns the users with the given ids, in no particular order.
+func (m *UserModel) GetByIds(ids []int) ([]*User, error) {
+	placeholders, args := inList(ids)
+	query := "SELECT " + userColumns + " FROM users WHERE id IN (" + placeholders + ")"
+
+	return m.getUsers(query, args...)
+}
+
+func (m *UserModel) getUsers(query string, args ...any) ([]*User, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*User{}
+
+	for rows.Next() {
+		var user User
+
+		err := rows.Scan(&user.Id, &user.Name, &user.Email, &user.Password, &user.Role, &user.EmailVerified,
+			&user.FailedLogins, &user.LastFailedLoginAt, &user.LockedUntil, &user.TokenVersion, &user.DisabledAt)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, &user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// IsLocked reports whether the account is locked out at the given time.
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// IsDisabled reports whether an administrator has disabled the account.
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
+}
+
+// RecordFailedLogin increments the consecutive failed login counter of a user
+// and returns the new count.
+func (m *UserModel) RecordFailedLogin(id int, at time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+	  UPDATE users SET failed_logins = failed_logins + 1, last_failed_login_at = $1
+	  WHERE id = $2
+	  RETURNING failed_logins
+	`
+
+	var failedLogins int
+	err := m.DB.QueryRowContext(ctx, query, at, id).Scan(&failedLogins)
+	if err != nil {
+		return 0, err
+	}
+
+	return failedLogins, nil
+}
+
+func (m *UserModel) Lock(id int, until time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := "UPDATE users SET locked_until = $1 WHERE id = $2"
+
+	_, err := m.DB.ExecContext(ctx, query, until, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ResetLoginFailures clears the failed login counter and any lockout, either
+// after a successful login or when an administrator unlocks the account.
+func (m *UserModel) ResetLoginFailures(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := "UPDATE users SET failed_logins = 0, last_failed_login_at = NULL, locked_until = NULL WHERE id = $1"
+
+	_, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *UserModel) MarkEmailVerified(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := "UPDATE users SET email_verified = 1 WHERE id = $1"
+
+	_, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Disable prevents a user from logging in and revokes every session issued
+// so far.
+func (m *UserModel) Disable(id int, at time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := "UPDATE users SET disabled_at = $1, token_version = token_version + 1 WHERE id = $2"
+
+	_, err := m.DB.ExecContext(ctx, query, at.UTC(), id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *UserModel) Enable(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := "UPDATE users SET disabled_at = NULL WHERE id = $1"
+
+	_, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetPassword replaces the password of a user, lifting any lockout. Like a
+// password reset, it revokes every session issued so far, but it keeps the
+// user's API keys: it is used by users who know their current password.
+func (m *UserModel) SetPassword(id int, passwordHash string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+	  UPDATE users SET password = $1, token_version = token_version + 1,
+	    failed_logins = 0, last_failed_login_at = NULL, locked_until = NULL
+	  WHERE id = $2
+	`
+
+	_, err := m.DB.ExecContext(ctx, query, passwordHash, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ResetPassword replaces the password of a user like SetPassword, and also
+// deletes their API keys, for an administrator recovering the account.
+func (m *UserModel) ResetPassword(id int, passwordHash string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := resetPassword(ctx, tx, id, passwordHash); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *UserModel) SetName(id int, name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := "UPDATE users SET name = $1 WHERE id = $2"
+
+	_, err := m.DB.ExecContext(ctx, query, name, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetEmail changes the email of a user, who has to verify the new address.
+func (m *UserModel) SetEmail(id int, email string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE users SET email = $1, email_verified = 0 WHERE id = $2"
+
+	_, err = tx.ExecContext(ctx, query, NormalizeEmail(email), id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicateEmail
+		}
+
+		return err
+	}
+
+	// Reset links were sent to the previous address, which may no longer
+	// belong to the user.
+	_, err = tx.ExecContext(ctx, "DELETE FROM password_resets WHERE user_id = $1 AND used_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *UserModel) SetRole(id int, role string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := "UPDATE users SET role = $1 WHERE id = $2"
+
+	_, err := m.DB.ExecContext(ctx, query, role, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Purge deletes a user along with everything they own: their events and
+// whatever belongs to them, their attendances, chat messages, webhooks, API
+// keys, linked identities, two-factor secrets, password resets and login
+// attempts. The deletion of each event is recorded in the outbox so the
+// remaining attendees get notified. It returns the number of events deleted.
+func (m *UserModel) Purge(id int) (int, error) {
+	return m.purge(id, 0)
+}
+
+// PurgeTransferringEvents deletes a user like Purge, except that their events
+// are given to another user instead of being deleted, in the same
+// transaction. It returns the number of events transferred.
+func (m *UserModel) PurgeTransferringEvents(id, newOwnerId int) (int, error) {
+	return m.purge(id, newOwnerId)
+}
+
+func (m *UserModel) purge(id, newOwnerId int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var email string
+	err = tx.QueryRowContext(ctx, "SELECT email FROM users WHERE id = $1", id).Scan(&email)
+	if err != nil {
+		return 0, err
+	}
+
+	transferred := 0
+	if newOwnerId != 0 {
+		transferred, err = transferEvents(ctx, tx, newOwnerId, "owner_id = $2", id)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	query := `
+	  SELECT e.id, e.owner_id, e.name, e.description, e.date, e.location, a.user_id
+	  FROM events e
+	  LEFT JOIN attendees a ON a.event_id = e.id AND a.user_id != e.owner_id
+	  WHERE e.owner_id = $1
+	  ORDER BY e.id
+	`
+
+	rows, err := tx.QueryContext(ctx, query, id)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	deleted := []*DeletedEvent{}
+
+	for rows.Next() {
+		var event Event
+		var attendeeId sql.NullInt64
+
+		err := rows.Scan(&event.Id, &event.OwnerId, &event.Name, &event.Description, &event.Date,
+			&event.Location, &attendeeId)
+		if err != nil {
+			return 0, err
+		}
+
+		if len(deleted) == 0 || deleted[len(deleted)-1].Event.Id != event.Id {
+			deleted = append(deleted, &DeletedEvent{Event: &event, AttendeeIds: []int{}})
+		}
+
+		if attendeeId.Valid {
+			last := deleted[len(deleted)-1]
+			last.AttendeeIds = append(last.AttendeeIds, int(attendeeId.Int64))
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	// Open enables the ON DELETE CASCADE of the foreign keys, but everything
+	// referencing the user or their events is still deleted explicitly, so
+	// that connections opened without it don't leave orphan rows.
+	const ownedEvents = "SELECT id FROM events WHERE owner_id = $1"
+	statements := []string{
+		"DELETE FROM event_messages WHERE user_id = $1 OR event_id IN (" + ownedEvents + ")",
+		"DELETE FROM event_reminders WHERE user_id = $1 OR event_id IN (" + ownedEvents + ")",
+		"DELETE FROM attendees WHERE user_id = $1 OR event_id IN (" + ownedEvents + ")",
+		"DELETE FROM webhook_deliveries WHERE webhook_id IN (SELECT id FROM webhooks WHERE user_id = $1)",
+		"DELETE FROM webhooks WHERE user_id = $1",
+		"DELETE FROM password_resets WHERE user_id = $1",
+		"DELETE FROM api_keys WHERE user_id = $1",
+		"DELETE FROM user_identities WHERE user_id = $1",
+		"DELETE FROM recovery_codes WHERE user_id = $1",
+		"DELETE FROM two_factor WHERE user_id = $1",
+		"DELETE FROM events WHERE owner_id = $1",
+		"DELETE FROM users WHERE id = $1",
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement, id); err != nil {
+			return 0, err
+		}
+	}
+
+	for _, statement := range []string{
+		"DELETE FROM login_attempts WHERE email = $1",
+		"DELETE FROM password_reset_requests WHERE email = $1",
+	} {
+		if _, err := tx.ExecContext(ctx, statement, email); err != nil {
+			return 0, err
+		}
+	}
+
+	for _, event := range deleted {
+		if err := insertOutboxMessage(ctx, tx, TopicEventDeleted, event); err != nil {
+			return 0, err
+		}
+	}
+
+	if newOwnerId != 0 {
+		return transferred, tx.Commit()
+	}
 
-	return m.getUser(query, email)
+	return len(deleted), tx.Commit()
 }
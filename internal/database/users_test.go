@@ -0,0 +1,53 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEmailsIgnoreCase(t *testing.T) {
+	models := newTestModels(t)
+
+	bob := insertTestUser(t, models, "Bob@Example.com")
+	if bob.Email != "bob@example.com" {
+		t.Errorf("stored email = %q, want bob@example.com", bob.Email)
+	}
+
+	found, err := models.Users.GetByEmail("BOB@example.COM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found == nil || found.Id != bob.Id {
+		t.Fatalf("GetByEmail with another case = %+v, want user %d", found, bob.Id)
+	}
+
+	err = models.Users.Insert(&User{Email: "bob@EXAMPLE.com", Name: "Other Bob", Password: "hash"})
+	if !errors.Is(err, ErrDuplicateEmail) {
+		t.Errorf("inserting the email with another case = %v, want ErrDuplicateEmail", err)
+	}
+
+	alice := insertTestUser(t, models, "alice@example.com")
+	if err := models.Users.SetEmail(alice.Id, "BOB@example.com"); !errors.Is(err, ErrDuplicateEmail) {
+		t.Errorf("changing to the email with another case = %v, want ErrDuplicateEmail", err)
+	}
+}
+
+func TestSetEmailDeletesPendingResets(t *testing.T) {
+	models := newTestModels(t)
+
+	user := insertTestUser(t, models, "alice@example.com")
+
+	reset := &PasswordReset{UserId: user.Id, TokenHash: "token-hash", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := models.PasswordResets.Insert(reset); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := models.Users.SetEmail(user.Id, "alice@example.org"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := models.PasswordResets.ResetPassword("token-hash", "new-hash", time.Now()); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("reset with a link sent to the previous address = %v, want ErrInvalidToken", err)
+	}
+}
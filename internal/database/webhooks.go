@@ -0,0 +1,279 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+type WebhookModel struct {
+	DB *sql.DB
+}
+
+type Webhook struct {
+	Id         int       `json:"id"`
+	UserId     int       `json:"userId"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"-"`
+	EventTypes []string  `json:"eventTypes"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// Subscribes reports whether the webhook wants to receive the given type of
+// event.
+func (w *Webhook) Subscribes(eventType string) bool {
+	return slices.Contains(w.EventTypes, eventType)
+}
+
+func (m *WebhookModel) Insert(webhook *Webhook) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if webhook.CreatedAt.IsZero() {
+		webhook.CreatedAt = time.Now().UTC()
+	}
+
+	query := "INSERT INTO webhooks (user_id, url, secret, event_types, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+
+	return m.DB.QueryRowContext(ctx, query, webhook.UserId, webhook.URL, webhook.Secret,
+		strings.Join(webhook.EventTypes, ","), webhook.CreatedAt).Scan(&webhook.Id)
+}
+
+func (m *WebhookModel) Get(id int) (*Webhook, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := "SELECT id, user_id, url, secret, event_types, created_at FROM webhooks WHERE id = $1"
+
+	var webhook Webhook
+	var eventTypes string
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(&webhook.Id, &webhook.UserId, &webhook.URL,
+		&webhook.Secret, &eventTypes, &webhook.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	webhook.EventTypes = strings.Split(eventTypes, ",")
+
+	return &webhook, nil
+}
+
+func (m *WebhookModel) GetByUser(userId int) ([]*Webhook, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := "SELECT id, user_id, url, secret, event_types, created_at FROM webhooks WHERE user_id = $1"
+
+	rows, err := m.DB.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []*Webhook{}
+
+	for rows.Next() {
+		var webhook Webhook
+		var eventTypes string
+
+		err := rows.Scan(&webhook.Id, &webhook.UserId, &webhook.URL,
+			&webhook.Secret, &eventTypes, &webhook.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		webhook.EventTypes = strings.Split(eventTypes, ",")
+		webhooks = append(webhooks, &webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (m *WebhookModel) Delete(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, "DELETE FROM webhook_deliveries WHERE webhook_id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.DB.ExecContext(ctx, "DELETE FROM webhooks WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type WebhookDeliveryModel struct {
+	DB *sql.DB
+}
+
+type WebhookDelivery struct {
+	Id             int        `json:"id"`
+	WebhookId      int        `json:"webhookId"`
+	EventType      string     `json:"eventType"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus *int       `json:"responseStatus"`
+	LastError      *string    `json:"lastError"`
+	NextAttemptAt  *time.Time `json:"nextAttemptAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	DeliveredAt    *time.Time `json:"deliveredAt"`
+}
+
+const deliveryColumns = `id, webhook_id, event_type, payload, status, attempts,
+	response_status, last_error, next_attempt_at, created_at, delivered_at`
+
+func scanDelivery(scanner interface{ Scan(...any) error }) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+
+	err := scanner.Scan(&delivery.Id, &delivery.WebhookId, &delivery.EventType, &delivery.Payload,
+		&delivery.Status, &delivery.Attempts, &delivery.ResponseStatus, &delivery.LastError,
+		&delivery.NextAttemptAt, &delivery.CreatedAt, &delivery.DeliveredAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+// Insert queues a delivery to be sent as soon as possible.
+func (m *WebhookDeliveryModel) Insert(delivery *WebhookDelivery) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if delivery.CreatedAt.IsZero() {
+		delivery.CreatedAt = time.Now().UTC()
+	}
+	delivery.Status = DeliveryPending
+	delivery.NextAttemptAt = &delivery.CreatedAt
+
+	query := `
+	  INSERT INTO webhook_deliveries (webhook_id, event_type, payload, status, next_attempt_at, created_at)
+	  VALUES ($1, $2, $3, $4, $5, $6)
+	  RETURNING id
+	`
+
+	return m.DB.QueryRowContext(ctx, query, delivery.WebhookId, delivery.EventType, delivery.Payload,
+		delivery.Status, delivery.NextAttemptAt, delivery.CreatedAt).Scan(&delivery.Id)
+}
+
+func (m *WebhookDeliveryModel) Get(id int) (*WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := "SELECT " + deliveryColumns + " FROM webhook_deliveries WHERE id = $1"
+
+	delivery, err := scanDelivery(m.DB.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// GetByWebhook returns the delivery log of a webhook, most recent first.
+func (m *WebhookDeliveryModel) GetByWebhook(webhookId int) ([]*WebhookDelivery, error) {
+	query := "SELECT " + deliveryColumns + " FROM webhook_deliveries WHERE webhook_id = $1 ORDER BY id DESC"
+
+	return m.getDeliveries(query, webhookId)
+}
+
+// GetDue returns pending deliveries whose next attempt is due.
+func (m *WebhookDeliveryModel) GetDue(now time.Time, limit int) ([]*WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries
+	  WHERE status = $1 AND next_attempt_at <= $2
+	  ORDER BY next_attempt_at
+	  LIMIT $3`
+
+	return m.getDeliveries(query, DeliveryPending, now.UTC(), limit)
+}
+
+func (m *WebhookDeliveryModel) getDeliveries(query string, args ...any) ([]*WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*WebhookDelivery{}
+
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// RecordAttempt stores the outcome of a delivery attempt. A nil nextAttemptAt
+// on a failed attempt means the delivery is given up.
+func (m *WebhookDeliveryModel) RecordAttempt(delivery *WebhookDelivery) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+	  UPDATE webhook_deliveries
+	  SET status = $1, attempts = $2, response_status = $3, last_error = $4,
+	    next_attempt_at = $5, delivered_at = $6
+	  WHERE id = $7
+	`
+
+	_, err := m.DB.ExecContext(ctx, query, delivery.Status, delivery.Attempts, delivery.ResponseStatus,
+		delivery.LastError, delivery.NextAttemptAt, delivery.DeliveredAt, delivery.Id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Redeliver queues a delivery again, whatever its current status.
+func (m *WebhookDeliveryModel) Redeliver(id int, now time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := "UPDATE webhook_deliveries SET status = $1, attempts = 0, next_attempt_at = $2 WHERE id = $3"
+
+	_, err := m.DB.ExecContext(ctx, query, DeliveryPending, now.UTC(), id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,336 @@
+// Package jwtkeys manages the asymmetric keys signing access tokens. Every
+// key has an id, sent in the kid header of the tokens it signs, so several
+// keys can be trusted at once: a new key can start signing tokens while the
+// previous one keeps verifying those it already issued.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Signing algorithms, named as in the alg header of the tokens.
+const (
+	RS256 = "RS256"
+	EdDSA = "EdDSA"
+)
+
+// ErrUnknownKey is returned when a token names a key that isn't in the set,
+// or was signed with another algorithm than its key's.
+var ErrUnknownKey = errors.New("jwtkeys: unknown signing key")
+
+type Key struct {
+	Id     string
+	Method jwt.SigningMethod
+	// private is nil for keys that only verify tokens, kept around until
+	// every token they signed has expired.
+	private crypto.PrivateKey
+	public  crypto.PublicKey
+}
+
+// CanSign reports whether the private part of the key is available.
+func (k *Key) CanSign() bool {
+	return k.private != nil
+}
+
+// PublicPEM returns the public key PEM encoded in PKIX form.
+func (k *Key) PublicPEM() ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(k.public)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// Set is the collection of trusted keys, one of which signs new tokens.
+type Set struct {
+	active *Key
+	keys   map[string]*Key
+	ids    []string
+}
+
+var keyFile = regexp.MustCompile(`^([A-Za-z0-9._-]+)\.pem$`)
+
+// LoadDir reads every <id>.pem file of dir, holding either a private key or,
+// for keys which only verify tokens, a public key. RSA and Ed25519 keys are
+// supported. The key signing tokens is activeId, or when empty the private
+// key with the greatest id, so that ids such as dates make the newest key
+// the active one.
+func LoadDir(dir, activeId string) (*Set, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &Set{keys: map[string]*Key{}}
+
+	for _, entry := range entries {
+		match := keyFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := ParseKey(match[1], data)
+		if err != nil {
+			return nil, fmt.Errorf("jwtkeys: %s: %w", entry.Name(), err)
+		}
+
+		set.keys[key.Id] = key
+		set.ids = append(set.ids, key.Id)
+	}
+
+	slices.Sort(set.ids)
+
+	if activeId == "" {
+		for _, id := range set.ids {
+			if set.keys[id].CanSign() {
+				activeId = id
+			}
+		}
+
+		if activeId == "" {
+			return nil, fmt.Errorf("jwtkeys: no private key in %s", dir)
+		}
+	}
+
+	set.active = set.keys[activeId]
+	if set.active == nil {
+		return nil, fmt.Errorf("jwtkeys: active key %q not found in %s", activeId, dir)
+	}
+	if !set.active.CanSign() {
+		return nil, fmt.Errorf("jwtkeys: active key %q has no private key", activeId)
+	}
+
+	return set, nil
+}
+
+// NewSet returns a set made of a single private key, for issuers whose keys
+// don't outlive the process.
+func NewSet(key *Key) (*Set, error) {
+	if !key.CanSign() {
+		return nil, fmt.Errorf("jwtkeys: key %q has no private key", key.Id)
+	}
+
+	return &Set{active: key, keys: map[string]*Key{key.Id: key}, ids: []string{key.Id}}, nil
+}
+
+// ParseKey parses a PEM encoded private or public key.
+func ParseKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data")
+	}
+
+	key := &Key{Id: id}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.private = private
+	case "PRIVATE KEY":
+		private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.private = private
+	case "PUBLIC KEY":
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.public = public
+	default:
+		return nil, fmt.Errorf("unexpected PEM block %q", block.Type)
+	}
+
+	if key.private != nil {
+		key.public = key.private.(crypto.Signer).Public()
+	}
+
+	switch public := key.public.(type) {
+	case *rsa.PublicKey:
+		if public.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, expected RSA or Ed25519", public)
+	}
+
+	return key, nil
+}
+
+// Active returns the key signing new tokens.
+func (s *Set) Active() *Key {
+	return s.active
+}
+
+// Keys returns every key of the set, ordered by id.
+func (s *Set) Keys() []*Key {
+	keys := make([]*Key, len(s.ids))
+	for i, id := range s.ids {
+		keys[i] = s.keys[id]
+	}
+
+	return keys
+}
+
+// Sign signs claims with the active key, naming it in the kid header.
+func (s *Set) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.active.Method, claims)
+	token.Header["kid"] = s.active.Id
+
+	return token.SignedString(s.active.private)
+}
+
+// VerificationKey returns the public key of the given id, as long as the
+// token was signed with the algorithm of that key.
+func (s *Set) VerificationKey(id string, method jwt.SigningMethod) (crypto.PublicKey, error) {
+	key := s.keys[id]
+	if key == nil || key.Method.Alg() != method.Alg() {
+		return nil, ErrUnknownKey
+	}
+
+	return key.public, nil
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519 (RFC 8037) and elliptic curve keys
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// PublicKey decodes the key, to verify tokens signed by other issuers. Only
+// RSA, Ed25519 and P-256 keys are supported.
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+
+	switch {
+	case k.Kty == "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwtkeys: key %s: decoding modulus: %w", k.Kid, err)
+		}
+
+		e, err := decode(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("jwtkeys: key %s: invalid exponent", k.Kid)
+		}
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case k.Kty == "OKP" && k.Crv == "Ed25519":
+		x, err := decode(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("jwtkeys: key %s: invalid Ed25519 key", k.Kid)
+		}
+
+		return ed25519.PublicKey(x), nil
+	case k.Kty == "EC" && k.Crv == "P-256":
+		x, errX := decode(k.X)
+		y, errY := decode(k.Y)
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("jwtkeys: key %s: invalid P-256 key", k.Kid)
+		}
+
+		public := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !public.Curve.IsOnCurve(public.X, public.Y) {
+			return nil, fmt.Errorf("jwtkeys: key %s: invalid P-256 key", k.Kid)
+		}
+
+		return public, nil
+	default:
+		return nil, fmt.Errorf("jwtkeys: key %s: unsupported key type %s %s", k.Kid, k.Kty, k.Crv)
+	}
+}
+
+// JWKS is the document listing the keys, served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public part of every key of the set.
+func (s *Set) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+
+	for _, key := range s.Keys() {
+		jwk := JWK{Kid: key.Id, Use: "sig", Alg: key.Method.Alg()}
+
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
+
+// Generate creates a private key for the given algorithm and returns it PEM
+// encoded in PKCS #8 form.
+func Generate(alg string) ([]byte, error) {
+	var key crypto.Signer
+	var err error
+
+	switch strings.ToUpper(alg) {
+	case RS256:
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case strings.ToUpper(EdDSA):
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("jwtkeys: unsupported algorithm %q, expected %s or %s", alg, RS256, EdDSA)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
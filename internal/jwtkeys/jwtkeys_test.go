@@ -0,0 +1,180 @@
+package jwtkeys
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// newTestKey generates a private key for alg with the given id.
+func newTestKey(t *testing.T, id, alg string) *Key {
+	t.Helper()
+
+	data, err := Generate(alg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ParseKey(id, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+// publicOnly returns the verification-only version of key, as kept once it is
+// retired.
+func publicOnly(t *testing.T, key *Key) *Key {
+	t.Helper()
+
+	data, err := key.PublicPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	public, err := ParseKey(key.Id, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return public
+}
+
+func TestLoadDirActiveKey(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]func() ([]byte, error){
+		"2024-01": func() ([]byte, error) { return Generate(RS256) },
+		"2024-02": func() ([]byte, error) { return Generate(EdDSA) },
+		// Retired: only the public key is left.
+		"2024-03": publicOnly(t, newTestKey(t, "2024-03", EdDSA)).PublicPEM,
+	}
+	for id, pem := range files {
+		data, err := pem()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, id+".pem"), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		activeId string
+		want     string
+		wantErr  bool
+	}{
+		// The greatest id with a private key.
+		{"", "2024-02", false},
+		{"2024-01", "2024-01", false},
+		{"2024-03", "", true},
+		{"2023-12", "", true},
+	}
+
+	for _, tt := range tests {
+		set, err := LoadDir(dir, tt.activeId)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("LoadDir(%q) = %s, want an error", tt.activeId, set.Active().Id)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("LoadDir(%q): %v", tt.activeId, err)
+			continue
+		}
+		if got := set.Active().Id; got != tt.want {
+			t.Errorf("LoadDir(%q) active key = %s, want %s", tt.activeId, got, tt.want)
+		}
+		if len(set.Keys()) != 3 {
+			t.Errorf("LoadDir(%q) loaded %d keys, want 3", tt.activeId, len(set.Keys()))
+		}
+	}
+}
+
+func TestVerificationKey(t *testing.T) {
+	rsaKey := newTestKey(t, "rsa", RS256)
+	edKey := newTestKey(t, "ed", EdDSA)
+
+	set, err := NewSet(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set.keys[edKey.Id] = publicOnly(t, edKey)
+	set.ids = append(set.ids, edKey.Id)
+
+	tests := []struct {
+		name    string
+		kid     string
+		method  jwt.SigningMethod
+		wantErr bool
+	}{
+		{"RSA key", "rsa", jwt.SigningMethodRS256, false},
+		{"retired Ed25519 key", "ed", jwt.SigningMethodEdDSA, false},
+		{"unknown key", "other", jwt.SigningMethodRS256, true},
+		// A public key must not be usable as an HMAC secret.
+		{"HS256 naming an RSA key", "rsa", jwt.SigningMethodHS256, true},
+		{"RS512 naming an RSA key", "rsa", jwt.SigningMethodRS512, true},
+		{"RS256 naming an Ed25519 key", "ed", jwt.SigningMethodRS256, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := set.VerificationKey(tt.kid, tt.method)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnknownKey) {
+					t.Errorf("got %v, %v, want ErrUnknownKey", key, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key == nil {
+				t.Error("got no key")
+			}
+		})
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	for _, alg := range []string{RS256, EdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			set, err := NewSet(newTestKey(t, "key-"+alg, alg))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			signed, err := set.Sign(jwt.MapClaims{"userId": 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			token, err := jwt.Parse(signed, func(t *jwt.Token) (interface{}, error) {
+				kid, _ := t.Header["kid"].(string)
+				return set.VerificationKey(kid, t.Method)
+			})
+			if err != nil || !token.Valid {
+				t.Fatalf("verifying the token: %v", err)
+			}
+			if alg := token.Header["alg"]; alg != set.Active().Method.Alg() {
+				t.Errorf("alg = %v, want %s", alg, set.Active().Method.Alg())
+			}
+		})
+	}
+}
+
+func TestNewSetRequiresPrivateKey(t *testing.T) {
+	if _, err := NewSet(publicOnly(t, newTestKey(t, "key", EdDSA))); err == nil {
+		t.Error("NewSet accepted a public key")
+	}
+}
@@ -0,0 +1,48 @@
+package notifications
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogNotifier writes every message to a log instead of delivering it, so the
+// application works offline during development.
+type LogNotifier struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func NewLogNotifier(w io.Writer) *LogNotifier {
+	return &LogNotifier{writer: w}
+}
+
+// NewFileNotifier returns a LogNotifier that appends messages to the given
+// file, creating it if needed.
+func NewFileNotifier(path string) (*LogNotifier, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLogNotifier(file), nil
+}
+
+func (n *LogNotifier) Send(msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "----- email %s -----\n", time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "To: %s\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\n\n", msg.Subject)
+	b.WriteString(msg.Text)
+	b.WriteString("\n")
+
+	_, err := io.WriteString(n.writer, b.String())
+
+	return err
+}
@@ -0,0 +1,65 @@
+package notifications
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// Message is a rendered notification ready to be delivered.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Notifier delivers messages to users. Implementations must be safe for
+// concurrent use.
+type Notifier interface {
+	Send(msg Message) error
+}
+
+// NewMessage renders the named template with the given data. Every template
+// defines a "subject", a "plainBody" and an "htmlBody" block.
+func NewMessage(to, templateName string, data any) (Message, error) {
+	file := "templates/" + templateName + ".tmpl"
+
+	textTmpl, err := template.New("email").ParseFS(templateFS, file)
+	if err != nil {
+		return Message{}, err
+	}
+
+	htmlTmpl, err := htmltemplate.New("email").ParseFS(templateFS, file)
+	if err != nil {
+		return Message{}, err
+	}
+
+	subject := new(bytes.Buffer)
+	if err := textTmpl.ExecuteTemplate(subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("rendering subject of %s: %w", templateName, err)
+	}
+
+	text := new(bytes.Buffer)
+	if err := textTmpl.ExecuteTemplate(text, "plainBody", data); err != nil {
+		return Message{}, fmt.Errorf("rendering text body of %s: %w", templateName, err)
+	}
+
+	html := new(bytes.Buffer)
+	if err := htmlTmpl.ExecuteTemplate(html, "htmlBody", data); err != nil {
+		return Message{}, fmt.Errorf("rendering html body of %s: %w", templateName, err)
+	}
+
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()),
+		HTML:    strings.TrimSpace(html.String()),
+	}, nil
+}
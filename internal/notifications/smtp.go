@@ -0,0 +1,104 @@
+package notifications
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+)
+
+// SMTPNotifier delivers messages as multipart (text and HTML) emails through
+// an SMTP server.
+type SMTPNotifier struct {
+	addr   string
+	auth   smtp.Auth
+	sender string
+}
+
+// NewSMTPNotifier returns a notifier sending through host:port. Credentials
+// are optional, which allows using a local relay or a fake server in tests.
+func NewSMTPNotifier(host string, port int, username, password, sender string) (*SMTPNotifier, error) {
+	if _, err := mail.ParseAddress(sender); err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", sender, err)
+	}
+
+	n := &SMTPNotifier{
+		addr:   net.JoinHostPort(host, strconv.Itoa(port)),
+		sender: sender,
+	}
+
+	if username != "" {
+		n.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return n, nil
+}
+
+func (n *SMTPNotifier) Send(msg Message) error {
+	from, err := mail.ParseAddress(n.sender)
+	if err != nil {
+		return err
+	}
+
+	body, err := n.buildMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	return smtp.SendMail(n.addr, n.auth, from.Address, []string{msg.To}, body)
+}
+
+func (n *SMTPNotifier) buildMessage(msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", n.sender)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", msg.Text},
+		{"text/html; charset=UTF-8", msg.HTML},
+	}
+
+	for _, p := range parts {
+		if p.content == "" {
+			continue
+		}
+
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		qp := quotedprintable.NewWriter(part)
+		if _, err := qp.Write([]byte(p.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
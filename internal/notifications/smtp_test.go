@@ -0,0 +1,255 @@
+package notifications
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// fakeSMTPServer accepts a single session speaking the minimum of SMTP
+// net/smtp needs, and records what it received.
+type fakeSMTPServer struct {
+	listener net.Listener
+	done     chan struct{}
+
+	auth string
+	from string
+	to   []string
+	data []byte
+	err  error
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	s := &fakeSMTPServer{listener: listener, done: make(chan struct{})}
+	go s.serve()
+
+	return s
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// wait returns once the session is over.
+func (s *fakeSMTPServer) wait(t *testing.T) {
+	t.Helper()
+
+	<-s.done
+	if s.err != nil {
+		t.Fatalf("fake SMTP server: %v", s.err)
+	}
+}
+
+func (s *fakeSMTPServer) serve() {
+	defer close(s.done)
+
+	conn, err := s.listener.Accept()
+	if err != nil {
+		s.err = err
+		return
+	}
+
+	text := textproto.NewConn(conn)
+	defer text.Close()
+
+	reply := func(line string) {
+		if s.err == nil {
+			s.err = text.PrintfLine("%s", line)
+		}
+	}
+
+	reply("220 localhost ESMTP fake")
+
+	for s.err == nil {
+		line, err := text.ReadLine()
+		if err != nil {
+			s.err = err
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250-8BITMIME")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			s.auth = arg
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			s.from = arg
+			reply("250 OK")
+		case "RCPT":
+			s.to = append(s.to, arg)
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			if s.data, s.err = text.ReadDotBytes(); s.err == nil {
+				reply("250 OK")
+			}
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPNotifierSend(t *testing.T) {
+	server := newFakeSMTPServer(t)
+
+	notifier, err := NewSMTPNotifier("127.0.0.1", server.port(), "mailer", "secret", "Events <no-reply@example.com>")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := Message{
+		To:      "ana@example.com",
+		Subject: "Lembrete: Café amanhã",
+		Text:    "O evento começa amanhã às 10h.\nUma linha bem longa para passar do limite de 76 caracteres de uma linha quoted-printable.",
+		HTML:    `<p>O evento <strong>começa</strong> amanhã às 10h.</p>`,
+	}
+
+	if err := notifier.Send(msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	server.wait(t)
+
+	// Envelope
+	if want := "PLAIN " + base64.StdEncoding.EncodeToString([]byte("\x00mailer\x00secret")); server.auth != want {
+		t.Errorf("AUTH %q, want %q", server.auth, want)
+	}
+	if !strings.HasPrefix(server.from, "FROM:<no-reply@example.com>") {
+		t.Errorf("MAIL %q, want FROM:<no-reply@example.com>", server.from)
+	}
+	if len(server.to) != 1 || server.to[0] != "TO:<ana@example.com>" {
+		t.Errorf("RCPT %q, want TO:<ana@example.com>", server.to)
+	}
+
+	// Headers
+	parsed, err := mail.ReadMessage(strings.NewReader(string(server.data)))
+	if err != nil {
+		t.Fatalf("parsing the message: %v", err)
+	}
+
+	headers := map[string]string{
+		"From":         "Events <no-reply@example.com>",
+		"To":           "ana@example.com",
+		"MIME-Version": "1.0",
+	}
+	for name, want := range headers {
+		if got := parsed.Header.Get(name); got != want {
+			t.Errorf("header %s = %q, want %q", name, got, want)
+		}
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("Subject = %q (%v), want %q", subject, err, msg.Subject)
+	}
+
+	if _, err := parsed.Header.Date(); err != nil {
+		t.Errorf("Date: %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q (%v), want multipart/alternative", parsed.Header.Get("Content-Type"), err)
+	}
+
+	// Parts, in order of preference: text first, then HTML.
+	wantParts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", msg.Text},
+		{"text/html; charset=UTF-8", msg.HTML},
+	}
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+
+	for i, want := range wantParts {
+		part, err := reader.NextRawPart()
+		if err != nil {
+			t.Fatalf("part %d: %v", i, err)
+		}
+
+		if got := part.Header.Get("Content-Type"); got != want.contentType {
+			t.Errorf("part %d: Content-Type = %q, want %q", i, got, want.contentType)
+		}
+		if got := part.Header.Get("Content-Transfer-Encoding"); got != "quoted-printable" {
+			t.Errorf("part %d: Content-Transfer-Encoding = %q, want quoted-printable", i, got)
+		}
+
+		raw, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// ReadDotBytes turned the CRLF line endings into LF.
+		for _, line := range strings.Split(string(raw), "\n") {
+			if len(line) > 76 {
+				t.Errorf("part %d: line of %d characters: %q", i, len(line), line)
+			}
+		}
+
+		decoded, err := io.ReadAll(quotedprintable.NewReader(bytes.NewReader(raw)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(decoded) != want.content {
+			t.Errorf("part %d = %q, want %q", i, decoded, want.content)
+		}
+	}
+
+	if _, err := reader.NextRawPart(); err != io.EOF {
+		t.Errorf("after the HTML part: %v, want io.EOF", err)
+	}
+}
+
+func TestSMTPNotifierWithoutCredentials(t *testing.T) {
+	server := newFakeSMTPServer(t)
+
+	notifier, err := NewSMTPNotifier("127.0.0.1", server.port(), "", "", "no-reply@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := notifier.Send(Message{To: "ana@example.com", Subject: "Hi", Text: "Hello"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	server.wait(t)
+
+	if server.auth != "" {
+		t.Errorf("AUTH %q sent without credentials", server.auth)
+	}
+	if !strings.Contains(string(server.data), "Hello") {
+		t.Errorf("message %q doesn't contain the text", server.data)
+	}
+	if strings.Contains(string(server.data), "text/html") {
+		t.Errorf("message %q has an empty HTML part", server.data)
+	}
+}
+
+func TestNewSMTPNotifierRejectsInvalidSender(t *testing.T) {
+	if _, err := NewSMTPNotifier("localhost", 25, "", "", "not an address"); err == nil {
+		t.Error("invalid sender accepted")
+	}
+}
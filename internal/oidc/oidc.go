@@ -0,0 +1,360 @@
+// Package oidc implements the relying party side of the OpenID Connect
+// authorization code flow with PKCE (RFC 7636): it discovers the endpoints of
+// an issuer, builds the authorization URL, exchanges the code for tokens and
+// verifies the ID token against the keys the issuer publishes.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/gumeeee/rest-api-in-gin/internal/jwtkeys"
+)
+
+// ErrInvalidIDToken is returned when an ID token fails verification.
+var ErrInvalidIDToken = errors.New("oidc: invalid ID token")
+
+// keysRefreshInterval limits how often the keys of the issuer are fetched
+// again when a token names an unknown one.
+const keysRefreshInterval = time.Minute
+
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// Scopes requested besides openid.
+	Scopes []string
+}
+
+// Metadata is the part of the issuer's discovery document the flow needs.
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Claims are the identity claims read from a verified ID token.
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider talks to a single issuer. Its discovery document is fetched on
+// first use, so the issuer doesn't need to be up when the API starts. It is
+// safe for concurrent use.
+type Provider struct {
+	config Config
+	client *http.Client
+
+	mu            sync.Mutex
+	metadata      *Metadata
+	keys          map[string]jwtkeys.JWK
+	keysFetchedAt time.Time
+}
+
+func New(config Config, client *http.Client) *Provider {
+	return &Provider{config: config, client: client}
+}
+
+// discover returns the metadata of the issuer, fetching it the first time.
+func (p *Provider) discover(ctx context.Context) (*Metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	var metadata Metadata
+
+	wellKnown := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, wellKnown, &metadata); err != nil {
+		return nil, fmt.Errorf("oidc: discovery: %w", err)
+	}
+
+	if metadata.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("oidc: discovery: issuer %q doesn't match the configured %q", metadata.Issuer, p.config.Issuer)
+	}
+
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("oidc: discovery: incomplete provider metadata")
+	}
+
+	p.metadata = &metadata
+
+	return p.metadata, nil
+}
+
+// AuthCodeURL returns the URL of the issuer's login page. The state and nonce
+// must be checked when the user comes back, and challenge is derived from the
+// PKCE verifier by NewVerifier.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(append([]string{"openid"}, p.config.Scopes...), " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return metadata.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange trades an authorization code for the ID token of the user and
+// verifies it.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Claims, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"code_verifier": {verifier},
+	}
+
+	// Public clients, which have no secret, identify themselves in the form.
+	if p.config.ClientSecret == "" {
+		form.Set("client_id", p.config.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+		return nil, fmt.Errorf("oidc: token request: status %d: %w", resp.StatusCode, err)
+	}
+
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		return nil, fmt.Errorf("oidc: token request: status %d: %s %s", resp.StatusCode, token.Error, token.ErrorDescription)
+	}
+
+	if token.IDToken == "" {
+		return nil, errors.New("oidc: token response has no ID token")
+	}
+
+	return p.Verify(ctx, token.IDToken, nonce)
+}
+
+// Verify checks the signature, issuer, audience, expiry and nonce of an ID
+// token and returns its claims.
+func (p *Provider) Verify(ctx context.Context, rawIDToken, nonce string) (*Claims, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.Parse(rawIDToken, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+
+		return p.verificationKey(ctx, metadata.JWKSURI, kid, t.Method)
+	})
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidIDToken
+	}
+
+	// The library only checks exp when present; ID tokens must carry it.
+	if _, ok := claims["exp"].(float64); !ok {
+		return nil, fmt.Errorf("%w: missing expiry", ErrInvalidIDToken)
+	}
+
+	if !claims.VerifyIssuer(metadata.Issuer, true) {
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrInvalidIDToken)
+	}
+
+	if !hasAudience(claims["aud"], p.config.ClientID) {
+		return nil, fmt.Errorf("%w: unexpected audience", ErrInvalidIDToken)
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+
+	result := Claims{Subject: subject}
+	result.Email, _ = claims["email"].(string)
+	result.Name, _ = claims["name"].(string)
+
+	// Some providers send email_verified as a string.
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		result.EmailVerified = verified
+	case string:
+		result.EmailVerified = verified == "true"
+	}
+
+	return &result, nil
+}
+
+// hasAudience reports whether the aud claim, a string or an array of
+// strings, names the client.
+func hasAudience(aud any, clientId string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == clientId
+	case []any:
+		for _, a := range aud {
+			if a == clientId {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// verificationKey returns the key of the issuer named kid, fetching the keys
+// again when it isn't known yet, as happens after the issuer rotates them.
+func (p *Provider) verificationKey(ctx context.Context, jwksURI, kid string, method jwt.SigningMethod) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	jwk, ok := p.findKey(kid)
+	if !ok && time.Since(p.keysFetchedAt) >= keysRefreshInterval {
+		var jwks jwtkeys.JWKS
+		if err := p.getJSON(ctx, jwksURI, &jwks); err != nil {
+			return nil, fmt.Errorf("oidc: fetching keys: %w", err)
+		}
+
+		p.keys = make(map[string]jwtkeys.JWK, len(jwks.Keys))
+		for _, key := range jwks.Keys {
+			if key.Use == "" || key.Use == "sig" {
+				p.keys[key.Kid] = key
+			}
+		}
+		p.keysFetchedAt = time.Now()
+
+		jwk, ok = p.findKey(kid)
+	}
+	if !ok {
+		return nil, jwtkeys.ErrUnknownKey
+	}
+
+	if jwk.Alg != "" && jwk.Alg != method.Alg() {
+		return nil, jwtkeys.ErrUnknownKey
+	}
+
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA, *jwt.SigningMethodEd25519:
+	default:
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	return jwk.PublicKey()
+}
+
+// findKey looks a key up by id. Tokens without kid are accepted when the
+// issuer has a single key.
+func (p *Provider) findKey(kid string) (jwtkeys.JWK, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+
+	key, ok := p.keys[kid]
+
+	return key, ok
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(dst)
+}
+
+// NewVerifier returns a random PKCE code verifier and its S256 challenge.
+func NewVerifier() (string, string, error) {
+	verifier, err := RandomString()
+	if err != nil {
+		return "", "", err
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// RandomString returns 32 random bytes encoded for URLs, for states and
+// nonces.
+func RandomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,172 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+)
+
+// Handler reacts to an outbox message. Messages are delivered at least once,
+// so a handler may see the same message again after a crash and must tolerate
+// duplicates.
+type Handler func(ctx context.Context, msg *database.OutboxMessage) error
+
+type registration struct {
+	name    string
+	handler Handler
+}
+
+// Dispatcher polls the outbox and publishes pending messages to the handlers
+// registered for their topic. Handlers that succeed are recorded on the
+// message, so after a failure only the failing ones run again, until the
+// message has been attempted maxAttempts times. Processed messages are deleted
+// once they are older than the retention.
+type Dispatcher struct {
+	store         *database.OutboxModel
+	interval      time.Duration
+	batchSize     int
+	maxBackoff    time.Duration
+	maxAttempts   int
+	retention     time.Duration
+	pruneInterval time.Duration
+
+	mu       sync.RWMutex
+	handlers map[string][]registration
+	wake     chan struct{}
+}
+
+func NewDispatcher(store *database.OutboxModel, interval time.Duration, maxAttempts int, retention time.Duration) *Dispatcher {
+	return &Dispatcher{
+		store:         store,
+		interval:      interval,
+		batchSize:     100,
+		maxBackoff:    5 * time.Minute,
+		maxAttempts:   maxAttempts,
+		retention:     retention,
+		pruneInterval: time.Hour,
+		handlers:      make(map[string][]registration),
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+// Register adds a handler for a topic. The name identifies the handler in the
+// outbox and must stay stable across releases.
+func (d *Dispatcher) Register(topic, name string, handler Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.handlers[topic] = append(d.handlers[topic], registration{name: name, handler: handler})
+}
+
+// Wake makes Run look for pending messages without waiting for the next poll.
+func (d *Dispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run dispatches messages until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	pruneTicker := time.NewTicker(d.pruneInterval)
+	defer pruneTicker.Stop()
+
+	d.pruneProcessed()
+
+	for {
+		d.dispatchPending(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		case <-pruneTicker.C:
+			d.pruneProcessed()
+		}
+	}
+}
+
+func (d *Dispatcher) pruneProcessed() {
+	removed, err := d.store.DeleteProcessedBefore(time.Now().Add(-d.retention))
+	if err != nil {
+		log.Printf("Failed to delete processed outbox messages: %v", err)
+		return
+	}
+
+	if removed > 0 {
+		log.Printf("Deleted %d processed outbox messages", removed)
+	}
+}
+
+func (d *Dispatcher) dispatchPending(ctx context.Context) {
+	messages, err := d.store.GetPending(time.Now(), d.batchSize)
+	if err != nil {
+		log.Printf("Failed to retreive pending outbox messages: %v", err)
+		return
+	}
+
+	for _, msg := range messages {
+		if ctx.Err() != nil {
+			return
+		}
+
+		completed, err := d.dispatch(ctx, msg)
+		if err != nil && msg.Attempts+1 >= d.maxAttempts {
+			log.Printf("Giving up on outbox message %d (%s) after %d attempts: %v", msg.Id, msg.Topic, msg.Attempts+1, err)
+
+			if err := d.store.MarkDead(msg.Id, completed, err.Error(), time.Now()); err != nil {
+				log.Printf("Failed to record failure of outbox message %d: %v", msg.Id, err)
+			}
+			continue
+		}
+		if err != nil {
+			backoff := min(time.Second<<min(msg.Attempts, 16), d.maxBackoff)
+			log.Printf("Failed to dispatch outbox message %d (%s), retrying in %s: %v", msg.Id, msg.Topic, backoff, err)
+
+			if err := d.store.MarkFailed(msg.Id, completed, err.Error(), time.Now().Add(backoff)); err != nil {
+				log.Printf("Failed to record failure of outbox message %d: %v", msg.Id, err)
+			}
+			continue
+		}
+
+		if err := d.store.MarkProcessed(msg.Id, time.Now()); err != nil {
+			log.Printf("Failed to mark outbox message %d as processed: %v", msg.Id, err)
+		}
+	}
+}
+
+// dispatch runs the handlers of the message that haven't succeeded yet and
+// returns the names of all the handlers that have.
+func (d *Dispatcher) dispatch(ctx context.Context, msg *database.OutboxMessage) ([]string, error) {
+	d.mu.RLock()
+	registrations := d.handlers[msg.Topic]
+	d.mu.RUnlock()
+
+	completed := slices.Clone(msg.Completed)
+
+	var errs []error
+	for _, r := range registrations {
+		if slices.Contains(completed, r.name) {
+			continue
+		}
+
+		if err := r.handler(ctx, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.name, err))
+			continue
+		}
+
+		completed = append(completed, r.name)
+	}
+
+	return completed, errors.Join(errs...)
+}
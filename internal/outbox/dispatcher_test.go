@@ -0,0 +1,223 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate"
+	"github.com/golang-migrate/migrate/database/sqlite3"
+	"github.com/golang-migrate/migrate/source/file"
+	"github.com/gumeeee/rest-api-in-gin/internal/database"
+)
+
+// newTestDispatcher returns a dispatcher over a migrated database in a
+// temporary directory that retries failed messages right away.
+func newTestDispatcher(t *testing.T, maxAttempts int) (*Dispatcher, *database.OutboxModel) {
+	t.Helper()
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "data.db"), database.Options{})
+	if err != nil {
+		t.Fatalf("opening the database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	instance, err := sqlite3.WithInstance(db, &sqlite3.Config{})
+	if err != nil {
+		t.Fatalf("creating the migration instance: %v", err)
+	}
+
+	source, err := (&file.File{}).Open("../../cmd/migrate/migrations")
+	if err != nil {
+		t.Fatalf("opening the migrations: %v", err)
+	}
+
+	m, err := migrate.NewWithInstance("file", source, "sqlite3", instance)
+	if err != nil {
+		t.Fatalf("creating the migration: %v", err)
+	}
+
+	if err := m.Up(); err != nil {
+		t.Fatalf("running the migrations: %v", err)
+	}
+
+	store := &database.OutboxModel{DB: db}
+
+	d := NewDispatcher(store, time.Hour, maxAttempts, time.Hour)
+	d.maxBackoff = 0
+
+	return d, store
+}
+
+type outboxRow struct {
+	attempts  int
+	completed string
+	processed bool
+	failed    bool
+	lastError *string
+}
+
+func getOutboxRow(t *testing.T, store *database.OutboxModel, id int) outboxRow {
+	t.Helper()
+
+	var row outboxRow
+	err := store.DB.QueryRow(`
+	  SELECT attempts, completed_handlers, processed_at IS NOT NULL, failed_at IS NOT NULL, last_error
+	  FROM outbox WHERE id = $1`, id).Scan(&row.attempts, &row.completed, &row.processed, &row.failed, &row.lastError)
+	if err != nil {
+		t.Fatalf("reading outbox message %d: %v", id, err)
+	}
+
+	return row
+}
+
+func insertMessage(t *testing.T, store *database.OutboxModel) int {
+	t.Helper()
+
+	if err := store.Insert(database.TopicEventCreated, map[string]int{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	var id int
+	if err := store.DB.QueryRow("SELECT MAX(id) FROM outbox").Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+
+	return id
+}
+
+func TestDispatchRetriesOnlyFailedHandlers(t *testing.T) {
+	d, store := newTestDispatcher(t, 5)
+
+	var okCalls, flakyCalls int
+	d.Register(database.TopicEventCreated, "ok", func(ctx context.Context, msg *database.OutboxMessage) error {
+		okCalls++
+		return nil
+	})
+	d.Register(database.TopicEventCreated, "flaky", func(ctx context.Context, msg *database.OutboxMessage) error {
+		flakyCalls++
+		if flakyCalls == 1 {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+
+	id := insertMessage(t, store)
+
+	d.dispatchPending(context.Background())
+
+	row := getOutboxRow(t, store, id)
+	if row.processed || row.failed || row.attempts != 1 || row.completed != "ok" || row.lastError == nil {
+		t.Fatalf("after a failed handler: got %+v", row)
+	}
+
+	d.dispatchPending(context.Background())
+
+	row = getOutboxRow(t, store, id)
+	if !row.processed || row.attempts != 2 || row.lastError != nil {
+		t.Fatalf("after the retry: got %+v", row)
+	}
+	if okCalls != 1 || flakyCalls != 2 {
+		t.Errorf("handlers ran %d and %d times, want 1 and 2", okCalls, flakyCalls)
+	}
+
+	d.dispatchPending(context.Background())
+	if okCalls != 1 || flakyCalls != 2 {
+		t.Errorf("a processed message was dispatched again")
+	}
+}
+
+func TestDispatchGivesUpAfterMaxAttempts(t *testing.T) {
+	d, store := newTestDispatcher(t, 3)
+
+	calls := 0
+	d.Register(database.TopicEventCreated, "broken", func(ctx context.Context, msg *database.OutboxMessage) error {
+		calls++
+		return errors.New("always fails")
+	})
+
+	id := insertMessage(t, store)
+	other := insertMessage(t, store)
+
+	for range 5 {
+		d.dispatchPending(context.Background())
+	}
+
+	for _, id := range []int{id, other} {
+		row := getOutboxRow(t, store, id)
+		if !row.failed || row.processed || row.attempts != 3 {
+			t.Errorf("message %d: got %+v, want failed after 3 attempts", id, row)
+		}
+		if row.lastError == nil || *row.lastError != "broken: always fails" {
+			t.Errorf("message %d: last error = %v", id, row.lastError)
+		}
+	}
+
+	if calls != 6 {
+		t.Errorf("handler ran %d times, want 6", calls)
+	}
+
+	pending, err := store.GetPending(time.Now(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("got %d pending messages, want none", len(pending))
+	}
+}
+
+func TestPruneProcessed(t *testing.T) {
+	d, store := newTestDispatcher(t, 3)
+
+	old := insertMessage(t, store)
+	recent := insertMessage(t, store)
+	pending := insertMessage(t, store)
+	dead := insertMessage(t, store)
+
+	if err := store.MarkProcessed(old, time.Now().Add(-2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.MarkProcessed(recent, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.MarkDead(dead, nil, "gave up", time.Now().Add(-2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	d.pruneProcessed()
+
+	var ids []int
+	rows, err := store.DB.Query("SELECT id FROM outbox ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	want := []int{recent, pending, dead}
+	if !slices.Equal(ids, want) {
+		t.Errorf("messages left: got %v, want %v", ids, want)
+	}
+}
+
+func TestWakeDoesNotBlock(t *testing.T) {
+	d, _ := newTestDispatcher(t, 3)
+
+	d.Wake()
+	d.Wake()
+
+	select {
+	case <-d.wake:
+	default:
+		t.Fatal("Wake didn't signal the dispatcher")
+	}
+}
@@ -0,0 +1,109 @@
+package pubsub
+
+import "sync"
+
+// Message is published to every subscriber of a topic.
+type Message struct {
+	Id   int
+	Type string
+	Data any
+}
+
+// Hub is an in-process publish/subscribe hub. Every subscriber gets its own
+// buffer; a subscriber that falls so far behind that its buffer is full is
+// evicted instead of slowing down publishers.
+type Hub struct {
+	mu          sync.Mutex
+	bufferSize  int
+	subscribers map[string]map[*Subscription]struct{}
+}
+
+func NewHub(bufferSize int) *Hub {
+	return &Hub{
+		bufferSize:  bufferSize,
+		subscribers: make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Subscription receives the messages published to a topic on C until it is
+// closed or evicted, at which point C is closed.
+type Subscription struct {
+	C <-chan Message
+
+	ch      chan Message
+	topic   string
+	hub     *Hub
+	evicted bool
+}
+
+func (h *Hub) Subscribe(topic string) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan Message, h.bufferSize)
+	sub := &Subscription{C: ch, ch: ch, topic: topic, hub: h}
+
+	if h.subscribers[topic] == nil {
+		h.subscribers[topic] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[topic][sub] = struct{}{}
+
+	return sub
+}
+
+// Publish sends msg to every subscriber of the topic without blocking.
+func (h *Hub) Publish(topic string, msg Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers[topic] {
+		select {
+		case sub.ch <- msg:
+		default:
+			sub.evicted = true
+			h.remove(sub)
+		}
+	}
+}
+
+// Subscribers returns the number of subscribers of a topic.
+func (h *Hub) Subscribers(topic string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subscribers[topic])
+}
+
+// remove must be called with h.mu held.
+func (h *Hub) remove(sub *Subscription) {
+	subs, ok := h.subscribers[sub.topic]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subscribers, sub.topic)
+	}
+
+	close(sub.ch)
+}
+
+// Close unsubscribes. It is safe to call more than once and after eviction.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.remove(s)
+}
+
+// Evicted reports whether the subscription was dropped for being too slow.
+func (s *Subscription) Evicted() bool {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	return s.evicted
+}
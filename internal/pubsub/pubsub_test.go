@@ -0,0 +1,103 @@
+package pubsub
+
+import "testing"
+
+func receive(t *testing.T, sub *Subscription) (Message, bool) {
+	t.Helper()
+
+	select {
+	case msg, ok := <-sub.C:
+		return msg, ok
+	default:
+		t.Fatal("no message waiting")
+		return Message{}, false
+	}
+}
+
+func TestPublishReachesSubscribersOfTheTopic(t *testing.T) {
+	hub := NewHub(4)
+
+	first := hub.Subscribe("event:1")
+	second := hub.Subscribe("event:1")
+	other := hub.Subscribe("event:2")
+	defer first.Close()
+	defer second.Close()
+	defer other.Close()
+
+	hub.Publish("event:1", Message{Id: 1, Type: "event.updated"})
+
+	for _, sub := range []*Subscription{first, second} {
+		msg, ok := receive(t, sub)
+		if !ok || msg.Id != 1 || msg.Type != "event.updated" {
+			t.Errorf("got %+v, %v", msg, ok)
+		}
+	}
+
+	select {
+	case msg := <-other.C:
+		t.Errorf("subscriber of another topic got %+v", msg)
+	default:
+	}
+
+	if n := hub.Subscribers("event:1"); n != 2 {
+		t.Errorf("Subscribers = %d, want 2", n)
+	}
+}
+
+func TestSlowSubscriberIsEvicted(t *testing.T) {
+	hub := NewHub(2)
+
+	slow := hub.Subscribe("event:1")
+	fast := hub.Subscribe("event:1")
+	defer fast.Close()
+
+	for id := 1; id <= 3; id++ {
+		hub.Publish("event:1", Message{Id: id})
+		if _, ok := receive(t, fast); !ok {
+			t.Fatal("fast subscriber was closed")
+		}
+	}
+
+	if !slow.Evicted() {
+		t.Fatal("slow subscriber wasn't evicted")
+	}
+	if fast.Evicted() {
+		t.Error("fast subscriber was evicted")
+	}
+
+	// The buffered messages are still delivered before the channel closes.
+	for id := 1; id <= 2; id++ {
+		if msg, ok := receive(t, slow); !ok || msg.Id != id {
+			t.Fatalf("got %+v, %v, want message %d", msg, ok, id)
+		}
+	}
+	if _, ok := receive(t, slow); ok {
+		t.Error("channel of the evicted subscriber isn't closed")
+	}
+
+	if n := hub.Subscribers("event:1"); n != 1 {
+		t.Errorf("Subscribers = %d, want 1", n)
+	}
+
+	slow.Close()
+}
+
+func TestCloseUnsubscribes(t *testing.T) {
+	hub := NewHub(1)
+
+	sub := hub.Subscribe("event:1")
+	sub.Close()
+	sub.Close()
+
+	if _, ok := <-sub.C; ok {
+		t.Error("channel isn't closed")
+	}
+	if sub.Evicted() {
+		t.Error("closed subscription reported as evicted")
+	}
+	if n := hub.Subscribers("event:1"); n != 0 {
+		t.Errorf("Subscribers = %d, want 0", n)
+	}
+
+	hub.Publish("event:1", Message{Id: 1})
+}
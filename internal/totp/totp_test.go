@@ -0,0 +1,204 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 seed of the test vectors of RFC 6238, appendix B:
+// the ASCII string "12345678901234567890", base32 encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestCodeRFC6238 checks the SHA-1 test vectors of RFC 6238. They have 8
+// digits, of which the codes are the last 6.
+func TestCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tt := range tests {
+		code, err := Code(rfcSecret, Counter(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code at %d: %v", tt.unix, err)
+		}
+
+		if want := tt.want[len(tt.want)-Digits:]; code != want {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, code, want)
+		}
+	}
+}
+
+func TestCodeLowercaseSecret(t *testing.T) {
+	upper, err := Code(rfcSecret, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lower, err := Code("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lower != upper {
+		t.Errorf("Code with a lowercase secret = %s, want %s", lower, upper)
+	}
+}
+
+func TestCodeInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code accepted an invalid secret")
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := Counter(now)
+
+	tests := []struct {
+		name   string
+		offset int64
+		want   bool
+	}{
+		{"two periods early", -2, false},
+		{"previous period", -1, true},
+		{"current period", 0, true},
+		{"next period", 1, true},
+		{"two periods late", 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Code(rfcSecret, current+tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			counter, ok := Validate(rfcSecret, code, now, 0)
+			if ok != tt.want {
+				t.Fatalf("Validate = %t, want %t", ok, tt.want)
+			}
+			if ok && counter != current+tt.offset {
+				t.Errorf("Validate matched counter %d, want %d", counter, current+tt.offset)
+			}
+		})
+	}
+}
+
+func TestValidateRefusesReplay(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	code, err := Code(rfcSecret, Counter(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counter, ok := Validate(rfcSecret, code, now, 0)
+	if !ok {
+		t.Fatal("first use of the code refused")
+	}
+
+	if _, ok := Validate(rfcSecret, code, now, counter); ok {
+		t.Error("the same code was accepted twice")
+	}
+
+	// Nor within the skew window, a period later.
+	if _, ok := Validate(rfcSecret, code, now.Add(Period), counter); ok {
+		t.Error("the same code was accepted again in the next period")
+	}
+
+	// An older code, still in the window, can't be used after a newer one.
+	previous, err := Code(rfcSecret, Counter(now)-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Validate(rfcSecret, previous, now, counter); ok {
+		t.Error("a code older than the last accepted one was accepted")
+	}
+
+	next, err := Code(rfcSecret, Counter(now)+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Validate(rfcSecret, next, now, counter); !ok {
+		t.Error("the code of the next period was refused")
+	}
+}
+
+func TestValidateFormat(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	code, err := Code(rfcSecret, Counter(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := Validate(rfcSecret, code[:3]+" "+code[3:], now, 0); !ok {
+		t.Error("code with a space refused")
+	}
+
+	for _, invalid := range []string{"", code[:5], code + "0", "abcdef"} {
+		if _, ok := Validate(rfcSecret, invalid, now, 0); ok {
+			t.Errorf("Validate accepted %q", invalid)
+		}
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := encoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret %q isn't base32: %v", secret, err)
+	}
+	if len(key) != 20 {
+		t.Errorf("secret of %d bytes, want 20", len(key))
+	}
+
+	other, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == secret {
+		t.Error("GenerateSecret returned the same secret twice")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri, err := url.Parse(ProvisioningURI("Events API", "ana@example.com", rfcSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if uri.Scheme != "otpauth" || uri.Host != "totp" {
+		t.Errorf("URI %s, want otpauth://totp/...", uri)
+	}
+	if uri.Path != "/Events API:ana@example.com" {
+		t.Errorf("label %q, want Events API:ana@example.com", uri.Path)
+	}
+
+	want := map[string]string{
+		"secret":    rfcSecret,
+		"issuer":    "Events API",
+		"algorithm": "SHA1",
+		"digits":    "6",
+		"period":    "30",
+	}
+	query := uri.Query()
+	for name, value := range want {
+		if got := query.Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Payload is the JSON document posted to subscribers.
+type Payload struct {
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+	Data      any       `json:"data"`
+}
+
+// Sign returns the value of the signature header for a body: the hex encoded
+// HMAC-SHA256 of the body keyed with the webhook secret, prefixed with
+// "sha256=". Receivers should compute it themselves and compare in constant
+// time.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Request describes a single delivery attempt.
+type Request struct {
+	URL        string
+	Secret     string
+	DeliveryId int
+	EventType  string
+	Body       []byte
+}
+
+var (
+	ErrUnsupportedScheme = errors.New("webhook URL must use http or https")
+	// ErrForbiddenDestination is returned for URLs pointing at the server
+	// itself or its private network, which users must not be able to reach
+	// through webhooks.
+	ErrForbiddenDestination = errors.New("webhook URL points to a private or local address")
+)
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which
+// netip doesn't count as private.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// forbiddenAddress reports whether ip is loopback, private, link-local (which
+// includes the cloud metadata endpoints), multicast or unspecified.
+func forbiddenAddress(ip netip.Addr) bool {
+	ip = ip.Unmap()
+
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip)
+}
+
+// CheckURL validates the URL of a webhook when it is saved. Only addresses
+// written in the URL are checked here: host names are checked once resolved,
+// when delivering, so that a name can't be pointed elsewhere afterwards.
+func CheckURL(rawURL string, allowPrivate bool) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ErrUnsupportedScheme
+	}
+
+	host := u.Hostname()
+	if host == "" {
+		return errors.New("webhook URL has no host")
+	}
+
+	if allowPrivate {
+		return nil
+	}
+
+	if ip, err := netip.ParseAddr(host); err == nil && forbiddenAddress(ip) {
+		return ErrForbiddenDestination
+	}
+
+	if host = strings.ToLower(strings.TrimSuffix(host, ".")); host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrForbiddenDestination
+	}
+
+	return nil
+}
+
+// refuseForbiddenAddress is the Control hook of the dialer, called with the
+// resolved address of every connection, redirects included.
+func refuseForbiddenAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+
+	if forbiddenAddress(addrPort.Addr()) {
+		return ErrForbiddenDestination
+	}
+
+	return nil
+}
+
+// Client posts signed payloads to subscriber URLs.
+type Client struct {
+	HTTPClient *http.Client
+	// allowPrivate lets webhooks reach private and local addresses, for
+	// development.
+	allowPrivate bool
+}
+
+// NewClient returns a client refusing to connect to private and local
+// addresses unless allowPrivate is set.
+func NewClient(timeout time.Duration, allowPrivate bool) *Client {
+	return newClient(timeout, allowPrivate, net.DefaultResolver)
+}
+
+func newClient(timeout time.Duration, allowPrivate bool, resolver *net.Resolver) *Client {
+	dialer := &net.Dialer{Timeout: timeout, Resolver: resolver}
+	if !allowPrivate {
+		dialer.Control = refuseForbiddenAddress
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be the one connecting to the subscriber, out of reach
+	// of the dialer's check.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Client{
+		HTTPClient:   &http.Client{Timeout: timeout, Transport: transport},
+		allowPrivate: allowPrivate,
+	}
+}
+
+// Deliver posts the request body and returns the response status code. Any
+// status outside the 2xx range is reported as an error.
+func (c *Client) Deliver(ctx context.Context, r Request) (int, error) {
+	if err := CheckURL(r.URL, c.allowPrivate); err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(r.Body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "rest-api-in-gin-webhooks/1.0")
+	req.Header.Set(HeaderEvent, r.EventType)
+	req.Header.Set(HeaderDelivery, strconv.Itoa(r.DeliveryId))
+	req.Header.Set(HeaderSignature, Sign(r.Secret, r.Body))
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected response status %d", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
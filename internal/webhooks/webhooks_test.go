@@ -0,0 +1,238 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+	"time"
+)
+
+var forbiddenTests = []struct {
+	name string
+	host string
+}{
+	{"IPv4 loopback", "127.0.0.1"},
+	{"IPv4 loopback range", "127.1.2.3"},
+	{"IPv6 loopback", "::1"},
+	{"RFC 1918 10/8", "10.0.0.1"},
+	{"RFC 1918 172.16/12", "172.16.5.4"},
+	{"RFC 1918 192.168/16", "192.168.1.1"},
+	{"link-local", "169.254.1.1"},
+	{"cloud metadata", "169.254.169.254"},
+	{"IPv6 link-local", "fe80::1"},
+	{"carrier-grade NAT", "100.64.0.1"},
+	{"carrier-grade NAT upper bound", "100.127.255.254"},
+	{"IPv6 unique local", "fd00::1"},
+	{"IPv4-mapped loopback", "::ffff:127.0.0.1"},
+	{"IPv4-mapped private", "::ffff:10.0.0.1"},
+	{"IPv4-mapped metadata", "::ffff:169.254.169.254"},
+	{"unspecified", "0.0.0.0"},
+	{"IPv6 unspecified", "::"},
+	{"multicast", "224.0.0.1"},
+}
+
+var allowedTests = []struct {
+	name string
+	host string
+}{
+	{"public IPv4", "93.184.216.34"},
+	{"public IPv6", "2606:2800:220:1:248:1893:25c8:1946"},
+	{"just below carrier-grade NAT", "100.63.255.255"},
+	{"just above carrier-grade NAT", "100.128.0.1"},
+	{"IPv4-mapped public", "::ffff:93.184.216.34"},
+}
+
+func TestCheckURL(t *testing.T) {
+	for _, tt := range forbiddenTests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := "https://" + net.JoinHostPort(tt.host, "8443") + "/hook"
+
+			if err := CheckURL(u, false); !errors.Is(err, ErrForbiddenDestination) {
+				t.Errorf("CheckURL(%s) = %v, want ErrForbiddenDestination", u, err)
+			}
+			if err := CheckURL(u, true); err != nil {
+				t.Errorf("CheckURL(%s) allowing private addresses = %v", u, err)
+			}
+		})
+	}
+
+	for _, tt := range allowedTests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := "https://" + net.JoinHostPort(tt.host, "443") + "/hook"
+
+			if err := CheckURL(u, false); err != nil {
+				t.Errorf("CheckURL(%s) = %v", u, err)
+			}
+		})
+	}
+
+	for u, want := range map[string]error{
+		"http://localhost:8080/hook":     ErrForbiddenDestination,
+		"http://LOCALHOST./hook":         ErrForbiddenDestination,
+		"http://api.localhost/hook":      ErrForbiddenDestination,
+		"ftp://example.com/hook":         ErrUnsupportedScheme,
+		"file:///etc/passwd":             ErrUnsupportedScheme,
+		"https://hooks.example.com/hook": nil,
+	} {
+		if err := CheckURL(u, false); !errors.Is(err, want) {
+			t.Errorf("CheckURL(%s) = %v, want %v", u, err, want)
+		}
+	}
+
+	if err := CheckURL("https:///hook", false); err == nil {
+		t.Error("CheckURL accepted a URL without a host")
+	}
+}
+
+func TestRefuseForbiddenAddress(t *testing.T) {
+	for _, tt := range forbiddenTests {
+		address := net.JoinHostPort(tt.host, "80")
+		if err := refuseForbiddenAddress("tcp", address, nil); !errors.Is(err, ErrForbiddenDestination) {
+			t.Errorf("%s: refuseForbiddenAddress(%s) = %v, want ErrForbiddenDestination", tt.name, address, err)
+		}
+	}
+
+	for _, tt := range allowedTests {
+		address := net.JoinHostPort(tt.host, "80")
+		if err := refuseForbiddenAddress("tcp", address, nil); err != nil {
+			t.Errorf("%s: refuseForbiddenAddress(%s) = %v", tt.name, address, err)
+		}
+	}
+}
+
+// fakeResolver answers every A query with addr, over the stream transport
+// of DNS, and every other query with no records.
+func fakeResolver(addr netip.Addr) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			client, server := net.Pipe()
+			go serveFakeDNS(server, addr)
+			return client, nil
+		},
+	}
+}
+
+func serveFakeDNS(conn net.Conn, addr netip.Addr) {
+	defer conn.Close()
+
+	for {
+		var length uint16
+		if err := binary.Read(conn, binary.BigEndian, &length); err != nil {
+			return
+		}
+
+		query := make([]byte, length)
+		if _, err := io.ReadFull(conn, query); err != nil {
+			return
+		}
+
+		// The question follows the 12 bytes of the header: the name, then
+		// its type and class.
+		end := 12
+		for query[end] != 0 {
+			end += int(query[end]) + 1
+		}
+		question := query[12 : end+5]
+		qtype := binary.BigEndian.Uint16(query[end+1:])
+
+		response := append([]byte{}, query[:2]...)
+		response = append(response, 0x81, 0x80, 0, 1, 0, 0, 0, 0, 0, 0)
+		response = append(response, question...)
+		if qtype == 1 {
+			response[7] = 1
+			ip := addr.As4()
+			// A pointer to the name in the question, type A, class IN, a TTL
+			// of 60 seconds and the 4 bytes of the address.
+			response = append(response, 0xc0, 12, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4)
+			response = append(response, ip[:]...)
+		}
+
+		if err := binary.Write(conn, binary.BigEndian, uint16(len(response))); err != nil {
+			return
+		}
+		if _, err := conn.Write(response); err != nil {
+			return
+		}
+	}
+}
+
+func TestDeliverRefusesHostResolvingToPrivateAddress(t *testing.T) {
+	received := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The name passes CheckURL but resolves to the loopback address of the
+	// test server.
+	request := Request{
+		URL:        "http://hooks.example.test:" + serverURL.Port() + "/hook",
+		Secret:     "secret",
+		DeliveryId: 1,
+		EventType:  "event.created",
+		Body:       []byte(`{}`),
+	}
+	resolver := fakeResolver(netip.MustParseAddr("127.0.0.1"))
+
+	client := newClient(5*time.Second, false, resolver)
+	if _, err := client.Deliver(context.Background(), request); !errors.Is(err, ErrForbiddenDestination) {
+		t.Fatalf("Deliver = %v, want ErrForbiddenDestination", err)
+	}
+	if received != 0 {
+		t.Fatalf("server received %d requests", received)
+	}
+
+	client = newClient(5*time.Second, true, resolver)
+	if status, err := client.Deliver(context.Background(), request); err != nil || status != http.StatusOK {
+		t.Fatalf("Deliver allowing private addresses = %d, %v", status, err)
+	}
+	if received != 1 {
+		t.Fatalf("server received %d requests, want 1", received)
+	}
+}
+
+func TestDeliverSignsBody(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	client := NewClient(5*time.Second, true)
+	status, err := client.Deliver(context.Background(), Request{
+		URL:        server.URL,
+		Secret:     "secret",
+		DeliveryId: 42,
+		EventType:  "event.updated",
+		Body:       []byte(`{"type":"event.updated"}`),
+	})
+	if err != nil || status != http.StatusAccepted {
+		t.Fatalf("Deliver = %d, %v", status, err)
+	}
+
+	if string(body) != `{"type":"event.updated"}` {
+		t.Errorf("body = %s", body)
+	}
+	if h := got.Header.Get(HeaderSignature); h != Sign("secret", body) {
+		t.Errorf("signature = %s, want %s", h, Sign("secret", body))
+	}
+	if got.Header.Get(HeaderDelivery) != "42" || got.Header.Get(HeaderEvent) != "event.updated" {
+		t.Errorf("headers = %v", got.Header)
+	}
+}
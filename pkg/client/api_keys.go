@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// CreateAPIKey creates a personal API key for the logged in user. The
+// returned key is the only one carrying the key itself. API keys can't be
+// managed by a client authenticated with an API key.
+func (c *Client) CreateAPIKey(ctx context.Context, input APIKeyInput) (*APIKey, error) {
+	var apiKey APIKey
+
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/v1/api-keys",
+		body:   input,
+		auth:   true,
+		out:    &apiKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiKey, nil
+}
+
+func (c *Client) ListAPIKeys(ctx context.Context) ([]*APIKey, error) {
+	var apiKeys []*APIKey
+
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/v1/api-keys",
+		auth:   true,
+		out:    &apiKeys,
+	})
+
+	return apiKeys, err
+}
+
+// RevokeAPIKey deletes an API key, which stops working immediately.
+func (c *Client) RevokeAPIKey(ctx context.Context, id int) error {
+	return c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   pathf("/api/v1/api-keys/%s", id),
+		auth:   true,
+	})
+}
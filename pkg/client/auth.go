@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Register creates a new account. It doesn't log the client in.
+func (c *Client) Register(ctx context.Context, name, email, password string) (*User, error) {
+	var user User
+
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/v1/auth/register",
+		body:   map[string]string{"name": name, "email": email, "password": password},
+		out:    &user,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// Login authenticates the user and returns the access token, which the
+// client keeps for the next requests. The credentials are remembered so the
+// client can log in again when the token stops being accepted. The token is
+// limited to the scopes given with WithScopes, if any.
+//
+// For accounts with two-factor authentication, Login returns a
+// *TwoFactorError and the login must be completed with LoginTwoFactor. Such
+// clients can't log in again on their own.
+func (c *Client) Login(ctx context.Context, email, password string) (string, error) {
+	var response loginResponse
+
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/v1/auth/login",
+		body: map[string]any{
+			"email":    email,
+			"password": password,
+			"scopes":   c.scopes,
+		},
+		out: &response,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if response.TwoFactorRequired {
+		return "", &TwoFactorError{ChallengeToken: response.ChallengeToken}
+	}
+
+	c.mu.Lock()
+	c.token = response.Token
+	c.email = email
+	c.password = password
+	c.mu.Unlock()
+
+	return response.Token, nil
+}
+
+// LoginTwoFactor completes a login with the challenge token of a
+// *TwoFactorError and a code of the authenticator app, or a recovery code.
+func (c *Client) LoginTwoFactor(ctx context.Context, challengeToken, code string) (string, error) {
+	var response loginResponse
+
+	body := map[string]string{"challengeToken": challengeToken, "code": code}
+	if !isTOTPCode(code) {
+		body = map[string]string{"challengeToken": challengeToken, "recoveryCode": code}
+	}
+
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/v1/auth/login/2fa",
+		body:   body,
+		out:    &response,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	c.SetToken(response.Token)
+
+	return response.Token, nil
+}
+
+type loginResponse struct {
+	Token             string `json:"token"`
+	TwoFactorRequired bool   `json:"twoFactorRequired"`
+	ChallengeToken    string `json:"challengeToken"`
+}
+
+// isTOTPCode tells codes of authenticator apps, made of 6 digits, from
+// recovery codes.
+func isTOTPCode(code string) bool {
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) != 6 {
+		return false
+	}
+
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Logout forgets the token and credentials of the client.
+func (c *Client) Logout() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.token = ""
+	c.email = ""
+	c.password = ""
+}
+
+// ForgotPassword asks for a password reset link to be emailed to the user.
+// It succeeds whether or not the account exists.
+func (c *Client) ForgotPassword(ctx context.Context, email string) error {
+	return c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/v1/auth/forgot-password",
+		body:   map[string]string{"email": email},
+	})
+}
+
+// ResetPassword sets a new password using the token from a reset link.
+func (c *Client) ResetPassword(ctx context.Context, token, password string) error {
+	return c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/v1/auth/reset-password",
+		body:   map[string]string{"token": token, "password": password},
+	})
+}
+
+// VerifyEmail confirms an email address using the token from a verification
+// link.
+func (c *Client) VerifyEmail(ctx context.Context, token string) error {
+	return c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/v1/auth/verify-email?token=" + url.QueryEscape(token),
+	})
+}
+
+// ResendVerificationEmail sends a new verification link to the user.
+func (c *Client) ResendVerificationEmail(ctx context.Context) error {
+	return c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/v1/auth/verify-email/resend",
+		auth:   true,
+	})
+}
+
+// UnlockUser lifts the login lockout of a user. Only administrators may call
+// it.
+func (c *Client) UnlockUser(ctx context.Context, userId int) (*User, error) {
+	var user User
+
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   pathf("/api/v1/admin/users/%s/unlock", userId),
+		auth:   true,
+		out:    &user,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,306 @@
+// Package client is a Go client for the events REST API.
+//
+// A Client keeps the access token of the user it is logged in as and sends it
+// with every request that needs one, unless it was given an API key with
+// WithAPIKey, which is sent instead. When it knows the user's credentials,
+// because Login was called or WithCredentials was given, it logs in again
+// and retries once if the server rejects the token. Idempotent requests
+// (GET, PUT and DELETE) are retried with exponential backoff on network
+// errors, 429 and 5xx responses.
+//
+// Errors returned by the server are reported as *APIError values, which can
+// be compared with errors.Is against ErrNotFound, ErrUnauthorized and the
+// other sentinel errors of this package.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errDecode = errors.New("client: decoding response")
+
+// Client talks to a single instance of the API. It is safe for concurrent
+// use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	apiKey string
+	scopes []string
+
+	mu       sync.Mutex
+	token    string
+	email    string
+	password string
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used to send requests.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken sets the access token sent with authenticated requests.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithAPIKey authenticates requests with a personal API key instead of an
+// access token. The client never logs in when it has a key.
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithScopes limits the access tokens the client logs in for to the given
+// scopes, e.g. "events:read". Tokens get every scope by default.
+func WithScopes(scopes ...string) Option {
+	return func(c *Client) {
+		c.scopes = scopes
+	}
+}
+
+// WithCredentials sets the credentials used to log in when the client has no
+// token yet or the server rejects it.
+func WithCredentials(email, password string) Option {
+	return func(c *Client) {
+		c.email = email
+		c.password = password
+	}
+}
+
+// WithRetries sets how many times idempotent requests are retried and the
+// bounds of the backoff between attempts.
+func WithRetries(maxRetries int, minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.minBackoff = minBackoff
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// New returns a client for the API served at baseURL, for example
+// "http://localhost:8080".
+func New(baseURL string, options ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		maxRetries: 3,
+		minBackoff: 200 * time.Millisecond,
+		maxBackoff: 5 * time.Second,
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+// Token returns the current access token, if any.
+func (c *Client) Token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.token
+}
+
+// SetToken replaces the access token sent with authenticated requests.
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.token = token
+}
+
+func (c *Client) credentials() (string, string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.email, c.password, c.email != "" && c.password != ""
+}
+
+// request describes a call to the API.
+type request struct {
+	method string
+	path   string
+	body   any
+	auth   bool
+	// out receives the decoded response body when not nil. An io.Writer
+	// receives the body as is.
+	out any
+}
+
+// do sends the request, authenticating and retrying it as needed.
+func (c *Client) do(ctx context.Context, req request) error {
+	if req.auth && c.apiKey == "" && c.Token() == "" {
+		if err := c.refreshToken(ctx); err != nil {
+			return err
+		}
+	}
+
+	err := c.doWithRetries(ctx, req)
+
+	// The token may have expired or been revoked: log in again and retry
+	// once when the credentials are known.
+	if req.auth && c.apiKey == "" && errors.Is(err, ErrUnauthorized) {
+		if _, _, ok := c.credentials(); ok {
+			if err := c.refreshToken(ctx); err != nil {
+				return err
+			}
+
+			return c.doWithRetries(ctx, req)
+		}
+	}
+
+	return err
+}
+
+func (c *Client) refreshToken(ctx context.Context) error {
+	email, password, ok := c.credentials()
+	if !ok {
+		return ErrNoCredentials
+	}
+
+	_, err := c.Login(ctx, email, password)
+
+	return err
+}
+
+func (c *Client) doWithRetries(ctx context.Context, req request) error {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return err
+		}
+	}
+
+	idempotent := req.method == http.MethodGet || req.method == http.MethodPut || req.method == http.MethodDelete
+
+	// A body copied to a writer may have been partly written already.
+	if _, ok := req.out.(io.Writer); ok {
+		idempotent = false
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := c.send(ctx, req, body)
+		if err == nil || !idempotent || attempt >= c.maxRetries || !retryable(err) {
+			return err
+		}
+
+		wait := c.backoff(attempt)
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > wait {
+			wait = apiErr.RetryAfter
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.minBackoff << attempt
+	if wait <= 0 || wait > c.maxBackoff {
+		wait = c.maxBackoff
+	}
+
+	return wait
+}
+
+// retryable reports whether a failed attempt may succeed if tried again.
+func retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+
+	if errors.Is(err, errDecode) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// Anything else failed before a response was received.
+	return true
+}
+
+func (c *Client) send(ctx context.Context, req request, body []byte) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, c.baseURL+req.path, reader)
+	if err != nil {
+		return err
+	}
+
+	httpReq.Header.Set("Accept", "application/json")
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if req.auth && c.apiKey != "" {
+		httpReq.Header.Set("X-API-Key", c.apiKey)
+	} else if token := c.Token(); req.auth && token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return newAPIError(resp)
+	}
+
+	if req.out == nil || resp.StatusCode == http.StatusNoContent {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	if w, ok := req.out.(io.Writer); ok {
+		_, err := io.Copy(w, resp.Body)
+		return err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(req.out); err != nil {
+		return fmt.Errorf("%w: %w", errDecode, err)
+	}
+
+	return nil
+}
+
+func pathf(format string, ids ...int) string {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = strconv.Itoa(id)
+	}
+
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFailingServer answers the first failures requests with status and the
+// next ones with a JSON null, and counts the requests.
+func newFailingServer(t *testing.T, failures int32, status int, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if calls.Add(1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(`{"error":"` + http.StatusText(status) + `"}`))
+			return
+		}
+
+		w.Write([]byte(`null`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &calls
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		failures  int32
+		status    int
+		wantErr   error
+		wantCalls int32
+	}{
+		{"GET retried on 503", http.MethodGet, 2, http.StatusServiceUnavailable, nil, 3},
+		{"PUT retried on 429", http.MethodPut, 1, http.StatusTooManyRequests, nil, 2},
+		{"DELETE retried on 500", http.MethodDelete, 1, http.StatusInternalServerError, nil, 2},
+		{"GET not retried on 404", http.MethodGet, 1, http.StatusNotFound, ErrNotFound, 1},
+		{"POST not retried on 503", http.MethodPost, 1, http.StatusServiceUnavailable, ErrServer, 1},
+		{"POST not retried on 429", http.MethodPost, 1, http.StatusTooManyRequests, ErrRateLimited, 1},
+		{"GET gives up after the retries", http.MethodGet, 10, http.StatusServiceUnavailable, ErrServer, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := newFailingServer(t, tt.failures, tt.status, "")
+			c := New(server.URL, WithRetries(3, time.Millisecond, time.Millisecond))
+
+			var out map[string]any
+			err := c.do(context.Background(), request{method: tt.method, path: "/things", body: map[string]string{}, out: &out})
+
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("got %v, want success", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("%d requests, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWriterOutputIsNotRetried(t *testing.T) {
+	server, calls := newFailingServer(t, 1, http.StatusServiceUnavailable, "")
+	c := New(server.URL, WithRetries(3, time.Millisecond, time.Millisecond))
+
+	var out bytes.Buffer
+	err := c.do(context.Background(), request{method: http.MethodGet, path: "/export", out: &out})
+	if !errors.Is(err, ErrServer) {
+		t.Fatalf("got %v, want ErrServer", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("%d requests, want 1", got)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	server, calls := newFailingServer(t, 1, http.StatusTooManyRequests, "1")
+	c := New(server.URL, WithRetries(3, time.Millisecond, time.Millisecond))
+
+	start := time.Now()
+	if _, err := c.ListEvents(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Retry-After is longer than the backoff, and wins.
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want the second of Retry-After", elapsed)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("%d requests, want 2", got)
+	}
+}
+
+func TestRetriesNetworkErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// Drop the connection without answering.
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+
+		w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(server.Close)
+
+	c := New(server.URL, WithRetries(3, time.Millisecond, time.Millisecond))
+	if _, err := c.ListEvents(context.Background()); err != nil {
+		t.Fatalf("got %v, want the request retried", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("%d requests, want 2", got)
+	}
+}
+
+func TestRetriesStopWhenContextIsCancelled(t *testing.T) {
+	server, calls := newFailingServer(t, 10, http.StatusServiceUnavailable, "")
+	c := New(server.URL, WithRetries(3, time.Hour, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.ListEvents(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want the context's error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("returned after %v, want as soon as the context is done", elapsed)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("%d requests, want 1", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := New("http://localhost", WithRetries(10, 10*time.Millisecond, 50*time.Millisecond))
+
+	want := []time.Duration{10, 20, 40, 50, 50}
+	for attempt, wait := range want {
+		if got := c.backoff(attempt); got != wait*time.Millisecond {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, wait*time.Millisecond)
+		}
+	}
+
+	// Shifting past the size of a Duration mustn't wrap around.
+	if got := c.backoff(80); got != 50*time.Millisecond {
+		t.Errorf("backoff(80) = %v, want the maximum", got)
+	}
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Sentinel errors an *APIError matches with errors.Is, depending on its
+// status code.
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrLocked       = errors.New("account locked")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrServer       = errors.New("server error")
+)
+
+// ErrNoCredentials is returned when a request needs authentication but the
+// client has neither a token nor credentials to log in with.
+var ErrNoCredentials = errors.New("client: no access token or credentials")
+
+// TwoFactorError is returned by Login when the account has two-factor
+// authentication enabled. The login is completed by LoginTwoFactor with the
+// challenge token and a code.
+type TwoFactorError struct {
+	ChallengeToken string
+}
+
+func (e *TwoFactorError) Error() string {
+	return "client: two-factor authentication code required"
+}
+
+// APIError is an error response from the API.
+type APIError struct {
+	StatusCode int
+	// Message is the error reported by the server.
+	Message string
+	// RetryAfter is how long the server asked to wait before trying again,
+	// for 423 and 429 responses.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return "api: " + http.StatusText(e.StatusCode)
+	}
+
+	return "api: " + e.Message
+}
+
+// Is makes errors.Is(err, ErrNotFound) and the like work on API errors.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrLocked:
+		return e.StatusCode == http.StatusLocked
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= 500
+	}
+
+	return false
+}
+
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	var body struct {
+		Error string `json:"error"`
+	}
+	if data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10)); err == nil {
+		if json.Unmarshal(data, &body) == nil {
+			apiErr.Message = body.Error
+		}
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return apiErr
+}
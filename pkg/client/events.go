@@ -0,0 +1,159 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func (c *Client) ListEvents(ctx context.Context) ([]*Event, error) {
+	var events []*Event
+
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/v1/events",
+		out:    &events,
+	})
+
+	return events, err
+}
+
+func (c *Client) GetEvent(ctx context.Context, id int) (*Event, error) {
+	var event Event
+
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   pathf("/api/v1/events/%s", id),
+		out:    &event,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// CreateEvent creates an event owned by the logged in user.
+func (c *Client) CreateEvent(ctx context.Context, input EventInput) (*Event, error) {
+	var event Event
+
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/v1/events",
+		body:   input,
+		auth:   true,
+		out:    &event,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// UpdateEvent replaces every field of an event owned by the logged in user.
+func (c *Client) UpdateEvent(ctx context.Context, id int, input EventInput) (*Event, error) {
+	var event Event
+
+	err := c.do(ctx, request{
+		method: http.MethodPut,
+		path:   pathf("/api/v1/events/%s", id),
+		body:   input,
+		auth:   true,
+		out:    &event,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+func (c *Client) DeleteEvent(ctx context.Context, id int) error {
+	return c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   pathf("/api/v1/events/%s", id),
+		auth:   true,
+	})
+}
+
+// ListAttendees returns the users attending an event.
+func (c *Client) ListAttendees(ctx context.Context, eventId int) ([]*User, error) {
+	var users []*User
+
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   pathf("/api/v1/events/%s/attendees", eventId),
+		out:    &users,
+	})
+
+	return users, err
+}
+
+// ListEventsByAttendee returns the events a user attends.
+func (c *Client) ListEventsByAttendee(ctx context.Context, userId int) ([]*Event, error) {
+	var events []*Event
+
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   pathf("/api/v1/attendees/%s/events", userId),
+		out:    &events,
+	})
+
+	return events, err
+}
+
+// AddAttendee adds a user to an event owned by the logged in user.
+func (c *Client) AddAttendee(ctx context.Context, eventId, userId int) (*Attendee, error) {
+	var attendee Attendee
+
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   pathf("/api/v1/events/%s/attendees/%s", eventId, userId),
+		auth:   true,
+		out:    &attendee,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &attendee, nil
+}
+
+// RemoveAttendee removes a user from an event owned by the logged in user.
+func (c *Client) RemoveAttendee(ctx context.Context, eventId, userId int) error {
+	return c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   pathf("/api/v1/events/%s/attendees/%s", eventId, userId),
+		auth:   true,
+	})
+}
+
+// ListMessages returns up to limit chat messages of an event older than the
+// message with id before, newest first. Zero values use the server defaults.
+func (c *Client) ListMessages(ctx context.Context, eventId, before, limit int) ([]*Message, error) {
+	query := url.Values{}
+	if before > 0 {
+		query.Set("before", strconv.Itoa(before))
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	path := pathf("/api/v1/events/%s/messages", eventId)
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var messages []*Message
+
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   path,
+		auth:   true,
+		out:    &messages,
+	})
+
+	return messages, err
+}
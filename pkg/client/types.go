@@ -0,0 +1,114 @@
+package client
+
+import "time"
+
+type User struct {
+	Id            int    `json:"id"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	Role          string `json:"role,omitempty"`
+	EmailVerified bool   `json:"emailVerified,omitempty"`
+}
+
+// Profile is the public part of a user's account.
+type Profile struct {
+	Id     int      `json:"id"`
+	Name   string   `json:"name"`
+	Events []*Event `json:"events"`
+}
+
+// DeleteAccountInput confirms the deletion of an account. Events is
+// "transfer", to give the user's events to the user TransferTo, or "delete".
+// Code is needed when two-factor authentication is enabled.
+type DeleteAccountInput struct {
+	Password   string `json:"password"`
+	Events     string `json:"events"`
+	TransferTo int    `json:"transferTo,omitempty"`
+	Code       string `json:"code,omitempty"`
+}
+
+type Event struct {
+	Id          int       `json:"id"`
+	OwnerId     int       `json:"ownerId"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Date        time.Time `json:"date"`
+	Location    string    `json:"location"`
+}
+
+// EventInput holds the fields of an event that can be set when creating or
+// updating it.
+type EventInput struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Date        time.Time `json:"date"`
+	Location    string    `json:"location"`
+}
+
+type Attendee struct {
+	Id      int `json:"id"`
+	UserId  int `json:"userId"`
+	EventId int `json:"eventId"`
+}
+
+type Message struct {
+	Id        int       `json:"id"`
+	EventId   int       `json:"eventId"`
+	UserId    int       `json:"userId"`
+	UserName  string    `json:"userName"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Webhook struct {
+	Id         int       `json:"id"`
+	UserId     int       `json:"userId"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"eventTypes"`
+	CreatedAt  time.Time `json:"createdAt"`
+	// Secret is only set on the webhook returned by CreateWebhook.
+	Secret string `json:"secret,omitempty"`
+}
+
+// WebhookInput describes a webhook to create. The server generates a secret
+// when Secret is empty.
+type WebhookInput struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret,omitempty"`
+	EventTypes []string `json:"eventTypes"`
+}
+
+type APIKey struct {
+	Id         int        `json:"id"`
+	UserId     int        `json:"userId"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	// Key is only set on the key returned by CreateAPIKey.
+	Key string `json:"key,omitempty"`
+}
+
+// APIKeyInput describes an API key to create. Keys without ExpiresAt never
+// expire.
+type APIKeyInput struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type WebhookDelivery struct {
+	Id             int        `json:"id"`
+	WebhookId      int        `json:"webhookId"`
+	EventType      string     `json:"eventType"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus *int       `json:"responseStatus"`
+	LastError      *string    `json:"lastError"`
+	NextAttemptAt  *time.Time `json:"nextAttemptAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	DeliveredAt    *time.Time `json:"deliveredAt"`
+}
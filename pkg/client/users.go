@@ -0,0 +1,140 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Me returns the account of the logged in user.
+func (c *Client) Me(ctx context.Context) (*User, error) {
+	var user User
+
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/v1/users/me",
+		auth:   true,
+		out:    &user,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// UpdateName changes the name of the logged in user.
+func (c *Client) UpdateName(ctx context.Context, name string) (*User, error) {
+	var user User
+
+	err := c.do(ctx, request{
+		method: http.MethodPatch,
+		path:   "/api/v1/users/me",
+		body:   map[string]string{"name": name},
+		auth:   true,
+		out:    &user,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// ChangePassword replaces the password of the logged in user. The server
+// revokes every token of the user, so the client switches to the new token it
+// returns.
+func (c *Client) ChangePassword(ctx context.Context, currentPassword, newPassword string) error {
+	var response loginResponse
+
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/v1/users/me/password",
+		body:   map[string]string{"currentPassword": currentPassword, "newPassword": newPassword},
+		auth:   true,
+		out:    &response,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.token = response.Token
+	if c.password != "" {
+		c.password = newPassword
+	}
+	c.mu.Unlock()
+
+	return nil
+}
+
+// ChangeEmail replaces the email of the logged in user, who has to verify
+// the new address with the link sent to it.
+func (c *Client) ChangeEmail(ctx context.Context, email, password string) (*User, error) {
+	var user User
+
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/v1/users/me/email",
+		body:   map[string]string{"email": email, "password": password},
+		auth:   true,
+		out:    &user,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.email != "" {
+		c.email = email
+	}
+	c.mu.Unlock()
+
+	return &user, nil
+}
+
+// GetProfile returns the public profile of a user.
+func (c *Client) GetProfile(ctx context.Context, userId int) (*Profile, error) {
+	var profile Profile
+
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   pathf("/api/v1/users/%s", userId),
+		out:    &profile,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+// ExportPersonalData writes everything the API holds about the logged in
+// user to w, as a JSON document or, with format "zip", a ZIP archive.
+func (c *Client) ExportPersonalData(ctx context.Context, w io.Writer, format string) error {
+	return c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/v1/users/me/export?format=" + url.QueryEscape(format),
+		auth:   true,
+		out:    w,
+	})
+}
+
+// DeleteAccount deletes the account of the logged in user and logs the client
+// out.
+func (c *Client) DeleteAccount(ctx context.Context, input DeleteAccountInput) error {
+	err := c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   "/api/v1/users/me",
+		body:   input,
+		auth:   true,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.Logout()
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// CreateWebhook subscribes a URL to changes on the logged in user's events.
+// The returned webhook is the only one carrying its secret.
+func (c *Client) CreateWebhook(ctx context.Context, input WebhookInput) (*Webhook, error) {
+	var webhook Webhook
+
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/v1/webhooks",
+		body:   input,
+		auth:   true,
+		out:    &webhook,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+func (c *Client) ListWebhooks(ctx context.Context) ([]*Webhook, error) {
+	var webhooks []*Webhook
+
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/v1/webhooks",
+		auth:   true,
+		out:    &webhooks,
+	})
+
+	return webhooks, err
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, id int) error {
+	return c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   pathf("/api/v1/webhooks/%s", id),
+		auth:   true,
+	})
+}
+
+// ListWebhookDeliveries returns the delivery log of a webhook, most recent
+// first.
+func (c *Client) ListWebhookDeliveries(ctx context.Context, webhookId int) ([]*WebhookDelivery, error) {
+	var deliveries []*WebhookDelivery
+
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   pathf("/api/v1/webhooks/%s/deliveries", webhookId),
+		auth:   true,
+		out:    &deliveries,
+	})
+
+	return deliveries, err
+}
+
+// Redeliver queues a delivery to be sent again.
+func (c *Client) Redeliver(ctx context.Context, webhookId, deliveryId int) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   pathf("/api/v1/webhooks/%s/deliveries/%s/redeliver", webhookId, deliveryId),
+		auth:   true,
+		out:    &delivery,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
@@ -0,0 +1,497 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: events/v1/attendees.proto
+
+package eventsv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Attendee struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	EventId       int64                  `protobuf:"varint,2,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	UserId        int64                  `protobuf:"varint,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Attendee) Reset() {
+	*x = Attendee{}
+	mi := &file_events_v1_attendees_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Attendee) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Attendee) ProtoMessage() {}
+
+func (x *Attendee) ProtoReflect() protoreflect.Message {
+	mi := &file_events_v1_attendees_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Attendee.ProtoReflect.Descriptor instead.
+func (*Attendee) Descriptor() ([]byte, []int) {
+	return file_events_v1_attendees_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Attendee) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Attendee) GetEventId() int64 {
+	if x != nil {
+		return x.EventId
+	}
+	return 0
+}
+
+func (x *Attendee) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type ListEventAttendeesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       int64                  `protobuf:"varint,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListEventAttendeesRequest) Reset() {
+	*x = ListEventAttendeesRequest{}
+	mi := &file_events_v1_attendees_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListEventAttendeesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListEventAttendeesRequest) ProtoMessage() {}
+
+func (x *ListEventAttendeesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_events_v1_attendees_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListEventAttendeesRequest.ProtoReflect.Descriptor instead.
+func (*ListEventAttendeesRequest) Descriptor() ([]byte, []int) {
+	return file_events_v1_attendees_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListEventAttendeesRequest) GetEventId() int64 {
+	if x != nil {
+		return x.EventId
+	}
+	return 0
+}
+
+type ListEventAttendeesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*User                `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListEventAttendeesResponse) Reset() {
+	*x = ListEventAttendeesResponse{}
+	mi := &file_events_v1_attendees_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListEventAttendeesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListEventAttendeesResponse) ProtoMessage() {}
+
+func (x *ListEventAttendeesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_events_v1_attendees_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListEventAttendeesResponse.ProtoReflect.Descriptor instead.
+func (*ListEventAttendeesResponse) Descriptor() ([]byte, []int) {
+	return file_events_v1_attendees_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListEventAttendeesResponse) GetUsers() []*User {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+type ListAttendeeEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAttendeeEventsRequest) Reset() {
+	*x = ListAttendeeEventsRequest{}
+	mi := &file_events_v1_attendees_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAttendeeEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAttendeeEventsRequest) ProtoMessage() {}
+
+func (x *ListAttendeeEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_events_v1_attendees_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAttendeeEventsRequest.ProtoReflect.Descriptor instead.
+func (*ListAttendeeEventsRequest) Descriptor() ([]byte, []int) {
+	return file_events_v1_attendees_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListAttendeeEventsRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type ListAttendeeEventsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*Event               `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAttendeeEventsResponse) Reset() {
+	*x = ListAttendeeEventsResponse{}
+	mi := &file_events_v1_attendees_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAttendeeEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAttendeeEventsResponse) ProtoMessage() {}
+
+func (x *ListAttendeeEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_events_v1_attendees_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAttendeeEventsResponse.ProtoReflect.Descriptor instead.
+func (*ListAttendeeEventsResponse) Descriptor() ([]byte, []int) {
+	return file_events_v1_attendees_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListAttendeeEventsResponse) GetEvents() []*Event {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type AddAttendeeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       int64                  `protobuf:"varint,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	UserId        int64                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddAttendeeRequest) Reset() {
+	*x = AddAttendeeRequest{}
+	mi := &file_events_v1_attendees_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddAttendeeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddAttendeeRequest) ProtoMessage() {}
+
+func (x *AddAttendeeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_events_v1_attendees_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddAttendeeRequest.ProtoReflect.Descriptor instead.
+func (*AddAttendeeRequest) Descriptor() ([]byte, []int) {
+	return file_events_v1_attendees_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AddAttendeeRequest) GetEventId() int64 {
+	if x != nil {
+		return x.EventId
+	}
+	return 0
+}
+
+func (x *AddAttendeeRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type RemoveAttendeeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       int64                  `protobuf:"varint,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	UserId        int64                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveAttendeeRequest) Reset() {
+	*x = RemoveAttendeeRequest{}
+	mi := &file_events_v1_attendees_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveAttendeeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveAttendeeRequest) ProtoMessage() {}
+
+func (x *RemoveAttendeeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_events_v1_attendees_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveAttendeeRequest.ProtoReflect.Descriptor instead.
+func (*RemoveAttendeeRequest) Descriptor() ([]byte, []int) {
+	return file_events_v1_attendees_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RemoveAttendeeRequest) GetEventId() int64 {
+	if x != nil {
+		return x.EventId
+	}
+	return 0
+}
+
+func (x *RemoveAttendeeRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type RemoveAttendeeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveAttendeeResponse) Reset() {
+	*x = RemoveAttendeeResponse{}
+	mi := &file_events_v1_attendees_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveAttendeeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveAttendeeResponse) ProtoMessage() {}
+
+func (x *RemoveAttendeeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_events_v1_attendees_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveAttendeeResponse.ProtoReflect.Descriptor instead.
+func (*RemoveAttendeeResponse) Descriptor() ([]byte, []int) {
+	return file_events_v1_attendees_proto_rawDescGZIP(), []int{7}
+}
+
+var File_events_v1_attendees_proto protoreflect.FileDescriptor
+
+const file_events_v1_attendees_proto_rawDesc = "" +
+	"\n" +
+	"\x19events/v1/attendees.proto\x12\tevents.v1\x1a\x16events/v1/events.proto\x1a\x15events/v1/users.proto\"N\n" +
+	"\bAttendee\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x19\n" +
+	"\bevent_id\x18\x02 \x01(\x03R\aeventId\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\x03R\x06userId\"6\n" +
+	"\x19ListEventAttendeesRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\x03R\aeventId\"C\n" +
+	"\x1aListEventAttendeesResponse\x12%\n" +
+	"\x05users\x18\x01 \x03(\v2\x0f.events.v1.UserR\x05users\"4\n" +
+	"\x19ListAttendeeEventsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\"F\n" +
+	"\x1aListAttendeeEventsResponse\x12(\n" +
+	"\x06events\x18\x01 \x03(\v2\x10.events.v1.EventR\x06events\"H\n" +
+	"\x12AddAttendeeRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\x03R\aeventId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x03R\x06userId\"K\n" +
+	"\x15RemoveAttendeeRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\x03R\aeventId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x03R\x06userId\"\x18\n" +
+	"\x16RemoveAttendeeResponse2\xf1\x02\n" +
+	"\x0fAttendeeService\x12a\n" +
+	"\x12ListEventAttendees\x12$.events.v1.ListEventAttendeesRequest\x1a%.events.v1.ListEventAttendeesResponse\x12a\n" +
+	"\x12ListAttendeeEvents\x12$.events.v1.ListAttendeeEventsRequest\x1a%.events.v1.ListAttendeeEventsResponse\x12A\n" +
+	"\vAddAttendee\x12\x1d.events.v1.AddAttendeeRequest\x1a\x13.events.v1.Attendee\x12U\n" +
+	"\x0eRemoveAttendee\x12 .events.v1.RemoveAttendeeRequest\x1a!.events.v1.RemoveAttendeeResponseB>Z<github.com/gumeeee/rest-api-in-gin/pkg/pb/events/v1;eventsv1b\x06proto3"
+
+var (
+	file_events_v1_attendees_proto_rawDescOnce sync.Once
+	file_events_v1_attendees_proto_rawDescData []byte
+)
+
+func file_events_v1_attendees_proto_rawDescGZIP() []byte {
+	file_events_v1_attendees_proto_rawDescOnce.Do(func() {
+		file_events_v1_attendees_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_events_v1_attendees_proto_rawDesc), len(file_events_v1_attendees_proto_rawDesc)))
+	})
+	return file_events_v1_attendees_proto_rawDescData
+}
+
+var file_events_v1_attendees_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_events_v1_attendees_proto_goTypes = []any{
+	(*Attendee)(nil),                   // 0: events.v1.Attendee
+	(*ListEventAttendeesRequest)(nil),  // 1: events.v1.ListEventAttendeesRequest
+	(*ListEventAttendeesResponse)(nil), // 2: events.v1.ListEventAttendeesResponse
+	(*ListAttendeeEventsRequest)(nil),  // 3: events.v1.ListAttendeeEventsRequest
+	(*ListAttendeeEventsResponse)(nil), // 4: events.v1.ListAttendeeEventsResponse
+	(*AddAttendeeRequest)(nil),         // 5: events.v1.AddAttendeeRequest
+	(*RemoveAttendeeRequest)(nil),      // 6: events.v1.RemoveAttendeeRequest
+	(*RemoveAttendeeResponse)(nil),     // 7: events.v1.RemoveAttendeeResponse
+	(*User)(nil),                       // 8: events.v1.User
+	(*Event)(nil),                      // 9: events.v1.Event
+}
+var file_events_v1_attendees_proto_depIdxs = []int32{
+	8, // 0: events.v1.ListEventAttendeesResponse.users:type_name -> events.v1.User
+	9, // 1: events.v1.ListAttendeeEventsResponse.events:type_name -> events.v1.Event
+	1, // 2: events.v1.AttendeeService.ListEventAttendees:input_type -> events.v1.ListEventAttendeesRequest
+	3, // 3: events.v1.AttendeeService.ListAttendeeEvents:input_type -> events.v1.ListAttendeeEventsRequest
+	5, // 4: events.v1.AttendeeService.AddAttendee:input_type -> events.v1.AddAttendeeRequest
+	6, // 5: events.v1.AttendeeService.RemoveAttendee:input_type -> events.v1.RemoveAttendeeRequest
+	2, // 6: events.v1.AttendeeService.ListEventAttendees:output_type -> events.v1.ListEventAttendeesResponse
+	4, // 7: events.v1.AttendeeService.ListAttendeeEvents:output_type -> events.v1.ListAttendeeEventsResponse
+	0, // 8: events.v1.AttendeeService.AddAttendee:output_type -> events.v1.Attendee
+	7, // 9: events.v1.AttendeeService.RemoveAttendee:output_type -> events.v1.RemoveAttendeeResponse
+	6, // [6:10] is the sub-list for method output_type
+	2, // [2:6] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_events_v1_attendees_proto_init() }
+func file_events_v1_attendees_proto_init() {
+	if File_events_v1_attendees_proto != nil {
+		return
+	}
+	file_events_v1_events_proto_init()
+	file_events_v1_users_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_events_v1_attendees_proto_rawDesc), len(file_events_v1_attendees_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_events_v1_attendees_proto_goTypes,
+		DependencyIndexes: file_events_v1_attendees_proto_depIdxs,
+		MessageInfos:      file_events_v1_attendees_proto_msgTypes,
+	}.Build()
+	File_events_v1_attendees_proto = out.File
+	file_events_v1_attendees_proto_goTypes = nil
+	file_events_v1_attendees_proto_depIdxs = nil
+}
@@ -0,0 +1,241 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: events/v1/attendees.proto
+
+package eventsv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AttendeeService_ListEventAttendees_FullMethodName = "/events.v1.AttendeeService/ListEventAttendees"
+	AttendeeService_ListAttendeeEvents_FullMethodName = "/events.v1.AttendeeService/ListAttendeeEvents"
+	AttendeeService_AddAttendee_FullMethodName        = "/events.v1.AttendeeService/AddAttendee"
+	AttendeeService_RemoveAttendee_FullMethodName     = "/events.v1.AttendeeService/RemoveAttendee"
+)
+
+// AttendeeServiceClient is the client API for AttendeeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// AttendeeService manages who attends which event. Listing is public; adding
+// and removing attendees requires a bearer token of the event's owner.
+type AttendeeServiceClient interface {
+	ListEventAttendees(ctx context.Context, in *ListEventAttendeesRequest, opts ...grpc.CallOption) (*ListEventAttendeesResponse, error)
+	ListAttendeeEvents(ctx context.Context, in *ListAttendeeEventsRequest, opts ...grpc.CallOption) (*ListAttendeeEventsResponse, error)
+	AddAttendee(ctx context.Context, in *AddAttendeeRequest, opts ...grpc.CallOption) (*Attendee, error)
+	RemoveAttendee(ctx context.Context, in *RemoveAttendeeRequest, opts ...grpc.CallOption) (*RemoveAttendeeResponse, error)
+}
+
+type attendeeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAttendeeServiceClient(cc grpc.ClientConnInterface) AttendeeServiceClient {
+	return &attendeeServiceClient{cc}
+}
+
+func (c *attendeeServiceClient) ListEventAttendees(ctx context.Context, in *ListEventAttendeesRequest, opts ...grpc.CallOption) (*ListEventAttendeesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListEventAttendeesResponse)
+	err := c.cc.Invoke(ctx, AttendeeService_ListEventAttendees_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *attendeeServiceClient) ListAttendeeEvents(ctx context.Context, in *ListAttendeeEventsRequest, opts ...grpc.CallOption) (*ListAttendeeEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAttendeeEventsResponse)
+	err := c.cc.Invoke(ctx, AttendeeService_ListAttendeeEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *attendeeServiceClient) AddAttendee(ctx context.Context, in *AddAttendeeRequest, opts ...grpc.CallOption) (*Attendee, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Attendee)
+	err := c.cc.Invoke(ctx, AttendeeService_AddAttendee_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *attendeeServiceClient) RemoveAttendee(ctx context.Context, in *RemoveAttendeeRequest, opts ...grpc.CallOption) (*RemoveAttendeeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveAttendeeResponse)
+	err := c.cc.Invoke(ctx, AttendeeService_RemoveAttendee_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AttendeeServiceServer is the server API for AttendeeService service.
+// All implementations must embed UnimplementedAttendeeServiceServer
+// for forward compatibility.
+//
+// AttendeeService manages who attends which event. Listing is public; adding
+// and removing attendees requires a bearer token of the event's owner.
+type AttendeeServiceServer interface {
+	ListEventAttendees(context.Context, *ListEventAttendeesRequest) (*ListEventAttendeesResponse, error)
+	ListAttendeeEvents(context.Context, *ListAttendeeEventsRequest) (*ListAttendeeEventsResponse, error)
+	AddAttendee(context.Context, *AddAttendeeRequest) (*Attendee, error)
+	RemoveAttendee(context.Context, *RemoveAttendeeRequest) (*RemoveAttendeeResponse, error)
+	mustEmbedUnimplementedAttendeeServiceServer()
+}
+
+// UnimplementedAttendeeServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAttendeeServiceServer struct{}
+
+func (UnimplementedAttendeeServiceServer) ListEventAttendees(context.Context, *ListEventAttendeesRequest) (*ListEventAttendeesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEventAttendees not implemented")
+}
+func (UnimplementedAttendeeServiceServer) ListAttendeeEvents(context.Context, *ListAttendeeEventsRequest) (*ListAttendeeEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAttendeeEvents not implemented")
+}
+func (UnimplementedAttendeeServiceServer) AddAttendee(context.Context, *AddAttendeeRequest) (*Attendee, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddAttendee not implemented")
+}
+func (UnimplementedAttendeeServiceServer) RemoveAttendee(context.Context, *RemoveAttendeeRequest) (*RemoveAttendeeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveAttendee not implemented")
+}
+func (UnimplementedAttendeeServiceServer) mustEmbedUnimplementedAttendeeServiceServer() {}
+func (UnimplementedAttendeeServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeAttendeeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AttendeeServiceServer will
+// result in compilation errors.
+type UnsafeAttendeeServiceServer interface {
+	mustEmbedUnimplementedAttendeeServiceServer()
+}
+
+func RegisterAttendeeServiceServer(s grpc.ServiceRegistrar, srv AttendeeServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAttendeeServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AttendeeService_ServiceDesc, srv)
+}
+
+func _AttendeeService_ListEventAttendees_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEventAttendeesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AttendeeServiceServer).ListEventAttendees(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AttendeeService_ListEventAttendees_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AttendeeServiceServer).ListEventAttendees(ctx, req.(*ListEventAttendeesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AttendeeService_ListAttendeeEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAttendeeEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AttendeeServiceServer).ListAttendeeEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AttendeeService_ListAttendeeEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AttendeeServiceServer).ListAttendeeEvents(ctx, req.(*ListAttendeeEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AttendeeService_AddAttendee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddAttendeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AttendeeServiceServer).AddAttendee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AttendeeService_AddAttendee_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AttendeeServiceServer).AddAttendee(ctx, req.(*AddAttendeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AttendeeService_RemoveAttendee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveAttendeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AttendeeServiceServer).RemoveAttendee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AttendeeService_RemoveAttendee_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AttendeeServiceServer).RemoveAttendee(ctx, req.(*RemoveAttendeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AttendeeService_ServiceDesc is the grpc.ServiceDesc for AttendeeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AttendeeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "events.v1.AttendeeService",
+	HandlerType: (*AttendeeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListEventAttendees",
+			Handler:    _AttendeeService_ListEventAttendees_Handler,
+		},
+		{
+			MethodName: "ListAttendeeEvents",
+			Handler:    _AttendeeService_ListAttendeeEvents_Handler,
+		},
+		{
+			MethodName: "AddAttendee",
+			Handler:    _AttendeeService_AddAttendee_Handler,
+		},
+		{
+			MethodName: "RemoveAttendee",
+			Handler:    _AttendeeService_RemoveAttendee_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "events/v1/attendees.proto",
+}
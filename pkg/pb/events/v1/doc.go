@@ -0,0 +1,5 @@
+// Package eventsv1 contains the messages and gRPC services generated from the
+// protobuf definitions in proto/events/v1.
+package eventsv1
+
+//go:generate protoc -I ../../../../proto --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative events/v1/users.proto events/v1/events.proto events/v1/attendees.proto